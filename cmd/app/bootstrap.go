@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/BootstrapService"
+)
+
+// runBootstrap writes <service>.bootstrap files, e.g. to bake them into images for starts without FeatureChaos
+func runBootstrap(bootstrap BootstrapService.Interface, args []string) error {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	services := fs.String("service", "", "comma separated service names")
+	out := fs.String("out", ".", "directory for the bootstrap files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *services == "" {
+		return errors.New("bootstrap: -service is required")
+	}
+
+	for _, name := range strings.Split(*services, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		file, version, err := bootstrap.Build(context.Background(), name)
+		if err != nil {
+			return fmt.Errorf("bootstrap %s: %w", name, err)
+		}
+
+		// Services of other projects ("<project>/<service>") land in a directory per project
+		path := filepath.Join(*out, name+".bootstrap")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, file, 0o644); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: version %d -> %s\n", name, version, path)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,14 +11,32 @@ import (
 	"gitlab.com/devpro_studio/FeatureChaos/src/controller/AdminHTTP"
 	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
 	"gitlab.com/devpro_studio/FeatureChaos/src/controller/PublicHTTP"
+	"gitlab.com/devpro_studio/FeatureChaos/src/metrics"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ChangeRequestRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ClientRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ExperimentRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureKeyRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/HealthRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/RelayRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ServiceAccessRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/StatsRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/WebhookRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/BootstrapService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ChangeRequestService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/EvaluationService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ExperimentService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/WebhookService"
 	"gitlab.com/devpro_studio/Paranoia/paranoia"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/pkg/cache/memory"
@@ -30,6 +49,12 @@ import (
 )
 
 func main() {
+	// "bootstrap" is a one-shot command against the same configuration, no servers are started for it
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
 	s := paranoia.New("feature chaos", "cfg.yaml")
 
 	cfg := s.GetConfig()
@@ -42,21 +67,79 @@ func main() {
 		s.PushPkg(std_log.New("std"))
 	}
 
-	s.PushPkg(memory.New(names.CacheMemory)).
-		PushPkg(redis.New(names.CacheRedis)).
-		PushPkg(postgres.New(names.DatabasePrimary)).
-		PushModule(FeatureRepository.New(names.FeatureRepository)).
-		PushModule(FeatureParamRepository.New(names.FeatureParamRepository)).
-		PushModule(FeatureKeyRepository.New(names.FeatureKeyRepository)).
-		PushModule(ActivationValuesRepository.New(names.ActivationValuesRepository)).
-		PushModule(ServiceAccessRepository.New(names.ServiceAccessRepository)).
-		PushModule(StatsRepository.New(names.StatsRepository)).
-		PushModule(FeatureService.New(names.FeatureService)).
-		PushModule(StatsService.New(names.StatsService))
+	// Relay mode mirrors an upstream FeatureChaos in memory, it needs no Postgres or Redis
+	relay := len(cfg.GetConfigItem(interfaces.PkgClient, names.UpstreamClient)) > 0
+
+	if relay {
+		s.PushModule(metrics.NewValues(RelayRepository.NewValues(names.ActivationValuesRepository))).
+			PushModule(RelayRepository.NewStats(names.StatsRepository)).
+			PushModule(metrics.NewFeatures(FeatureService.New(names.FeatureService))).
+			PushModule(metrics.NewStats(StatsService.New(names.StatsService)))
+	} else {
+		s.PushPkg(memory.New(names.CacheMemory)).
+			PushPkg(postgres.New(names.DatabasePrimary))
+
+		if len(cfg.GetConfigItem(interfaces.PkgDatabase, names.DatabaseReplica)) > 0 {
+			s.PushPkg(postgres.New(names.DatabaseReplica))
+		}
+
+		// Redis is optional: without its entry the version and stats live in Postgres, hot data in the memory cache
+		if len(cfg.GetConfigItem(interfaces.PkgCache, names.CacheRedis)) > 0 {
+			s.PushPkg(redis.New(names.CacheRedis)).
+				PushModule(VersionRepository.New(names.VersionRepository)).
+				PushModule(StatsRepository.New(names.StatsRepository))
+		} else {
+			s.PushModule(VersionRepository.NewPostgres(names.VersionRepository)).
+				PushModule(StatsRepository.NewPostgres(names.StatsRepository))
+		}
+
+		s.PushModule(ProjectRepository.New(names.ProjectRepository)).
+			PushModule(FeatureRepository.New(names.FeatureRepository)).
+			PushModule(FeatureParamRepository.New(names.FeatureParamRepository)).
+			PushModule(FeatureKeyRepository.New(names.FeatureKeyRepository)).
+			PushModule(metrics.NewValues(ActivationValuesRepository.New(names.ActivationValuesRepository))).
+			PushModule(ServiceAccessRepository.New(names.ServiceAccessRepository)).
+			PushModule(LayerRepository.New(names.LayerRepository)).
+			PushModule(ExperimentRepository.New(names.ExperimentRepository)).
+			PushModule(ChangeRequestRepository.New(names.ChangeRequestRepository)).
+			PushModule(WebhookRepository.New(names.WebhookRepository)).
+			PushModule(ClientRepository.New(names.ClientRepository)).
+			PushModule(HealthRepository.New(names.HealthRepository)).
+			PushModule(metrics.NewFeatures(FeatureService.New(names.FeatureService))).
+			PushModule(metrics.NewStats(StatsService.New(names.StatsService))).
+			PushModule(ExperimentService.New(names.ExperimentService)).
+			PushModule(ChangeRequestService.New(names.ChangeRequestService)).
+			PushModule(WebhookService.New(names.WebhookService)).
+			PushModule(SignService.New(names.SignService)).
+			PushModule(EvaluationService.New(names.EvaluationService)).
+			PushModule(BootstrapService.New(names.BootstrapService)).
+			PushModule(ClientService.New(names.ClientService))
+	}
+
+	s.PushModule(HealthService.New(names.HealthService))
+
+	if command == "bootstrap" {
+		if err := s.Init(); err != nil {
+			panic(err)
+		}
+
+		err := runBootstrap(s.GetModule(interfaces.ModuleService, names.BootstrapService).(BootstrapService.Interface), os.Args[2:])
+		_ = s.Stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if len(cfg.GetConfigItem(interfaces.PkgServer, names.HttpPublicServer)) > 0 {
-		s.PushPkg(httpSrv.New(names.HttpPublicServer)).
-			PushModule(PublicHTTP.New(names.PublicHTTP))
+		// A relay has no admin server, its metrics go out on the public one
+		if relay {
+			s.PushPkg(metrics.NewPublicServer(httpSrv.New(names.HttpPublicServer)))
+		} else {
+			s.PushPkg(httpSrv.New(names.HttpPublicServer))
+		}
+		s.PushModule(PublicHTTP.New(names.PublicHTTP))
 	}
 
 	if len(cfg.GetConfigItem(interfaces.PkgServer, names.GrpcServer)) > 0 {
@@ -64,8 +147,8 @@ func main() {
 			PushModule(FeatureChaos.NewController(names.FeatureChaosController))
 	}
 
-	if len(cfg.GetConfigItem(interfaces.PkgServer, names.HttpServer)) > 0 {
-		s.PushPkg(httpSrv.New(names.HttpServer)).
+	if !relay && len(cfg.GetConfigItem(interfaces.PkgServer, names.HttpServer)) > 0 {
+		s.PushPkg(metrics.NewServer(httpSrv.New(names.HttpServer))).
 			PushModule(AdminHTTP.New(names.AdminHTTP))
 	}
 
@@ -82,4 +165,15 @@ func main() {
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	<-stop
+
+	// Open streams move to other instances before the servers stop, a second signal cuts the drain short
+	c, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	s.GetLogger().Info(c, "draining update streams")
+	s.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface).Drain(c)
+	cancel()
 }
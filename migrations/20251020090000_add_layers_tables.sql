@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+create table layers
+(
+    id uuid primary key,
+    name varchar(255) not null unique,
+    salt varchar(255) not null,
+    description text,
+    created_at timestamp not null default now()
+);
+
+-- Bucket range [bucket_from, bucket_to) owned by an experiment (feature) inside a layer
+create table layer_allocations
+(
+    id uuid primary key,
+    layer_id uuid not null references layers(id) on delete cascade,
+    feature_id uuid not null references features(id),
+    bucket_from smallint not null,
+    bucket_to smallint not null,
+    constraint chk_layer_allocations_range check (bucket_from >= 0 and bucket_to <= 100 and bucket_from < bucket_to)
+);
+
+create unique index ux_layer_allocations_feature on layer_allocations(feature_id);
+create index idx_layer_allocations_layer_id on layer_allocations(layer_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table layer_allocations;
+
+drop table layers;
+-- +goose StatementEnd
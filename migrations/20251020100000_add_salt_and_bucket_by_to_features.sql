@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Hash salt of the feature, initialised from the name and kept across renames
+alter table features
+add column if not exists salt varchar(255);
+
+update features set salt = name where salt is null;
+
+alter table features alter column salt set not null;
+
+-- Context attribute the SDK hashes for bucketing, empty means the caller provided seed
+alter table features
+add column if not exists bucket_by varchar(255) not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table features drop column if exists bucket_by;
+
+alter table features drop column if exists salt;
+-- +goose StatementEnd
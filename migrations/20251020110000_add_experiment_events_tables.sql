@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+create table experiment_exposures
+(
+    id uuid primary key,
+    feature_name varchar(255) not null,
+    variant varchar(255) not null,
+    unit_id varchar(255) not null,
+    exposed_at timestamp not null default now()
+);
+
+create index idx_experiment_exposures_feature_unit on experiment_exposures(feature_name, unit_id, exposed_at);
+
+create table experiment_metrics
+(
+    id uuid primary key,
+    unit_id varchar(255) not null,
+    metric varchar(255) not null,
+    value double precision not null default 1,
+    created_at timestamp not null default now()
+);
+
+create index idx_experiment_metrics_metric_unit on experiment_metrics(metric, unit_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table experiment_metrics;
+
+drop table experiment_exposures;
+-- +goose StatementEnd
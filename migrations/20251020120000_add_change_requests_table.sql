@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Pending diffs of protected features, applied only after a second person approves them
+create table change_requests
+(
+    id uuid primary key,
+    operation varchar(32) not null,
+    target_id uuid not null,
+    feature_id uuid not null references features(id),
+    base_version bigint not null,
+    payload jsonb not null default '{}'::jsonb,
+    status varchar(16) not null default 'pending',
+    proposed_by varchar(255) not null,
+    reviewed_by varchar(255),
+    comment text not null default '',
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now()
+);
+
+create index idx_change_requests_status on change_requests(status, created_at);
+create index idx_change_requests_feature_id on change_requests(feature_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table change_requests;
+-- +goose StatementEnd
@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+create table webhooks
+(
+    id uuid primary key,
+    url text not null,
+    secret text not null,
+    -- empty filter arrays match everything
+    feature_names text[] not null default '{}',
+    service_names text[] not null default '{}',
+    event_types text[] not null default '{}',
+    is_active boolean not null default true,
+    created_at timestamp not null default now()
+);
+
+-- Changes are written here in the same transaction as the change itself
+create table webhook_outbox
+(
+    id bigserial primary key,
+    event_type varchar(32) not null,
+    feature_id uuid not null,
+    feature_name varchar(255) not null,
+    key_name varchar(255),
+    param_name varchar(255),
+    service_names text[] not null default '{}',
+    value_before integer,
+    value_after integer,
+    version bigint not null,
+    created_at timestamp not null default now(),
+    dispatched_at timestamp
+);
+
+create index idx_webhook_outbox_pending on webhook_outbox(id) where dispatched_at is null;
+
+create table webhook_deliveries
+(
+    id bigserial primary key,
+    webhook_id uuid not null references webhooks(id) on delete cascade,
+    outbox_id bigint not null references webhook_outbox(id) on delete cascade,
+    status varchar(16) not null default 'pending',
+    attempts integer not null default 0,
+    next_attempt_at timestamp not null default now(),
+    last_error text not null default '',
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now()
+);
+
+create unique index ux_webhook_deliveries_webhook_outbox on webhook_deliveries(webhook_id, outbox_id);
+create index idx_webhook_deliveries_due on webhook_deliveries(next_attempt_at) where status = 'pending';
+
+create table webhook_delivery_log
+(
+    id bigserial primary key,
+    delivery_id bigint not null references webhook_deliveries(id) on delete cascade,
+    attempt integer not null,
+    status_code integer not null default 0,
+    error text not null default '',
+    duration_ms integer not null default 0,
+    created_at timestamp not null default now()
+);
+
+create index idx_webhook_delivery_log_delivery_id on webhook_delivery_log(delivery_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table webhook_delivery_log;
+
+drop table webhook_deliveries;
+
+drop table webhook_outbox;
+
+drop table webhooks;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Features exposed to client-side evaluation, browsers only ever receive evaluated results of these
+alter table service_access add column client_side boolean not null default false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table service_access drop column client_side;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+create table projects
+(
+    id uuid primary key,
+    name varchar(255) not null unique,
+    created_at timestamp not null default now()
+);
+
+-- Existing data moves into the default project, clients sending bare service names keep resolving there
+insert into projects (id, name) values ('00000000-0000-0000-0000-000000000001', 'default');
+
+alter table features add column project_id uuid not null default '00000000-0000-0000-0000-000000000001' references projects(id);
+alter table features alter column project_id drop default;
+alter table features drop constraint features_name_key;
+create unique index ux_features_project_name on features(project_id, name);
+
+alter table services add column project_id uuid not null default '00000000-0000-0000-0000-000000000001' references projects(id);
+alter table services alter column project_id drop default;
+alter table services drop constraint services_name_key;
+create unique index ux_services_project_name on services(project_id, name);
+
+alter table layers add column project_id uuid not null default '00000000-0000-0000-0000-000000000001' references projects(id);
+alter table layers alter column project_id drop default;
+alter table layers drop constraint layers_name_key;
+create unique index ux_layers_project_name on layers(project_id, name);
+
+alter table webhooks add column project_id uuid not null default '00000000-0000-0000-0000-000000000001' references projects(id);
+alter table webhooks alter column project_id drop default;
+create index idx_webhooks_project_id on webhooks(project_id);
+
+alter table webhook_outbox add column project_id uuid not null default '00000000-0000-0000-0000-000000000001';
+alter table webhook_outbox alter column project_id drop default;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table webhook_outbox drop column project_id;
+
+drop index idx_webhooks_project_id;
+alter table webhooks drop column project_id;
+
+drop index ux_layers_project_name;
+alter table layers add constraint layers_name_key unique (name);
+alter table layers drop column project_id;
+
+drop index ux_services_project_name;
+alter table services add constraint services_name_key unique (name);
+alter table services drop column project_id;
+
+drop index ux_features_project_name;
+alter table features add constraint features_name_key unique (name);
+alter table features drop column project_id;
+
+drop table projects;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Last report of a feature or service, used instead of Redis when it is not configured
+create table usage_stats
+(
+    kind varchar(16) not null,
+    name varchar(511) not null,
+    seen_at timestamp not null,
+    primary key (kind, name)
+);
+
+-- The global version is rebuilt from MAX(v) on startup and whenever the cache lost it
+create index if not exists idx_activation_values_v on activation_values(v);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_activation_values_v;
+
+drop table usage_stats;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Binding changes are versioned like values: bound services receive the feature, unbound ones its tombstone
+alter table service_access add column v bigint not null default 0;
+alter table service_access add column deleted_at timestamp null;
+create index idx_service_access_v on service_access(v);
+
+-- Deleted services keep their tombstoned bindings until a service with the same name is created again
+alter table services add column deleted_at timestamp null;
+drop index ux_services_project_name;
+create unique index ux_services_project_name on services(project_id, name) where deleted_at is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from service_access where deleted_at is not null;
+delete from services where deleted_at is not null;
+
+drop index ux_services_project_name;
+create unique index ux_services_project_name on services(project_id, name);
+alter table services drop column deleted_at;
+
+drop index idx_service_access_v;
+alter table service_access drop column deleted_at;
+alter table service_access drop column v;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Old names of renamed features, keys and params; subscribers receive them as deletions in the rename's version
+create table renames
+(
+    id uuid primary key,
+    kind smallint not null,
+    feature_id uuid not null references features(id),
+    key_id uuid null references activation_keys(id),
+    feature_name varchar(255) not null,
+    key_name varchar(255) null,
+    param_name varchar(255) null,
+    v bigint not null,
+    created_at timestamp not null default now()
+);
+
+create index idx_renames_feature_v on renames(feature_id, v);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table renames;
+-- +goose StatementEnd
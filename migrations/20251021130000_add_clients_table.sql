@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SDK instances that subscribed or polled recently, every server instance writes the clients it serves
+create table clients
+(
+    service_name varchar(511) not null,
+    instance_id varchar(255) not null,
+    hostname varchar(255) not null default '',
+    sdk_name varchar(64) not null default '',
+    sdk_version varchar(64) not null default '',
+    labels jsonb not null default '{}',
+    transport varchar(16) not null,
+    version bigint not null,
+    version_at timestamp not null,
+    streaming boolean not null default false,
+    connected_at timestamp null,
+    seen_at timestamp not null,
+    primary key (service_name, instance_id)
+);
+
+create index idx_clients_seen_at on clients(seen_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table clients;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Versions come from a sequence: a number taken by a transaction that wrote nothing or rolled back is never handed
+-- out again, so subscribers that already saw it cannot miss the write that would reuse it
+create sequence feature_version_seq;
+select setval('feature_version_seq', greatest(
+    (select coalesce(max(v), 0) from activation_values),
+    (select coalesce(max(v), 0) from service_access)
+) + 1, false);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop sequence feature_version_seq;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Feature names repeat across projects, exposures belong to the feature they were resolved to at ingestion
+alter table experiment_exposures add column feature_id uuid;
+
+-- Before projects every feature lived in the default one
+update experiment_exposures e
+set feature_id = f.id
+from features f
+where f.name = e.feature_name
+  and f.project_id = '00000000-0000-0000-0000-000000000001';
+
+drop index idx_experiment_exposures_feature_unit;
+create index idx_experiment_exposures_feature_unit on experiment_exposures(feature_id, unit_id, exposed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index idx_experiment_exposures_feature_unit;
+create index idx_experiment_exposures_feature_unit on experiment_exposures(feature_name, unit_id, exposed_at);
+
+alter table experiment_exposures drop column feature_id;
+-- +goose StatementEnd
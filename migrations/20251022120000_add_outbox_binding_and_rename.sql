@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Binding events name the service that was bound or unbound, rename events the name clients knew before
+alter table webhook_outbox add column service_name varchar(255);
+alter table webhook_outbox add column previous_name varchar(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table webhook_outbox drop column previous_name;
+alter table webhook_outbox drop column service_name;
+-- +goose StatementEnd
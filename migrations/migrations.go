@@ -0,0 +1,27 @@
+// Package migrations embeds the goose migrations, so the binary knows which schema version it expects
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the version of the newest migration, the numeric prefix of its file name
+func Latest() int64 {
+	files, _ := fs.Glob(FS, "*.sql")
+
+	var latest int64
+	for _, name := range files {
+		prefix, _, _ := strings.Cut(name, "_")
+		if v, err := strconv.ParseInt(prefix, 10, 64); err == nil && v > latest {
+			latest = v
+		}
+	}
+
+	return latest
+}
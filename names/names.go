@@ -4,17 +4,35 @@ const (
 	CacheMemory                = "secondary"
 	CacheRedis                 = "primary"
 	DatabasePrimary            = "primary"
+	DatabaseReplica            = "replica"
 	HttpServer                 = "http"
 	HttpPublicServer           = "http_public"
 	GrpcServer                 = "grpc"
+	UpstreamClient             = "upstream"
 	FeatureKeyRepository       = "feature_key"
 	FeatureParamRepository     = "feature_param"
 	FeatureRepository          = "feature"
 	ActivationValuesRepository = "activation_values"
 	ServiceAccessRepository    = "service_access"
 	StatsRepository            = "stats"
+	LayerRepository            = "layer"
+	ExperimentRepository       = "experiment"
+	ChangeRequestRepository    = "change_request"
+	WebhookRepository          = "webhook"
+	ProjectRepository          = "project"
+	VersionRepository          = "version"
+	ClientRepository           = "client"
+	HealthRepository           = "health"
 	FeatureService             = "feature"
 	StatsService               = "stats"
+	ExperimentService          = "experiment"
+	ChangeRequestService       = "change_request"
+	WebhookService             = "webhook"
+	SignService                = "sign"
+	EvaluationService          = "evaluation"
+	BootstrapService           = "bootstrap"
+	ClientService              = "client"
+	HealthService              = "health"
 	FeatureChaosController     = "grpc_controller"
 	AdminHTTP                  = "http_admin"
 	PublicHTTP                 = "http_public"
@@ -0,0 +1,33 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// getBootstrap downloads the signed bootstrap file SDKs load at start until the stream connects
+func (t *Controller) getBootstrap(c context.Context, ctx httpSrv.ICtx) {
+	serviceName := ctx.GetRequest().GetQuery().Get("service_name")
+	if serviceName == "" {
+		respondBadRequest(ctx, "service_name", "service_name required")
+		return
+	}
+
+	// Subscribers of other projects address the service as "<project>/<service>", the file carries that name
+	file, version, err := t.bootstrap.Build(c, ProjectRepository.QualifiedName(projectOf(c).Name, serviceName))
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	header := ctx.GetResponse().Header()
+	header.Set("Content-Type", "application/jose")
+	header.Set("Content-Disposition", `attachment; filename="`+serviceName+`.bootstrap"`)
+	header.Set("X-FeatureChaos-Version", strconv.FormatInt(version, 10))
+	ctx.GetResponse().SetStatus(http.StatusOK)
+	ctx.GetResponse().SetBody(file)
+}
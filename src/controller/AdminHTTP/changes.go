@@ -0,0 +1,202 @@
+package AdminHTTP
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// User is an Admin API caller listed in the config, requests name it by its token in "Authorization: Bearer <token>"
+type User struct {
+	Name  string `yaml:"name"`
+	Role  string `yaml:"role"`
+	Token string `yaml:"token"`
+}
+
+// identity returns the name and role of the configured user whose token the request carries, empty ones when none matches.
+// Headers naming the user directly are not trusted, anyone could send them
+func (t *Controller) identity(ctx httpSrv.ICtx) (string, string) {
+	token, ok := strings.CutPrefix(ctx.GetRequest().GetHeader().Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", ""
+	}
+
+	for _, u := range t.config.Users {
+		if subtle.ConstantTimeCompare([]byte(u.Token), []byte(token)) == 1 {
+			return u.Name, u.Role
+		}
+	}
+
+	return "", ""
+}
+
+type changeRequestProposeReq struct {
+	Operation string                  `json:"operation"`
+	TargetId  uuid.UUID               `json:"target_id"`
+	Payload   db.ChangeRequestPayload `json:"payload"`
+	Comment   string                  `json:"comment"`
+}
+
+type changeRequestReviewReq struct {
+	Comment string `json:"comment"`
+}
+
+// proposeIfProtected holds the change for review when the feature falls under the approval policy,
+// returns true when the response has already been written
+func (t *Controller) proposeIfProtected(c context.Context, ctx httpSrv.ICtx, operation string, targetId uuid.UUID, payload db.ChangeRequestPayload, revision int64) bool {
+	user, _ := t.identity(ctx)
+	cr, err := t.changes.ProposeIfRequired(c, operation, targetId, payload, user, revision)
+	if err != nil {
+		t.respondWriteError(c, ctx, targetId, err)
+		return true
+	}
+
+	if cr == nil {
+		return false
+	}
+
+	respondJSON(ctx, http.StatusAccepted, toChangeRequest(cr))
+	return true
+}
+
+func (t *Controller) listChangeRequests(c context.Context, ctx httpSrv.ICtx) {
+	items, err := t.changes.List(c, projectOf(c).Id, ctx.GetRequest().GetQuery().Get("status"))
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := make([]ChangeRequest, 0, len(items))
+	for _, it := range items {
+		out = append(out, toChangeRequest(it))
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+func (t *Controller) getChangeRequest(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	cr, err := t.changes.Get(c, id)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, toChangeRequest(cr))
+}
+
+func (t *Controller) proposeChangeRequest(c context.Context, ctx httpSrv.ICtx) {
+	var req changeRequestProposeReq
+	if err := parseJSON(ctx, &req); err != nil || req.Operation == "" || req.TargetId == uuid.Nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := checkPercent("payload.value", req.Payload.Value); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	if !t.ownedBy(c, ctx, projectOf(c), targetEntity(req.Operation), req.TargetId) {
+		return
+	}
+	if req.Payload.ServiceId != uuid.Nil && !t.ownedBy(c, ctx, projectOf(c), ProjectRepository.EntityService, req.Payload.ServiceId) {
+		return
+	}
+	if req.Payload.LayerId != uuid.Nil && !t.ownedBy(c, ctx, projectOf(c), ProjectRepository.EntityLayer, req.Payload.LayerId) {
+		return
+	}
+
+	user, _ := t.identity(ctx)
+	cr, err := t.changes.Propose(c, req.Operation, req.TargetId, req.Payload, user, req.Comment)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, toChangeRequest(cr))
+}
+
+// targetEntity is what the target of an operation is, service and layer changes do not target a feature
+func targetEntity(operation string) string {
+	switch operation {
+	case db.ChangeOperationDeleteService:
+		return ProjectRepository.EntityService
+	case db.ChangeOperationUpdateLayer, db.ChangeOperationDeleteLayer:
+		return ProjectRepository.EntityLayer
+	}
+
+	return ProjectRepository.EntityFeature
+}
+
+func (t *Controller) approveChangeRequest(c context.Context, ctx httpSrv.ICtx) {
+	t.reviewChangeRequest(c, ctx, t.changes.Approve)
+}
+
+func (t *Controller) rejectChangeRequest(c context.Context, ctx httpSrv.ICtx) {
+	t.reviewChangeRequest(c, ctx, t.changes.Reject)
+}
+
+func (t *Controller) reviewChangeRequest(c context.Context, ctx httpSrv.ICtx, review func(context.Context, uuid.UUID, string, string, string) (*db.ChangeRequest, error)) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	// Comment is optional, an empty body is fine
+	var req changeRequestReviewReq
+	_ = parseJSON(ctx, &req)
+
+	user, role := t.identity(ctx)
+	cr, err := review(c, id, user, role, req.Comment)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, toChangeRequest(cr))
+}
+
+func (t *Controller) applyChangeRequest(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	user, _ := t.identity(ctx)
+	cr, err := t.changes.Apply(c, id, user)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, toChangeRequest(cr))
+}
+
+func toChangeRequest(cr *db.ChangeRequest) ChangeRequest {
+	return ChangeRequest{
+		ID:          cr.Id.String(),
+		Operation:   cr.Operation,
+		TargetID:    cr.TargetId.String(),
+		FeatureID:   cr.FeatureId.String(),
+		BaseVersion: cr.BaseVersion,
+		Payload:     cr.Payload,
+		Status:      cr.Status,
+		ProposedBy:  cr.ProposedBy,
+		ReviewedBy:  cr.ReviewedBy,
+		Comment:     cr.Comment,
+		CreatedAt:   cr.CreatedAt,
+		UpdatedAt:   cr.UpdatedAt,
+	}
+}
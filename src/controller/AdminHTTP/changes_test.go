@@ -0,0 +1,44 @@
+package AdminHTTP
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+func TestController_identity(t *testing.T) {
+	controller := &Controller{config: Config{Users: []User{
+		{Name: "alice", Role: "editor", Token: "alice-token"},
+		{Name: "bob", Role: "admin", Token: "bob-token"},
+	}}}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		user   string
+		role   string
+	}{
+		{name: "token", header: map[string]string{"Authorization": "Bearer bob-token"}, user: "bob", role: "admin"},
+		{name: "unknown token", header: map[string]string{"Authorization": "Bearer mallory-token"}},
+		{name: "not bearer", header: map[string]string{"Authorization": "bob-token"}},
+		{name: "forged headers", header: map[string]string{"X-User": "bob", "X-Role": "admin"}},
+		{name: "forged headers with token", header: map[string]string{"Authorization": "Bearer alice-token", "X-User": "bob", "X-Role": "admin"}, user: "alice", role: "editor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+			ctx.Fill(req)
+
+			user, role := controller.identity(ctx)
+			if user != tt.user || role != tt.role {
+				t.Errorf("expected %q/%q, got %q/%q", tt.user, tt.role, user, role)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+var errServiceNotFound = errs.NotFound("service_not_found", "service not found")
+
+// listServiceClients answers "who is running what version": instances of the service seen recently by any server instance
+func (t *Controller) listServiceClients(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	project := projectOf(c)
+	name, ok := t.serviceName(c, project.Id, id)
+	if !ok {
+		t.respondError(c, ctx, errServiceNotFound)
+		return
+	}
+
+	clients, err := t.clients.List(c, ProjectRepository.QualifiedName(project.Name, name))
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	version, _ := t.featureService.GetVersion(c)
+	out := ServiceClients{Version: version, Clients: make([]ClientInstance, 0, len(clients))}
+	for _, cl := range clients {
+		out.Clients = append(out.Clients, ClientInstance{
+			InstanceID:  cl.InstanceId,
+			Hostname:    cl.Hostname,
+			SdkName:     cl.SdkName,
+			SdkVersion:  cl.SdkVersion,
+			Labels:      cl.Labels,
+			Transport:   cl.Transport,
+			Version:     cl.Version,
+			VersionAt:   cl.VersionAt,
+			Lag:         cl.Lag,
+			Stuck:       cl.Stuck,
+			Connected:   cl.Streaming,
+			ConnectedAt: cl.ConnectedAt,
+			LastSeenAt:  cl.SeenAt,
+		})
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+// serviceName looks the service up among the services of the project
+func (t *Controller) serviceName(c context.Context, projectId uuid.UUID, serviceId uuid.UUID) (string, bool) {
+	for _, svc := range t.access.ListServices(c, projectId) {
+		if svc.Id == serviceId {
+			return svc.Name, true
+		}
+	}
+
+	return "", false
+}
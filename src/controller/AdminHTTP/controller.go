@@ -14,8 +14,16 @@ import (
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureKeyRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ServiceAccessRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/BootstrapService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ChangeRequestService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ExperimentService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/WebhookService"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/controller"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
@@ -42,12 +50,21 @@ var tplLogoSVG string
 
 type Controller struct {
 	controller.Mock
+	logger           interfaces.ILogger
 	features         FeatureRepository.Interface
 	keys             FeatureKeyRepository.Interface
 	params           FeatureParamRepository.Interface
 	stats            StatsService.Interface
+	featureService   FeatureService.Interface
 	access           ServiceAccessRepository.Interface
 	activationValues ActivationValuesRepository.Interface
+	layers           LayerRepository.Interface
+	experiments      ExperimentService.Interface
+	changes          ChangeRequestService.Interface
+	webhooks         WebhookService.Interface
+	bootstrap        BootstrapService.Interface
+	projects         ProjectRepository.Interface
+	clients          ClientService.Interface
 
 	config Config
 }
@@ -57,6 +74,8 @@ type Config struct {
 	DeprecatedTime time.Duration `yaml:"deprecated_time"`
 	PageSize       int           `yaml:"page_size"`
 	AppTitle       string        `yaml:"app_title"`
+	// Users authenticate change requests and reviews, forced deletions are logged with their name
+	Users []User `yaml:"users"`
 }
 
 func New(name string) *Controller {
@@ -64,12 +83,21 @@ func New(name string) *Controller {
 }
 
 func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	t.logger = app.GetLogger()
 	t.features = app.GetModule(interfaces.ModuleRepository, names.FeatureRepository).(FeatureRepository.Interface)
 	t.keys = app.GetModule(interfaces.ModuleRepository, names.FeatureKeyRepository).(FeatureKeyRepository.Interface)
 	t.params = app.GetModule(interfaces.ModuleRepository, names.FeatureParamRepository).(FeatureParamRepository.Interface)
 	t.stats = app.GetModule(interfaces.ModuleService, names.StatsService).(StatsService.Interface)
+	t.featureService = app.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface)
 	t.access = app.GetModule(interfaces.ModuleRepository, names.ServiceAccessRepository).(ServiceAccessRepository.Interface)
 	t.activationValues = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
+	t.layers = app.GetModule(interfaces.ModuleRepository, names.LayerRepository).(LayerRepository.Interface)
+	t.experiments = app.GetModule(interfaces.ModuleService, names.ExperimentService).(ExperimentService.Interface)
+	t.changes = app.GetModule(interfaces.ModuleService, names.ChangeRequestService).(ChangeRequestService.Interface)
+	t.webhooks = app.GetModule(interfaces.ModuleService, names.WebhookService).(WebhookService.Interface)
+	t.bootstrap = app.GetModule(interfaces.ModuleService, names.BootstrapService).(BootstrapService.Interface)
+	t.projects = app.GetModule(interfaces.ModuleRepository, names.ProjectRepository).(ProjectRepository.Interface)
+	t.clients = app.GetModule(interfaces.ModuleService, names.ClientService).(ClientService.Interface)
 
 	http := app.GetPkg(interfaces.PkgServer, names.HttpServer).(httpSrv.IHttp)
 
@@ -90,6 +118,17 @@ func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) er
 		t.config.AppTitle = "test"
 	}
 
+	tokens := make(map[string]bool, len(t.config.Users))
+	for _, u := range t.config.Users {
+		if u.Name == "" || u.Token == "" {
+			return errors.New("users need a name and a token")
+		}
+		if tokens[u.Token] {
+			return errors.New("user " + u.Name + " shares a token with another user")
+		}
+		tokens[u.Token] = true
+	}
+
 	t.config.AppUrl = strings.TrimRight(t.config.AppUrl, "/")
 
 	tplIndexHTML = strings.ReplaceAll(tplIndexHTML, "{{APP_URL}}", t.config.AppUrl)
@@ -97,6 +136,13 @@ func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) er
 
 	tplIndexJS = strings.ReplaceAll(tplIndexJS, "{{APP_URL}}", t.config.AppUrl)
 
+	t.registerRoutes(http)
+
+	return nil
+}
+
+// registerRoutes pushes every Admin API route, openapi.yaml documents each of the /api ones
+func (t *Controller) registerRoutes(http httpSrv.IHttp) {
 	// static
 	http.PushRoute("GET", "/", t.indexPage, nil)
 	http.PushRoute("GET", "/main.js", t.mainJS, nil)
@@ -105,30 +151,74 @@ func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) er
 	http.PushRoute("GET", "/favicon.ico", t.faviconICO, nil)
 	http.PushRoute("GET", "/logo.svg", t.logoSVG, nil)
 
+	// projects
+	http.PushRoute("GET", "/api/projects", t.listProjects, nil)
+	http.PushRoute("POST", "/api/projects", t.createProject, nil)
+	http.PushRoute("DELETE", projectPrefix, t.inProject(t.deleteProject), nil)
+
+	feature := owned{"id", ProjectRepository.EntityFeature}
+	service := owned{"id", ProjectRepository.EntityService}
+	boundService := owned{"sid", ProjectRepository.EntityService}
+	layer := owned{"id", ProjectRepository.EntityLayer}
+	webhook := owned{"id", ProjectRepository.EntityWebhook}
+	changeRequest := owned{"id", ProjectRepository.EntityChangeRequest}
+
 	// features
-	http.PushRoute("GET", "/api/features", t.listFeatures, nil)
-	http.PushRoute("POST", "/api/features", t.createFeature, nil)
-	http.PushRoute("PUT", "/api/features/{id}", t.updateFeature, nil)
-	http.PushRoute("DELETE", "/api/features/{id}", t.deleteFeature, nil)
+	http.PushRoute("GET", projectPrefix+"/features", t.inProject(t.listFeatures), nil)
+	http.PushRoute("POST", projectPrefix+"/features", t.inProject(t.createFeature), nil)
+	http.PushRoute("PUT", projectPrefix+"/features/{id}", t.inProject(t.updateFeature, feature), nil)
+	http.PushRoute("DELETE", projectPrefix+"/features/{id}", t.inProject(t.deleteFeature, feature), nil)
+	http.PushRoute("POST", projectPrefix+"/features/{id}/value", t.inProject(t.setFeatureValue, feature), nil)
 
 	// services
-	http.PushRoute("GET", "/api/services", t.listServices, nil)
-	http.PushRoute("POST", "/api/services", t.createService, nil)
-	http.PushRoute("DELETE", "/api/services/{id}", t.deleteService, nil)
-	http.PushRoute("POST", "/api/features/{id}/services/{sid}", t.addFeatureService, nil)
-	http.PushRoute("DELETE", "/api/features/{id}/services/{sid}", t.removeFeatureService, nil)
+	http.PushRoute("GET", projectPrefix+"/services", t.inProject(t.listServices), nil)
+	http.PushRoute("POST", projectPrefix+"/services", t.inProject(t.createService), nil)
+	http.PushRoute("DELETE", projectPrefix+"/services/{id}", t.inProject(t.deleteService, service), nil)
+	http.PushRoute("GET", projectPrefix+"/services/{id}/clients", t.inProject(t.listServiceClients, service), nil)
+	http.PushRoute("POST", projectPrefix+"/features/{id}/services/{sid}", t.inProject(t.addFeatureService, feature, boundService), nil)
+	http.PushRoute("DELETE", projectPrefix+"/features/{id}/services/{sid}", t.inProject(t.removeFeatureService, feature, boundService), nil)
+	http.PushRoute("PUT", projectPrefix+"/features/{id}/services/{sid}", t.inProject(t.setFeatureServiceClientSide, feature, boundService), nil)
 
 	// keys
-	http.PushRoute("POST", "/api/features/{id}/keys", t.createKey, nil)
-	http.PushRoute("PUT", "/api/keys/{id}", t.updateKey, nil)
-	http.PushRoute("DELETE", "/api/keys/{id}", t.deleteKey, nil)
+	http.PushRoute("POST", projectPrefix+"/features/{id}/keys", t.inProject(t.createKey, feature), nil)
+	http.PushRoute("PUT", projectPrefix+"/keys/{id}", t.inProject(t.updateKey, feature), nil)
+	http.PushRoute("DELETE", projectPrefix+"/keys/{id}", t.inProject(t.deleteKey, feature), nil)
+	http.PushRoute("POST", projectPrefix+"/keys/{id}/value", t.inProject(t.setKeyValue, feature), nil)
 
 	// params
-	http.PushRoute("POST", "/api/keys/{id}/params", t.createParam, nil)
-	http.PushRoute("PUT", "/api/params/{id}", t.updateParam, nil)
-	http.PushRoute("DELETE", "/api/params/{id}", t.deleteParam, nil)
-
-	return nil
+	http.PushRoute("POST", projectPrefix+"/keys/{id}/params", t.inProject(t.createParam, feature), nil)
+	http.PushRoute("PUT", projectPrefix+"/params/{id}", t.inProject(t.updateParam, feature), nil)
+	http.PushRoute("DELETE", projectPrefix+"/params/{id}", t.inProject(t.deleteParam, feature), nil)
+	http.PushRoute("POST", projectPrefix+"/params/{id}/value", t.inProject(t.setParamValue, feature), nil)
+
+	// layers
+	http.PushRoute("GET", projectPrefix+"/layers", t.inProject(t.listLayers), nil)
+	http.PushRoute("POST", projectPrefix+"/layers", t.inProject(t.createLayer), nil)
+	http.PushRoute("PUT", projectPrefix+"/layers/{id}", t.inProject(t.updateLayer, layer), nil)
+	http.PushRoute("DELETE", projectPrefix+"/layers/{id}", t.inProject(t.deleteLayer, layer), nil)
+	http.PushRoute("PUT", projectPrefix+"/features/{id}/layer", t.inProject(t.setFeatureLayer, feature), nil)
+	http.PushRoute("DELETE", projectPrefix+"/features/{id}/layer", t.inProject(t.removeFeatureLayer, feature), nil)
+
+	// experiments
+	http.PushRoute("GET", projectPrefix+"/features/{id}/experiment", t.inProject(t.getExperiment, feature), nil)
+
+	// change requests
+	http.PushRoute("GET", projectPrefix+"/change-requests", t.inProject(t.listChangeRequests), nil)
+	http.PushRoute("POST", projectPrefix+"/change-requests", t.inProject(t.proposeChangeRequest), nil)
+	http.PushRoute("GET", projectPrefix+"/change-requests/{id}", t.inProject(t.getChangeRequest, changeRequest), nil)
+	http.PushRoute("POST", projectPrefix+"/change-requests/{id}/approve", t.inProject(t.approveChangeRequest, changeRequest), nil)
+	http.PushRoute("POST", projectPrefix+"/change-requests/{id}/reject", t.inProject(t.rejectChangeRequest, changeRequest), nil)
+	http.PushRoute("POST", projectPrefix+"/change-requests/{id}/apply", t.inProject(t.applyChangeRequest, changeRequest), nil)
+
+	// webhooks
+	http.PushRoute("GET", projectPrefix+"/webhooks", t.inProject(t.listWebhooks), nil)
+	http.PushRoute("POST", projectPrefix+"/webhooks", t.inProject(t.createWebhook), nil)
+	http.PushRoute("PUT", projectPrefix+"/webhooks/{id}", t.inProject(t.updateWebhook, webhook), nil)
+	http.PushRoute("DELETE", projectPrefix+"/webhooks/{id}", t.inProject(t.deleteWebhook, webhook), nil)
+	http.PushRoute("GET", projectPrefix+"/webhooks/{id}/deliveries", t.inProject(t.listWebhookDeliveries, webhook), nil)
+
+	// Offline bootstrap
+	http.PushRoute("GET", projectPrefix+"/bootstrap", t.inProject(t.getBootstrap), nil)
 }
 
 func respondJSON(ctx httpSrv.ICtx, status int, v any) {
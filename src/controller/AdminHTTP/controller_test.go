@@ -0,0 +1,82 @@
+package AdminHTTP
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+	"gopkg.in/yaml.v3"
+)
+
+// routeRecorder collects the routes the controller registers
+type routeRecorder struct {
+	httpSrv.IHttp
+	routes map[string]bool
+}
+
+func (t *routeRecorder) PushRoute(method string, path string, _ httpSrv.RouteFunc, _ []string) {
+	t.routes[strings.ToUpper(method)+" "+path] = true
+}
+
+// documentedRoutes reads the operations of openapi.yaml as "METHOD path"
+func documentedRoutes(t *testing.T) map[string]bool {
+	t.Helper()
+
+	b, err := os.ReadFile("../../../openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	routes := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				routes[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	return routes
+}
+
+func TestController_routesMatchOpenAPI(t *testing.T) {
+	recorder := &routeRecorder{routes: map[string]bool{}}
+	(&Controller{}).registerRoutes(recorder)
+
+	documented := documentedRoutes(t)
+
+	var missing, undocumented []string
+	for route := range documented {
+		if !recorder.routes[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range recorder.routes {
+		// The UI assets are not part of the API
+		if !strings.Contains(route, " /api/") {
+			continue
+		}
+		if !documented[route] {
+			undocumented = append(undocumented, route)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(undocumented)
+	for _, route := range missing {
+		t.Errorf("documented in openapi.yaml but not registered: %s", route)
+	}
+	for _, route := range undocumented {
+		t.Errorf("registered but missing from openapi.yaml: %s", route)
+	}
+}
@@ -0,0 +1,114 @@
+package AdminHTTP
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// maxNameLength is the width of the name columns
+const maxNameLength = 255
+
+// ErrorResponse is the body of every Admin API error, clients switch on Code and highlight Field
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// respondError answers with the status of the error's kind. Errors outside the domain model are logged and
+// answered with a generic 500, driver messages never reach the caller
+func (t *Controller) respondError(c context.Context, ctx httpSrv.ICtx, err error) {
+	e := errs.As(err)
+	if e == nil {
+		if t.logger != nil {
+			t.logger.Error(c, err)
+		}
+		respondJSON(ctx, http.StatusInternalServerError, ErrorResponse{ErrorDetail{Code: "internal", Message: "internal error"}})
+		return
+	}
+
+	respondJSON(ctx, errorStatus(e), ErrorResponse{ErrorDetail{Code: e.Code, Message: e.Message, Field: e.Field}})
+}
+
+// respondBadRequest answers a request that could not be read: malformed JSON, path ids or query values
+func respondBadRequest(ctx httpSrv.ICtx, field string, message string) {
+	code := "invalid_request"
+	if field == "" {
+		code = "invalid_body"
+	}
+	respondJSON(ctx, http.StatusBadRequest, ErrorResponse{ErrorDetail{Code: code, Message: message, Field: field}})
+}
+
+func errorStatus(e *errs.Error) int {
+	switch {
+	case errors.Is(e, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(e, errs.ErrConflict), errors.Is(e, errs.ErrInUse):
+		return http.StatusConflict
+	case errors.Is(e, errs.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(e, errs.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(e, errs.ErrForbidden):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
+
+// firstError returns the first failed check
+func firstError(checks ...error) error {
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkName validates a feature, key, param or layer name before it reaches the database
+func checkName(field string, name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errs.Validation("required", field, field+" is required")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		return errs.Validation("too_long", field, field+" must be at most 255 characters")
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return errs.Validation("invalid_characters", field, field+" must not contain control characters")
+	}
+
+	return nil
+}
+
+// checkScopedName also rejects "/" in project and service names, clients address services as "<project>/<service>"
+func checkScopedName(field string, name string) error {
+	if err := checkName(field, name); err != nil {
+		return err
+	}
+	if strings.Contains(name, "/") {
+		return errs.Validation("invalid_characters", field, field+` must not contain "/"`)
+	}
+
+	return nil
+}
+
+// checkPercent validates a rollout percentage
+func checkPercent(field string, value int) error {
+	if value < 0 || value > 100 {
+		return errs.Validation("out_of_range", field, field+" must be between 0 and 100")
+	}
+
+	return nil
+}
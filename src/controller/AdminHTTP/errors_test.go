@@ -0,0 +1,93 @@
+package AdminHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type fakeFeatures struct {
+	FeatureRepository.Interface
+	err     error
+	created int
+}
+
+func (t *fakeFeatures) CreateFeature(context.Context, uuid.UUID, string, string, string, string, int) (uuid.UUID, error) {
+	t.created++
+	return uuid.New(), t.err
+}
+
+// call runs handler in the default project and returns the status and the decoded error
+func call(t *testing.T, handler httpSrv.RouteFunc, body string) (int, ErrorDetail) {
+	t.Helper()
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	c := context.WithValue(context.Background(), projectCtxKey{}, &db.Project{Id: uuid.New(), Name: "default"})
+	handler(c, ctx)
+
+	var resp ErrorResponse
+	_ = json.Unmarshal(ctx.GetResponse().GetBody(), &resp)
+
+	return ctx.GetResponse().GetStatus(), resp.Error
+}
+
+func TestController_createFeatureErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{name: "malformed", body: `{"name":`, status: http.StatusBadRequest, code: "invalid_body"},
+		{name: "empty name", body: `{"name":" ","value":10}`, status: http.StatusUnprocessableEntity, code: "required", field: "name"},
+		{name: "long name", body: `{"name":"` + strings.Repeat("a", 256) + `"}`, status: http.StatusUnprocessableEntity, code: "too_long", field: "name"},
+		{name: "percent", body: `{"name":"checkout","value":101}`, status: http.StatusUnprocessableEntity, code: "out_of_range", field: "value"},
+		{
+			name:   "taken name",
+			body:   `{"name":"checkout","value":10}`,
+			err:    errs.Conflict("name_taken", "name", "feature name is already taken in the project"),
+			status: http.StatusConflict,
+			code:   "name_taken",
+			field:  "name",
+		},
+		{
+			name:   "driver error",
+			body:   `{"name":"checkout","value":10}`,
+			err:    errors.New(`dial tcp 10.0.0.5:5432: connection refused`),
+			status: http.StatusInternalServerError,
+			code:   "internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := &fakeFeatures{err: tt.err}
+			controller := &Controller{features: features}
+
+			status, detail := call(t, controller.createFeature, tt.body)
+
+			if status != tt.status || detail.Code != tt.code || detail.Field != tt.field {
+				t.Fatalf("expected %d %s/%s, got %d %+v", tt.status, tt.code, tt.field, status, detail)
+			}
+			if tt.err != nil && tt.status == http.StatusInternalServerError && strings.Contains(detail.Message, "10.0.0.5") {
+				t.Errorf("internal error leaked: %q", detail.Message)
+			}
+			if tt.status == http.StatusUnprocessableEntity && features.created != 0 {
+				t.Errorf("invalid input must be rejected before the repository")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// Experiment analysis of a feature against one metric
+func (t *Controller) getExperiment(c context.Context, ctx httpSrv.ICtx) {
+	idStr := ctx.GetRouterValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	metric := ctx.GetRequest().GetQuery().Get("metric")
+	if metric == "" {
+		respondBadRequest(ctx, "metric", "metric required")
+		return
+	}
+
+	name, err := t.features.GetFeatureName(c, id)
+	if err != nil {
+		t.respondError(c, ctx, FeatureRepository.ErrFeatureNotFound)
+		return
+	}
+
+	result, err := t.experiments.Analyze(c, id, name, metric, ctx.GetRequest().GetQuery().Get("control"))
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := Experiment{
+		FeatureName: result.FeatureName,
+		Metric:      result.Metric,
+		Control:     result.Control,
+		Variants:    make([]ExperimentVariant, 0, len(result.Variants)),
+	}
+	for _, v := range result.Variants {
+		out.Variants = append(out.Variants, ExperimentVariant{
+			Variant:        v.Variant,
+			Units:          v.Units,
+			Conversions:    v.Conversions,
+			ConversionRate: v.ConversionRate,
+			Mean:           v.Mean,
+			StdDev:         v.StdDev,
+			ConversionTest: toSignificanceTest(v.ConversionTest),
+			MeanTest:       toSignificanceTest(v.MeanTest),
+		})
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+func toSignificanceTest(s *dto.SignificanceTest) *SignificanceTest {
+	if s == nil {
+		return nil
+	}
+
+	return &SignificanceTest{
+		Statistic:        s.Statistic,
+		DegreesOfFreedom: s.DegreesOfFreedom,
+		PValue:           s.PValue,
+		Significant:      s.Significant,
+	}
+}
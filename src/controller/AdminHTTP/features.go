@@ -6,19 +6,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
 )
 
 func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 	var req GetFeaturesRequest
 	if err := req.FromRequest(ctx); err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 
-	items, count, err := t.activationValues.GetFeatures(c, req.ServiceId, req.Page, t.config.PageSize, req.Find, req.IsDeprecated, t.config.DeprecatedTime)
+	items, count, err := t.activationValues.GetFeatures(c, projectOf(c).Id, req.ServiceId, req.Page, t.config.PageSize, req.Find, req.IsDeprecated, t.config.DeprecatedTime)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 
@@ -40,7 +42,7 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 
 	access, err := t.access.GetAccessByFeatures(c, features)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 
@@ -53,7 +55,7 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 	for _, it := range items {
 		used := false
 		if t.stats != nil {
-			used = t.stats.IsUsed(context.Background(), it.Name)
+			used = t.stats.IsUsed(context.Background(), ProjectRepository.QualifiedName(projectOf(c).Name, it.Name))
 		}
 
 		svcResp := make([]Service, 0)
@@ -61,8 +63,9 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 		if svcs, ok := access[it.Id]; ok {
 			for _, svc := range svcs {
 				svcResp = append(svcResp, Service{
-					ID:   svc.ServiceId.String(),
-					Name: svc.Name,
+					ID:         svc.ServiceId.String(),
+					Name:       svc.Name,
+					ClientSide: svc.ClientSide,
 				})
 			}
 		}
@@ -70,17 +73,19 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 		keyResp := make([]Key, 0)
 		for _, key := range it.Keys {
 			k := Key{
-				ID:     key.Id.String(),
-				Name:   key.Key,
-				Value:  key.Value,
-				Params: make([]Param, 0),
+				ID:       key.Id.String(),
+				Name:     key.Key,
+				Value:    key.Value,
+				Revision: key.Revision,
+				Params:   make([]Param, 0),
 			}
 
 			for _, param := range key.Params {
 				k.Params = append(k.Params, Param{
-					ID:    param.Id.String(),
-					Name:  param.Name,
-					Value: param.Value,
+					ID:       param.Id.String(),
+					Name:     param.Name,
+					Value:    param.Value,
+					Revision: param.Revision,
 				})
 			}
 
@@ -91,7 +96,10 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 			ID:           it.Id.String(),
 			Name:         it.Name,
 			Description:  it.Description,
+			Salt:         it.Salt,
+			BucketBy:     it.BucketBy,
 			Value:        it.Value,
+			Revision:     it.Revision,
 			Used:         used,
 			Services:     svcResp,
 			Keys:         keyResp,
@@ -107,43 +115,65 @@ func (t *Controller) listFeatures(c context.Context, ctx httpSrv.ICtx) {
 type featureCreateReq struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Salt        string `json:"salt"`
+	BucketBy    string `json:"bucket_by"`
 	Value       int    `json:"value"`
 }
 
 func (t *Controller) createFeature(c context.Context, ctx httpSrv.ICtx) {
 	var req featureCreateReq
-	if err := parseJSON(ctx, &req); err != nil || req.Name == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
 		return
 	}
-	id, err := t.features.CreateFeature(c, req.Name, req.Description, req.Value)
+	if err := firstError(checkName("name", req.Name), checkPercent("value", req.Value)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	id, err := t.features.CreateFeature(c, projectOf(c).Id, req.Name, req.Description, req.Salt, req.BucketBy, req.Value)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
 }
 
 type featureUpdateReq struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Value       int    `json:"value"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Salt        *string `json:"salt"`
+	BucketBy    *string `json:"bucket_by"`
+	Value       int     `json:"value"`
+	Revision    int64   `json:"revision"`
 }
 
 func (t *Controller) updateFeature(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
 		return
 	}
 	var req featureUpdateReq
-	if err := parseJSON(ctx, &req); err != nil || req.Name == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("name", req.Name), checkPercent("value", req.Value)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	revision, ok := expectedRevision(ctx, req.Revision)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	payload := db.ChangeRequestPayload{Name: req.Name, Description: req.Description, Salt: req.Salt, BucketBy: req.BucketBy, Value: req.Value}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationUpdateFeature, id, payload, revision) {
 		return
 	}
-	if err := t.features.UpdateFeature(c, id, req.Name, req.Description, req.Value); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := t.features.UpdateFeature(c, id, req.Name, req.Description, req.Salt, req.BucketBy, req.Value, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
@@ -153,12 +183,27 @@ func (t *Controller) deleteFeature(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
 		return
 	}
-
-	if err := t.features.DeleteFeature(c, id); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	revision, ok := expectedRevision(ctx, 0)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	impact, used, err := t.featureImpact(c, projectOf(c), id)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	if !t.allowDeletion(c, ctx, errFeatureInUse, "feature", id, impact, used) {
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationDeleteFeature, id, db.ChangeRequestPayload{}, revision) {
+		return
+	}
+	if err := t.features.DeleteFeature(c, id, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusNoContent, nil)
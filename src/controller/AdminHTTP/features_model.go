@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"time"
 
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
 )
 
@@ -20,11 +22,20 @@ type GetFeaturesResponse struct {
 	Features   []Feature `json:"features"`
 }
 
+type Project struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Feature struct {
 	ID           string    `json:"id"`
 	Name         string    `json:"name"`
 	Description  string    `json:"description"`
+	Salt         string    `json:"salt"`
+	BucketBy     string    `json:"bucket_by"`
 	Value        int       `json:"value"`
+	Revision     int64     `json:"revision"`
 	Used         bool      `json:"used"`
 	IsDeprecated bool      `json:"is_deprecated"`
 	Services     []Service `json:"services"`
@@ -34,22 +45,155 @@ type Feature struct {
 }
 
 type Service struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	Active bool   `json:"active"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Active     bool   `json:"active"`
+	ClientSide bool   `json:"client_side"`
 }
 
 type Key struct {
-	ID     string  `json:"id"`
-	Name   string  `json:"name"`
-	Value  int     `json:"value"`
-	Params []Param `json:"params"`
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Value    int     `json:"value"`
+	Revision int64   `json:"revision"`
+	Params   []Param `json:"params"`
 }
 
 type Param struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Value int    `json:"value"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Value    int    `json:"value"`
+	Revision int64  `json:"revision"`
+}
+
+// EntityState is a feature, key or param as it is now, Revision changes with every write to it
+type EntityState struct {
+	ID          string `json:"id"`
+	FeatureID   string `json:"feature_id"`
+	KeyID       string `json:"key_id,omitempty"`
+	ParamID     string `json:"param_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       int    `json:"value"`
+	Revision    int64  `json:"revision"`
+}
+
+// DeletionImpact is what deleting a feature or service would break. Connected counts update streams open on
+// the instance that answered
+type DeletionImpact struct {
+	LastSeenAt *time.Time      `json:"last_seen_at,omitempty"`
+	Connected  int             `json:"connected"`
+	Services   []ImpactService `json:"services,omitempty"`
+}
+
+type ImpactService struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Connected  int        `json:"connected"`
+}
+
+type Layer struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Salt        string            `json:"salt"`
+	Description string            `json:"description"`
+	Allocations []LayerAllocation `json:"allocations"`
+}
+
+type LayerAllocation struct {
+	FeatureID   string `json:"feature_id"`
+	FeatureName string `json:"feature_name"`
+	From        int    `json:"from"`
+	To          int    `json:"to"`
+}
+
+type Experiment struct {
+	FeatureName string              `json:"feature_name"`
+	Metric      string              `json:"metric"`
+	Control     string              `json:"control"`
+	Variants    []ExperimentVariant `json:"variants"`
+}
+
+type ExperimentVariant struct {
+	Variant        string            `json:"variant"`
+	Units          int64             `json:"units"`
+	Conversions    int64             `json:"conversions"`
+	ConversionRate float64           `json:"conversion_rate"`
+	Mean           float64           `json:"mean"`
+	StdDev         float64           `json:"std_dev"`
+	ConversionTest *SignificanceTest `json:"conversion_test,omitempty"`
+	MeanTest       *SignificanceTest `json:"mean_test,omitempty"`
+}
+
+type SignificanceTest struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom float64 `json:"df,omitempty"`
+	PValue           float64 `json:"p_value"`
+	Significant      bool    `json:"significant"`
+}
+
+type ChangeRequest struct {
+	ID          string                  `json:"id"`
+	Operation   string                  `json:"operation"`
+	TargetID    string                  `json:"target_id"`
+	FeatureID   string                  `json:"feature_id"`
+	BaseVersion int64                   `json:"base_version"`
+	Payload     db.ChangeRequestPayload `json:"payload"`
+	Status      string                  `json:"status"`
+	ProposedBy  string                  `json:"proposed_by"`
+	ReviewedBy  *string                 `json:"reviewed_by,omitempty"`
+	Comment     string                  `json:"comment"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// ServiceClients lists the instances of a service, Version is the current global version
+type ServiceClients struct {
+	Version int64            `json:"version"`
+	Clients []ClientInstance `json:"clients"`
+}
+
+// ClientInstance is an SDK instance as the client registry knows it. Lag counts global versions and stays 0 while
+// the instance holds every change of its service; Stuck marks an instance missing changes for several minutes
+type ClientInstance struct {
+	InstanceID  string            `json:"instance_id"`
+	Hostname    string            `json:"hostname,omitempty"`
+	SdkName     string            `json:"sdk_name,omitempty"`
+	SdkVersion  string            `json:"sdk_version,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Transport   string            `json:"transport"`
+	Version     int64             `json:"version"`
+	VersionAt   time.Time         `json:"version_at"`
+	Lag         int64             `json:"lag"`
+	Stuck       bool              `json:"stuck"`
+	Connected   bool              `json:"connected"`
+	ConnectedAt *time.Time        `json:"connected_at,omitempty"`
+	LastSeenAt  time.Time         `json:"last_seen_at"`
+}
+
+// Webhook never exposes the secret, it is write-only
+type Webhook struct {
+	ID           string    `json:"id"`
+	Url          string    `json:"url"`
+	FeatureNames []string  `json:"feature_names"`
+	ServiceNames []string  `json:"service_names"`
+	EventTypes   []string  `json:"event_types"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID  int64     `json:"delivery_id"`
+	EventID     int64     `json:"event_id"`
+	Event       string    `json:"event"`
+	FeatureName string    `json:"feature_name"`
+	Status      string    `json:"status"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int       `json:"duration_ms"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 func (t *GetFeaturesRequest) FromRequest(ctx httpSrv.ICtx) error {
@@ -0,0 +1,121 @@
+package AdminHTTP
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+var (
+	errFeatureInUse = errs.InUse("feature_in_use", "feature was used recently, pass force=true to delete it anyway")
+	errServiceInUse = errs.InUse("service_in_use", "service has connected clients or was used recently, pass force=true to delete it anyway")
+)
+
+// DeletionBlockedResponse answers a deletion of an entity clients still use, Impact is what deleting it would break
+type DeletionBlockedResponse struct {
+	Error  ErrorDetail    `json:"error"`
+	Impact DeletionImpact `json:"impact"`
+}
+
+// featureImpact reports who uses the feature: when it was last evaluated and which bound services are alive
+func (t *Controller) featureImpact(c context.Context, project *db.Project, featureId uuid.UUID) (DeletionImpact, bool, error) {
+	name, err := t.features.GetFeatureName(c, featureId)
+	if err != nil {
+		return DeletionImpact{}, false, err
+	}
+
+	access, err := t.access.GetAccessByFeatures(c, []uuid.UUID{featureId})
+	if err != nil {
+		return DeletionImpact{}, false, err
+	}
+
+	impact := DeletionImpact{Services: make([]ImpactService, 0, len(access[featureId]))}
+	used := false
+	if t.stats != nil {
+		impact.LastSeenAt, used = seenAt(t.stats.FeatureSeenAt(c, ProjectRepository.QualifiedName(project.Name, name)))
+	}
+
+	for _, svc := range access[featureId] {
+		s, _ := t.serviceUsage(c, ProjectRepository.QualifiedName(svc.ProjectName, svc.Name))
+		s.ID = svc.ServiceId.String()
+		s.Name = svc.Name
+		impact.Connected += s.Connected
+		impact.Services = append(impact.Services, s)
+	}
+
+	return impact, used, nil
+}
+
+// serviceImpact reports when the service last reported usage and how many of its clients are connected
+func (t *Controller) serviceImpact(c context.Context, project *db.Project, serviceId uuid.UUID) (DeletionImpact, bool) {
+	name, ok := t.serviceName(c, project.Id, serviceId)
+	if !ok {
+		// Nothing to protect, the repository reports the missing service
+		return DeletionImpact{}, false
+	}
+
+	s, used := t.serviceUsage(c, ProjectRepository.QualifiedName(project.Name, name))
+
+	return DeletionImpact{LastSeenAt: s.LastSeenAt, Connected: s.Connected}, used
+}
+
+// serviceUsage is used when the service reported usage recently or has update streams open on any server instance
+func (t *Controller) serviceUsage(c context.Context, qualifiedName string) (ImpactService, bool) {
+	var s ImpactService
+	used := false
+	if t.stats != nil {
+		s.LastSeenAt, used = seenAt(t.stats.ServiceSeenAt(c, qualifiedName))
+	}
+	if t.clients != nil {
+		connected, err := t.clients.Connected(c, qualifiedName)
+		if err != nil && t.logger != nil {
+			t.logger.Error(c, fmt.Errorf("connected clients of %s: %w", qualifiedName, err))
+		}
+		s.Connected = connected
+	}
+
+	return s, used || s.Connected > 0
+}
+
+// allowDeletion lets the deletion through when the entity is unused or the caller forces it, forced deletions are
+// logged with the impact they had. Returns false when the blocked response has already been written
+func (t *Controller) allowDeletion(c context.Context, ctx httpSrv.ICtx, blocked *errs.Error, entity string, id uuid.UUID, impact DeletionImpact, used bool) bool {
+	if !used {
+		return true
+	}
+
+	if ctx.GetRequest().GetQuery().Get("force") == "true" {
+		if t.logger != nil {
+			user, _ := t.identity(ctx)
+			t.logger.Warn(c, fmt.Sprintf("forced deletion of %s %s in project %s by %q: last seen %v, %d connected, %d bound services",
+				entity, id, projectOf(c).Name, user, impact.LastSeenAt, impact.Connected, len(impact.Services)))
+		}
+		return true
+	}
+
+	respondJSON(ctx, http.StatusConflict, DeletionBlockedResponse{
+		Error:  ErrorDetail{Code: blocked.Code, Message: blocked.Message},
+		Impact: impact,
+	})
+
+	return false
+}
+
+func seenAt(at time.Time, ok bool) (*time.Time, bool) {
+	if !ok {
+		return nil, false
+	}
+	if at.IsZero() {
+		// Used, the time is not known
+		return nil, true
+	}
+
+	return &at, true
+}
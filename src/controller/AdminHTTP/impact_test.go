@@ -0,0 +1,134 @@
+package AdminHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ServiceAccessRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+func (t *fakeFeatures) GetFeatureName(context.Context, uuid.UUID) (string, error) {
+	return "checkout", nil
+}
+
+type fakeStats struct {
+	StatsService.Interface
+	seen map[string]time.Time
+}
+
+func (t *fakeStats) FeatureSeenAt(_ context.Context, name string) (time.Time, bool) {
+	at, ok := t.seen[name]
+	return at, ok
+}
+
+func (t *fakeStats) ServiceSeenAt(_ context.Context, name string) (time.Time, bool) {
+	at, ok := t.seen[name]
+	return at, ok
+}
+
+// fakeClients is the shared client registry, connected counts streaming instances by qualified service name
+type fakeClients struct {
+	ClientService.Interface
+	connected map[string]int
+}
+
+func (t *fakeClients) Connected(_ context.Context, serviceName string) (int, error) {
+	return t.connected[serviceName], nil
+}
+
+type fakeAccess struct {
+	ServiceAccessRepository.Interface
+	services []db.Service
+}
+
+func (t *fakeAccess) ListServices(context.Context, uuid.UUID) []db.Service {
+	return t.services
+}
+
+func (t *fakeAccess) GetAccessByFeatures(_ context.Context, featureIds []uuid.UUID) (map[uuid.UUID][]*db.ServiceAccess, error) {
+	res := make(map[uuid.UUID][]*db.ServiceAccess)
+	for _, svc := range t.services {
+		res[featureIds[0]] = append(res[featureIds[0]], &db.ServiceAccess{ServiceId: svc.Id, Name: svc.Name, ProjectName: "default"})
+	}
+	return res, nil
+}
+
+// deleteWith runs allowDeletion for url and returns whether it let the deletion through and the blocked body
+func deleteWith(controller *Controller, url string, impact DeletionImpact, used bool) (bool, int, DeletionBlockedResponse) {
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("DELETE", url, nil))
+	c := context.WithValue(context.Background(), projectCtxKey{}, &db.Project{Id: uuid.New(), Name: "default"})
+
+	allowed := controller.allowDeletion(c, ctx, errFeatureInUse, "feature", uuid.New(), impact, used)
+
+	var resp DeletionBlockedResponse
+	_ = json.Unmarshal(ctx.GetResponse().GetBody(), &resp)
+
+	return allowed, ctx.GetResponse().GetStatus(), resp
+}
+
+func TestController_featureDeletionImpact(t *testing.T) {
+	seenAt := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+	billing := db.Service{Id: uuid.New(), Name: "billing"}
+	project := &db.Project{Id: uuid.New(), Name: "default"}
+
+	controller := &Controller{
+		features: &fakeFeatures{},
+		access:   &fakeAccess{services: []db.Service{billing}},
+		stats:    &fakeStats{seen: map[string]time.Time{"checkout": seenAt, "billing": seenAt}},
+		clients:  &fakeClients{connected: map[string]int{"billing": 2}},
+	}
+
+	impact, used, err := controller.featureImpact(context.Background(), project, uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !used || impact.LastSeenAt == nil || !impact.LastSeenAt.Equal(seenAt) || impact.Connected != 2 {
+		t.Fatalf("expected a used feature seen at %v with 2 connected, got %v %+v", seenAt, used, impact)
+	}
+	if len(impact.Services) != 1 || impact.Services[0].Name != "billing" || impact.Services[0].Connected != 2 {
+		t.Fatalf("expected billing in the impact, got %+v", impact.Services)
+	}
+
+	allowed, status, resp := deleteWith(controller, "/", impact, used)
+	if allowed || status != http.StatusConflict || resp.Error.Code != "feature_in_use" {
+		t.Fatalf("used feature must be blocked with 409, got %v %d %+v", allowed, status, resp.Error)
+	}
+	if resp.Impact.Connected != 2 || len(resp.Impact.Services) != 1 {
+		t.Errorf("blocked response must carry the impact, got %+v", resp.Impact)
+	}
+
+	if allowed, status, _ := deleteWith(controller, "/?force=true", impact, used); !allowed || status == http.StatusConflict {
+		t.Errorf("force must override the block, got %v %d", allowed, status)
+	}
+}
+
+func TestController_serviceDeletionImpact(t *testing.T) {
+	billing := db.Service{Id: uuid.New(), Name: "billing"}
+	project := &db.Project{Id: uuid.New(), Name: "default"}
+	controller := &Controller{
+		access:  &fakeAccess{services: []db.Service{billing}},
+		stats:   &fakeStats{},
+		clients: &fakeClients{},
+	}
+
+	if _, used := controller.serviceImpact(context.Background(), project, billing.Id); used {
+		t.Errorf("idle service must be deletable")
+	}
+
+	// Clients stream updates through another server instance but have not reported usage yet
+	controller.clients = &fakeClients{connected: map[string]int{"billing": 1}}
+	impact, used := controller.serviceImpact(context.Background(), project, billing.Id)
+	if !used || impact.Connected != 1 || impact.LastSeenAt != nil {
+		t.Errorf("service with connected clients must be protected, got %v %+v", used, impact)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
 )
 
@@ -18,43 +19,64 @@ func (t *Controller) createKey(c context.Context, ctx httpSrv.ICtx) {
 	featureIdStr := ctx.GetRouterValue("id")
 	featureId, err := uuid.Parse(featureIdStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid feature id"})
+		respondBadRequest(ctx, "id", "invalid feature id")
 		return
 	}
 	var req keyCreateReq
-	if err := parseJSON(ctx, &req); err != nil || req.Key == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("key", req.Key), checkPercent("value", req.Value)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	payload := db.ChangeRequestPayload{Name: req.Key, Description: req.Description, Value: req.Value}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationCreateKey, featureId, payload, 0) {
 		return
 	}
 	id, err := t.keys.CreateKey(c, featureId, req.Key, req.Description, req.Value)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
 }
 
 type keyUpdateReq struct {
-	FeatureId   uuid.UUID `json:"feature_id"`
-	Key         string    `json:"key"`
-	Description string    `json:"description"`
-	Value       int       `json:"value"`
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Value       int    `json:"value"`
+	Revision    int64  `json:"revision"`
 }
 
 func (t *Controller) updateKey(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
 		return
 	}
 	var req keyUpdateReq
-	if err := parseJSON(ctx, &req); err != nil || req.Key == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("key", req.Key), checkPercent("value", req.Value)); err != nil {
+		t.respondError(c, ctx, err)
 		return
 	}
-	if err := t.keys.UpdateKey(c, id, req.FeatureId, req.Key, req.Description, req.Value); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	revision, ok := expectedRevision(ctx, req.Revision)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	payload := db.ChangeRequestPayload{Name: req.Key, Description: req.Description, Value: req.Value}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationUpdateKey, id, payload, revision) {
+		return
+	}
+	if err := t.keys.UpdateKey(c, id, req.Key, req.Description, req.Value, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
@@ -64,11 +86,19 @@ func (t *Controller) deleteKey(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	revision, ok := expectedRevision(ctx, 0)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationDeleteKey, id, db.ChangeRequestPayload{}, revision) {
 		return
 	}
-	if err := t.keys.DeleteKey(c, id); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := t.keys.DeleteKey(c, id, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusNoContent, nil)
@@ -0,0 +1,176 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type layerReq struct {
+	Name        string `json:"name"`
+	Salt        string `json:"salt"`
+	Description string `json:"description"`
+}
+
+type layerAllocationReq struct {
+	LayerId uuid.UUID `json:"layer_id"`
+	From    int       `json:"from"`
+	To      int       `json:"to"`
+}
+
+// Layers CRUD endpoints
+func (t *Controller) listLayers(c context.Context, ctx httpSrv.ICtx) {
+	layers, err := t.layers.ListLayers(c, projectOf(c).Id)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	allocations, err := t.layers.ListAllocations(c)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := make([]Layer, 0, len(layers))
+	for _, l := range layers {
+		item := Layer{
+			ID:          l.Id.String(),
+			Name:        l.Name,
+			Salt:        l.Salt,
+			Description: l.Description,
+			Allocations: make([]LayerAllocation, 0),
+		}
+
+		for _, a := range allocations[l.Id] {
+			item.Allocations = append(item.Allocations, LayerAllocation{
+				FeatureID:   a.FeatureId.String(),
+				FeatureName: a.FeatureName,
+				From:        a.From,
+				To:          a.To,
+			})
+		}
+
+		out = append(out, item)
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+func (t *Controller) createLayer(c context.Context, ctx httpSrv.ICtx) {
+	var req layerReq
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("name", req.Name)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	id, err := t.layers.CreateLayer(c, projectOf(c).Id, req.Name, req.Salt, req.Description)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
+}
+
+func (t *Controller) updateLayer(c context.Context, ctx httpSrv.ICtx) {
+	idStr := ctx.GetRouterValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	var req layerReq
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("name", req.Name)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	payload := db.ChangeRequestPayload{Name: req.Name, Description: req.Description}
+	if req.Salt != "" {
+		payload.Salt = &req.Salt
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationUpdateLayer, id, payload, 0) {
+		return
+	}
+	if err := t.layers.UpdateLayer(c, id, req.Name, req.Salt, req.Description); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (t *Controller) deleteLayer(c context.Context, ctx httpSrv.ICtx) {
+	idStr := ctx.GetRouterValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationDeleteLayer, id, db.ChangeRequestPayload{}, 0) {
+		return
+	}
+	if err := t.layers.DeleteLayer(c, id); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusNoContent, nil)
+}
+
+// Experiment allocation endpoints
+func (t *Controller) setFeatureLayer(c context.Context, ctx httpSrv.ICtx) {
+	idStr := ctx.GetRouterValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid feature id")
+		return
+	}
+	var req layerAllocationReq
+	if err := parseJSON(ctx, &req); err != nil || req.LayerId == uuid.Nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if req.From < 0 || req.To > LayerRepository.BucketCount || req.From >= req.To {
+		t.respondError(c, ctx, LayerRepository.ErrInvalidRange)
+		return
+	}
+	if !t.ownedBy(c, ctx, projectOf(c), ProjectRepository.EntityLayer, req.LayerId) {
+		return
+	}
+	payload := db.ChangeRequestPayload{LayerId: req.LayerId, From: req.From, To: req.To}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationSetLayer, id, payload, 0) {
+		return
+	}
+	if err := t.layers.SetAllocation(c, req.LayerId, id, req.From, req.To); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (t *Controller) removeFeatureLayer(c context.Context, ctx httpSrv.ICtx) {
+	idStr := ctx.GetRouterValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid feature id")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationRemoveLayer, id, db.ChangeRequestPayload{}, 0) {
+		return
+	}
+	if err := t.layers.RemoveAllocation(c, id); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusNoContent, nil)
+}
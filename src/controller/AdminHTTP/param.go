@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
 )
 
@@ -18,17 +19,24 @@ func (t *Controller) createParam(c context.Context, ctx httpSrv.ICtx) {
 	keyIdStr := ctx.GetRouterValue("id")
 	keyId, err := uuid.Parse(keyIdStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid key id"})
+		respondBadRequest(ctx, "id", "invalid key id")
 		return
 	}
 	var body paramCreateReq
-	if err := parseJSON(ctx, &body); err != nil || body.Name == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &body); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("name", body.Name), checkPercent("value", body.Value)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationCreateParam, keyId, db.ChangeRequestPayload{Name: body.Name, Value: body.Value}, 0) {
 		return
 	}
 	id, err := t.params.CreateParam(c, body.FeatureId, keyId, body.Name, body.Value)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
@@ -38,21 +46,33 @@ func (t *Controller) updateParam(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
 		return
 	}
 	var req struct {
-		FeatureId uuid.UUID `json:"feature_id"`
-		KeyId     uuid.UUID `json:"key_id"`
-		Name      string    `json:"name"`
-		Value     int       `json:"value"`
+		Name     string `json:"name"`
+		Value    int    `json:"value"`
+		Revision int64  `json:"revision"`
+	}
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := firstError(checkName("name", req.Name), checkPercent("value", req.Value)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	revision, ok := expectedRevision(ctx, req.Revision)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
 	}
-	if err := parseJSON(ctx, &req); err != nil || req.Name == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	payload := db.ChangeRequestPayload{Name: req.Name, Value: req.Value}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationUpdateParam, id, payload, revision) {
 		return
 	}
-	if err := t.params.UpdateParam(c, req.FeatureId, req.KeyId, id, req.Name, req.Value); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := t.params.UpdateParam(c, id, req.Name, req.Value, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
@@ -62,11 +82,19 @@ func (t *Controller) deleteParam(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	revision, ok := expectedRevision(ctx, 0)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationDeleteParam, id, db.ChangeRequestPayload{}, revision) {
 		return
 	}
-	if err := t.params.DeleteParam(c, id); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := t.params.DeleteParam(c, id, revision); err != nil {
+		t.respondWriteError(c, ctx, id, err)
 		return
 	}
 	respondJSON(ctx, http.StatusNoContent, nil)
@@ -0,0 +1,118 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// Every admin resource lives under /api/projects/{project}, the project is referenced by id or name
+const projectPrefix = "/api/projects/{project}"
+
+type projectCtxKey struct{}
+
+// owned names a path value that must reference an entity of the request's project
+type owned struct {
+	param  string
+	entity string
+}
+
+// inProject resolves {project} for h and answers 404 for path ids that belong to another project,
+// so a handler never touches data outside the project it was called for
+func (t *Controller) inProject(h httpSrv.RouteFunc, checks ...owned) httpSrv.RouteFunc {
+	return func(c context.Context, ctx httpSrv.ICtx) {
+		project, err := t.projects.FindProject(c, ctx.GetRouterValue("project"))
+		if err != nil {
+			t.respondError(c, ctx, err)
+			return
+		}
+
+		for _, check := range checks {
+			id, err := uuid.Parse(ctx.GetRouterValue(check.param))
+			if err != nil {
+				respondBadRequest(ctx, check.param, "invalid "+check.param)
+				return
+			}
+
+			if !t.ownedBy(c, ctx, project, check.entity, id) {
+				return
+			}
+		}
+
+		h(context.WithValue(c, projectCtxKey{}, project), ctx)
+	}
+}
+
+// ownedBy writes the error response and returns false when id is not an entity of the project
+func (t *Controller) ownedBy(c context.Context, ctx httpSrv.ICtx, project *db.Project, entity string, id uuid.UUID) bool {
+	ok, err := t.projects.Owns(c, project.Id, entity, id)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return false
+	}
+
+	if !ok {
+		t.respondError(c, ctx, errs.NotFound(entity+"_not_found", entity+" not found in project "+project.Name))
+		return false
+	}
+
+	return true
+}
+
+// projectOf returns the project resolved by inProject
+func projectOf(c context.Context) *db.Project {
+	return c.Value(projectCtxKey{}).(*db.Project)
+}
+
+// Projects CRUD endpoints
+func (t *Controller) listProjects(c context.Context, ctx httpSrv.ICtx) {
+	projects, err := t.projects.ListProjects(c)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := make([]Project, 0, len(projects))
+	for _, p := range projects {
+		out = append(out, Project{ID: p.Id.String(), Name: p.Name, CreatedAt: p.CreatedAt})
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+func (t *Controller) createProject(c context.Context, ctx httpSrv.ICtx) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := parseJSON(ctx, &body); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := checkScopedName("name", body.Name); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	if _, err := uuid.Parse(body.Name); err == nil {
+		t.respondError(c, ctx, errs.Validation("name_is_id", "name", "project name must not be an id"))
+		return
+	}
+
+	id, err := t.projects.CreateProject(c, body.Name)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
+}
+
+func (t *Controller) deleteProject(c context.Context, ctx httpSrv.ICtx) {
+	if err := t.projects.DeleteProject(c, projectOf(c).Id); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusNoContent, nil)
+}
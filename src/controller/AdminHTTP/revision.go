@@ -0,0 +1,83 @@
+package AdminHTTP
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+const headerIfMatch = "If-Match"
+
+// RevisionConflictResponse answers a write made against a stale revision, Current is what the caller should reload
+type RevisionConflictResponse struct {
+	Error   ErrorDetail `json:"error"`
+	Current EntityState `json:"current"`
+}
+
+// expectedRevision is the revision the caller last read: the If-Match header wins over the body field,
+// 0 means the caller did not send one, or sent "*", and the write is unconditional
+func expectedRevision(ctx httpSrv.ICtx, body int64) (int64, bool) {
+	header := strings.TrimSpace(ctx.GetRequest().GetHeader().Get(headerIfMatch))
+	if header == "" {
+		return body, body >= 0
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	header = strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	revision, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, false
+	}
+
+	return revision, true
+}
+
+// respondWriteError answers a failed write on a feature, key or param, a revision mismatch carries the current state
+func (t *Controller) respondWriteError(c context.Context, ctx httpSrv.ICtx, id uuid.UUID, err error) {
+	if !errors.Is(err, ActivationValuesRepository.ErrRevisionMismatch) {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	state, stateErr := t.activationValues.GetState(c, id)
+	if stateErr != nil {
+		// Deleted in the meantime, that is the current state
+		t.respondError(c, ctx, stateErr)
+		return
+	}
+
+	e := errs.As(err)
+	respondJSON(ctx, http.StatusConflict, RevisionConflictResponse{
+		Error:   ErrorDetail{Code: e.Code, Message: e.Message, Field: e.Field},
+		Current: toEntityState(state),
+	})
+}
+
+func toEntityState(s *dto.EntityState) EntityState {
+	out := EntityState{
+		ID:          s.Id.String(),
+		FeatureID:   s.FeatureId.String(),
+		Name:        s.Name,
+		Description: s.Description,
+		Value:       s.Value,
+		Revision:    s.Revision,
+	}
+	if s.KeyId != nil {
+		out.KeyID = s.KeyId.String()
+	}
+	if s.ParamId != nil {
+		out.ParamID = s.ParamId.String()
+	}
+
+	return out
+}
@@ -0,0 +1,91 @@
+package AdminHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type fakeActivationValues struct {
+	ActivationValuesRepository.Interface
+	state *dto.EntityState
+}
+
+func (t *fakeActivationValues) GetState(context.Context, uuid.UUID) (*dto.EntityState, error) {
+	if t.state == nil {
+		return nil, ActivationValuesRepository.ErrValueNotFound
+	}
+	return t.state, nil
+}
+
+func TestExpectedRevision(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		body     int64
+		revision int64
+		ok       bool
+	}{
+		{name: "none", ok: true},
+		{name: "body", body: 7, revision: 7, ok: true},
+		{name: "quoted header", ifMatch: `"12"`, body: 7, revision: 12, ok: true},
+		{name: "weak header", ifMatch: `W/"12"`, revision: 12, ok: true},
+		{name: "bare header", ifMatch: `12`, revision: 12, ok: true},
+		{name: "wildcard", ifMatch: `*`, body: 7, ok: true},
+		{name: "garbage", ifMatch: `"abc"`},
+		{name: "negative body", body: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set(headerIfMatch, tt.ifMatch)
+			}
+			ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+			ctx.Fill(r)
+
+			revision, ok := expectedRevision(ctx, tt.body)
+			if ok != tt.ok || (ok && revision != tt.revision) {
+				t.Errorf("expected %d %v, got %d %v", tt.revision, tt.ok, revision, ok)
+			}
+		})
+	}
+}
+
+func TestController_respondWriteErrorCarriesCurrentState(t *testing.T) {
+	id := uuid.New()
+	controller := &Controller{activationValues: &fakeActivationValues{state: &dto.EntityState{Id: id, FeatureId: id, Name: "checkout", Value: 40, Revision: 12}}}
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("PUT", "/", nil))
+	controller.respondWriteError(context.Background(), ctx, id, ActivationValuesRepository.ErrRevisionMismatch)
+
+	var resp RevisionConflictResponse
+	if err := json.Unmarshal(ctx.GetResponse().GetBody(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.GetResponse().GetStatus() != http.StatusConflict || resp.Error.Code != "revision_mismatch" {
+		t.Fatalf("expected 409 revision_mismatch, got %d %+v", ctx.GetResponse().GetStatus(), resp.Error)
+	}
+	if resp.Current.Revision != 12 || resp.Current.Value != 40 || resp.Current.Name != "checkout" {
+		t.Errorf("conflict must carry the current state, got %+v", resp.Current)
+	}
+
+	// Deleted by the other writer: there is nothing to reload
+	controller.activationValues = &fakeActivationValues{}
+	ctx = httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("PUT", "/", nil))
+	controller.respondWriteError(context.Background(), ctx, id, ActivationValuesRepository.ErrRevisionMismatch)
+
+	if ctx.GetResponse().GetStatus() != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted target, got %d", ctx.GetResponse().GetStatus())
+	}
+}
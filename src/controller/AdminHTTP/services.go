@@ -5,12 +5,15 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
 )
 
 // Services CRUD endpoints
 func (t *Controller) listServices(c context.Context, ctx httpSrv.ICtx) {
-	svcs := t.access.ListServices(c)
+	project := projectOf(c)
+	svcs := t.access.ListServices(c, project.Id)
 	type resp struct {
 		ID     string `json:"id"`
 		Name   string `json:"name"`
@@ -20,7 +23,7 @@ func (t *Controller) listServices(c context.Context, ctx httpSrv.ICtx) {
 	for i, s := range svcs {
 		active := false
 		if t.stats != nil {
-			active = t.stats.IsServiceUsed(c, s.Name)
+			active = t.stats.IsServiceUsed(c, ProjectRepository.QualifiedName(project.Name, s.Name))
 		}
 		out[i] = resp{ID: s.Id.String(), Name: s.Name, Active: active}
 	}
@@ -31,13 +34,17 @@ func (t *Controller) createService(c context.Context, ctx httpSrv.ICtx) {
 	var body struct {
 		Name string `json:"name"`
 	}
-	if err := parseJSON(ctx, &body); err != nil || body.Name == "" {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	if err := parseJSON(ctx, &body); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
 		return
 	}
-	id, err := t.access.CreateService(c, body.Name)
+	if err := checkScopedName("name", body.Name); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	id, err := t.access.CreateService(c, projectOf(c).Id, body.Name)
 	if err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
@@ -47,11 +54,18 @@ func (t *Controller) deleteService(c context.Context, ctx httpSrv.ICtx) {
 	idStr := ctx.GetRouterValue("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	impact, used := t.serviceImpact(c, projectOf(c), id)
+	if !t.allowDeletion(c, ctx, errServiceInUse, "service", id, impact, used) {
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationDeleteService, id, db.ChangeRequestPayload{}, 0) {
 		return
 	}
 	if err := t.access.DeleteService(c, id); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusNoContent, nil)
@@ -63,16 +77,19 @@ func (t *Controller) addFeatureService(c context.Context, ctx httpSrv.ICtx) {
 	sidStr := ctx.GetRouterValue("sid")
 	fid, err := uuid.Parse(fidStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid feature id"})
+		respondBadRequest(ctx, "id", "invalid feature id")
 		return
 	}
 	sid, err := uuid.Parse(sidStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid service id"})
+		respondBadRequest(ctx, "sid", "invalid service id")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationAddService, fid, db.ChangeRequestPayload{ServiceId: sid}, 0) {
 		return
 	}
 	if err := t.access.AddAccess(c, fid, sid); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusCreated, map[string]string{"status": "ok"})
@@ -83,17 +100,51 @@ func (t *Controller) removeFeatureService(c context.Context, ctx httpSrv.ICtx) {
 	sidStr := ctx.GetRouterValue("sid")
 	fid, err := uuid.Parse(fidStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid feature id"})
+		respondBadRequest(ctx, "id", "invalid feature id")
 		return
 	}
 	sid, err := uuid.Parse(sidStr)
 	if err != nil {
-		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid service id"})
+		respondBadRequest(ctx, "sid", "invalid service id")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationRemoveService, fid, db.ChangeRequestPayload{ServiceId: sid}, 0) {
 		return
 	}
 	if err := t.access.RemoveAccess(c, fid, sid); err != nil {
-		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		t.respondError(c, ctx, err)
 		return
 	}
 	respondJSON(ctx, http.StatusNoContent, nil)
 }
+
+// setFeatureServiceClientSide toggles whether the service exposes the feature to client-side evaluation
+func (t *Controller) setFeatureServiceClientSide(c context.Context, ctx httpSrv.ICtx) {
+	fid, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid feature id")
+		return
+	}
+	sid, err := uuid.Parse(ctx.GetRouterValue("sid"))
+	if err != nil {
+		respondBadRequest(ctx, "sid", "invalid service id")
+		return
+	}
+
+	var body struct {
+		ClientSide *bool `json:"client_side"`
+	}
+	if err := parseJSON(ctx, &body); err != nil || body.ClientSide == nil {
+		respondBadRequest(ctx, "client_side", "client_side required")
+		return
+	}
+
+	if t.proposeIfProtected(c, ctx, db.ChangeOperationSetClientSide, fid, db.ChangeRequestPayload{ServiceId: sid, ClientSide: body.ClientSide}, 0) {
+		return
+	}
+	if err := t.access.SetClientSide(c, fid, sid, *body.ClientSide); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -14,20 +14,96 @@ function renderFromTemplate(id, fill) {
 
 function qs(sel, root) { return (root || document).querySelector(sel); }
 function qsa(sel, root) { return Array.prototype.slice.call((root || document).querySelectorAll(sel)); }
-function fetchJson(url, options) {
+// The server takes the user and role from the Admin API token, it is asked once and kept across reloads
+function authHeaders() {
+  var token = localStorage.getItem('featurechaos.token');
+  return token ? { 'Authorization': 'Bearer ' + token } : {};
+}
+
+function askToken() {
+  var token = null;
+  try { token = window.prompt('Токен доступа к Admin API'); } catch (_) {}
+  if (!token) return false;
+  localStorage.setItem('featurechaos.token', token);
+  return true;
+}
+
+function fetchJson(url, options, retried) {
   var opts = options || {};
-  opts.headers = Object.assign({ 'Accept': 'application/json' }, opts.headers || {});
+  opts.headers = Object.assign({ 'Accept': 'application/json' }, opts.headers || {}, authHeaders());
   return fetch(url, opts).then(function(resp){
-    if (!resp.ok) throw new Error('http_' + resp.status);
+    if (resp.status === 401 && !retried && askToken()) {
+      return fetchJson(url, options, true);
+    }
+    if (!resp.ok) {
+      return resp.json().catch(function(){ return {}; }).then(function(body){
+        var err = new Error('http_' + resp.status);
+        err.status = resp.status;
+        err.body = body;
+        throw err;
+      });
+    }
     return resp.json().catch(function(){ return {}; });
   });
 }
 
+// Writes carry the revision the form was opened with, the server refuses them once someone else saved in between
+function ifMatch(revision) {
+  return typeof revision === 'number' && revision > 0 ? { headers: { 'If-Match': '"' + revision + '"' } } : {};
+}
+
+function isRevisionConflict(err) {
+  return !!(err && err.status === 409 && err.body && err.body.error && err.body.error.code === 'revision_mismatch');
+}
+
+// Deletions of features and services clients still use are refused with an impact report, the user may force them
+function isDeletionBlocked(err) {
+  return !!(err && err.status === 409 && err.body && err.body.impact);
+}
+
+function describeImpact(impact) {
+  var lines = ['Клиенты всё ещё используют эту сущность.'];
+  if (impact.last_seen_at) lines.push('Последнее использование: ' + new Date(impact.last_seen_at).toLocaleString());
+  lines.push('Подключено экземпляров: ' + (impact.connected || 0));
+  var services = Array.isArray(impact.services) ? impact.services : [];
+  if (services.length) {
+    lines.push('Сервисы: ' + services.map(function(s){ return (s.name || s.id) + ' (' + (s.connected || 0) + ')'; }).join(', '));
+  }
+  return lines.join('\n');
+}
+
+function deleteConfirmingImpact(url, opts) {
+  return api.del(url, opts).catch(function(err){
+    if (!isDeletionBlocked(err)) throw err;
+    var ok = false;
+    try { ok = window.confirm(describeImpact(err.body.impact) + '\n\nВсё равно удалить?'); } catch (_) {}
+    if (!ok) {
+      err.cancelled = true;
+      throw err;
+    }
+    return api.del(url + (url.indexOf('?') >= 0 ? '&' : '?') + 'force=true', opts);
+  });
+}
+
+function confirmReload() {
+  var ok = true;
+  try { ok = window.confirm('Фичу изменил кто-то другой. Загрузить актуальную версию? Несохранённые изменения будут потеряны.'); } catch (_) {}
+  return ok;
+}
+
+// Admin resources live under /api/projects/{project}, the selected project survives reloads
+var currentProject = localStorage.getItem('featurechaos.project') || 'default';
+
+function projectUrl(url) {
+  if (url.indexOf('/api/projects') === 0) return url;
+  return url.replace(/^\/api\//, '/api/projects/' + encodeURIComponent(currentProject) + '/');
+}
+
 var api = {
-  get: function(url, opts) { return fetchJson("{{APP_URL}}"+url, Object.assign({ method: 'GET' }, opts || {})); },
-  post: function(url, body, opts) { return fetchJson("{{APP_URL}}"+url, Object.assign({ method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body || {}) }, opts || {})); },
-  put: function(url, body, opts) { return fetchJson("{{APP_URL}}"+url, Object.assign({ method: 'PUT', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body || {}) }, opts || {})); },
-  del: function(url, opts) { return fetchJson("{{APP_URL}}"+url, Object.assign({ method: 'DELETE' }, opts || {})); }
+  get: function(url, opts) { return fetchJson("{{APP_URL}}"+projectUrl(url), Object.assign({ method: 'GET' }, opts || {})); },
+  post: function(url, body, opts) { return fetchJson("{{APP_URL}}"+projectUrl(url), Object.assign({ method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body || {}) }, opts || {})); },
+  put: function(url, body, opts) { return fetchJson("{{APP_URL}}"+projectUrl(url), Object.assign({ method: 'PUT', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body || {}) }, opts || {})); },
+  del: function(url, opts) { return fetchJson("{{APP_URL}}"+projectUrl(url), Object.assign({ method: 'DELETE' }, opts || {})); }
 };
 
 var AppState = {
@@ -81,6 +157,30 @@ var AppState = {
   }
 })();
 
+// Project switcher
+(function() {
+  var select = document.getElementById('projectSelect');
+  if (!select) return;
+
+  api.get('/api/projects')
+    .then(function(list) {
+      select.innerHTML = '';
+      (Array.isArray(list) ? list : []).forEach(function(p) {
+        var opt = document.createElement('option');
+        opt.value = p.name;
+        opt.textContent = p.name;
+        opt.selected = p.name === currentProject || p.id === currentProject;
+        select.appendChild(opt);
+      });
+    })
+    .catch(function(){});
+
+  select.addEventListener('change', function() {
+    localStorage.setItem('featurechaos.project', select.value);
+    window.location.reload();
+  });
+})();
+
 // Services catalog loading + overlay rendering
 (function() {
   var servicesListEl = document.getElementById('servicesList');
@@ -183,6 +283,75 @@ var AppState = {
     } catch (e) {}
   };
 
+  function describeClientVersion(client) {
+    var text = 'v' + (client.version || 0);
+    if (client.lag > 0) text += ' · отстаёт на ' + client.lag;
+    else text += ' · актуальна';
+    if (client.stuck) text += ' · не обновляется';
+    return text;
+  }
+
+  function renderClients(ul, data) {
+    ul.innerHTML = '';
+    var clients = data && Array.isArray(data.clients) ? data.clients : [];
+    if (!clients.length) {
+      var empty = document.createElement('li');
+      empty.className = 'services-overlay__client';
+      empty.textContent = 'Экземпляров за последние 10 минут не было';
+      ul.appendChild(empty);
+      return;
+    }
+    clients.forEach(function(client) {
+      var frag = renderFromTemplate('serviceClientTemplate', function(node){
+        var root = node.firstElementChild || node;
+        if (!root) return;
+        root.setAttribute('data-stuck', client.stuck ? 'true' : 'false');
+        var labels = client.labels ? Object.keys(client.labels).map(function(k){ return k + '=' + client.labels[k]; }) : [];
+        root.title = [client.instance_id].concat(labels).join('\n');
+        var host = root.querySelector('.services-overlay__client-host');
+        if (host) host.textContent = client.hostname || client.instance_id || '';
+        var sdk = root.querySelector('.services-overlay__client-sdk');
+        if (sdk) {
+          var parts = [((client.sdk_name || 'SDK') + ' ' + (client.sdk_version || '')).trim(), client.transport || ''];
+          if (client.connected) parts.push('подключён');
+          sdk.textContent = parts.filter(Boolean).join(' · ');
+        }
+        var version = root.querySelector('.services-overlay__client-version');
+        if (version) version.textContent = describeClientVersion(client);
+      });
+      if (frag) ul.appendChild(frag);
+    });
+  }
+
+  // Instances of a service unfold under it, they are loaded on every opening
+  function toggleClients(btn, li, id) {
+    var ul = li.querySelector('.services-overlay__clients');
+    if (!ul) return;
+    if (!ul.hidden) {
+      ul.hidden = true;
+      btn.setAttribute('aria-expanded', 'false');
+      return;
+    }
+    ul.hidden = false;
+    btn.setAttribute('aria-expanded', 'true');
+    ul.innerHTML = '<li class="services-overlay__client">Загружаем…</li>';
+    api.get('/api/services/' + encodeURIComponent(id) + '/clients')
+      .then(function(data){ renderClients(ul, data); })
+      .catch(function(){
+        ul.innerHTML = '<li class="services-overlay__client">Не удалось загрузить экземпляры</li>';
+      });
+  }
+
+  if (servicesListEl) {
+    servicesListEl.addEventListener('click', function(e) {
+      var btn = e.target && e.target.closest('button[data-action="clients"]');
+      if (!btn) return;
+      var li = btn.closest('li');
+      var id = li ? li.getAttribute('data-service-id') || '' : '';
+      if (id) toggleClients(btn, li, id);
+    });
+  }
+
   // Handle delete clicks with optimistic UI and server sync
   if (servicesListEl) {
     servicesListEl.addEventListener('click', function(e) {
@@ -204,7 +373,7 @@ var AppState = {
       btn.disabled = true;
       btn.setAttribute('aria-busy', 'true');
 
-      api.del('/api/services/' + encodeURIComponent(id))
+      deleteConfirmingImpact('/api/services/' + encodeURIComponent(id))
         .then(function(){ return api.get('/api/services'); })
         .then(function(arr){
           var norm = Array.isArray(arr) ? arr.map(normalizeService) : [];
@@ -213,8 +382,10 @@ var AppState = {
             window.__refreshServicesUi();
           }
         })
-        .catch(function(){
-          try { window.alert('Не удалось удалить сервис. Повторите попытку.'); } catch (_) {}
+        .catch(function(err){
+          if (!(err && err.cancelled)) {
+            try { window.alert('Не удалось удалить сервис. Повторите попытку.'); } catch (_) {}
+          }
           // restore button state if still in DOM
           if (btn && btn.isConnected) {
             btn.innerHTML = originalHtml;
@@ -519,6 +690,7 @@ var AppState = {
     var name = item.name != null ? String(item.name) : id;
     var description = item.description != null ? String(item.description) : '';
     var value = typeof item.value === 'number' ? item.value : 0;
+    var revision = typeof item.revision === 'number' ? item.revision : 0;
     var used = !!item.used;
     var isDeprecated = !!(item.is_deprecated);
     var createdAt = item.created_at || item.createdAt || new Date().toISOString();
@@ -531,16 +703,18 @@ var AppState = {
         id: k && k.id != null ? String(k.id) : '',
         name: k && k.name != null ? String(k.name) : '',
         value: typeof (k && k.value) === 'number' ? k.value : 0,
+        revision: typeof (k && k.revision) === 'number' ? k.revision : 0,
         params: Array.isArray(k && k.params) ? k.params.map(function(p){
           return {
             id: p && p.id != null ? String(p.id) : '',
             name: p && p.name != null ? String(p.name) : '',
-            value: typeof (p && p.value) === 'number' ? p.value : 0
+            value: typeof (p && p.value) === 'number' ? p.value : 0,
+            revision: typeof (p && p.revision) === 'number' ? p.revision : 0
           };
         }) : []
       };
     }) : [];
-    return { id: id, name: name, description: description, value: value, used: used, is_deprecated: isDeprecated, services: services, keys: keys, createdAt: createdAt, updatedAt: updatedAt };
+    return { id: id, name: name, description: description, value: value, revision: revision, used: used, is_deprecated: isDeprecated, services: services, keys: keys, createdAt: createdAt, updatedAt: updatedAt };
   }
 
   function buildFeaturesQuery() {
@@ -933,6 +1107,7 @@ var AppState = {
         featureUpdated: false,
         createdKeyIdByTemp: {}, // tempKeyId -> realKeyId
         createdParamIdByTemp: {}, // tempParamId -> realParamId
+        updatedParamIds: {}, // realParamId -> true, a retry must not resend the revision it already consumed
         deletedKeyIds: {}, // realKeyId -> true
         deletedParamIds: {} // realParamId -> true
       };
@@ -978,7 +1153,7 @@ var AppState = {
         var draftValue = (typeof draft.value === 'number' ? Math.max(0, Math.min(100, draft.value)) : 0);
         if (draftValue !== originalValue && !progress.featureUpdated) {
           tasks.push(function(){
-            return api.put('/api/features/' + encodeURIComponent(featureId), { name: original.name || '', description: original.description || '', value: draftValue })
+            return api.put('/api/features/' + encodeURIComponent(featureId), { name: original.name || '', description: original.description || '', value: draftValue, revision: original.revision || 0 })
               .then(function(){ progress.featureUpdated = true; });
           });
         }
@@ -1077,9 +1252,11 @@ var AppState = {
 
           // Updated params
           updatedParams.forEach(function(pid){
+            if (progress.updatedParamIds[pid]) return;
             var dp = dParamsById[pid];
             tasks.push(function(){
-            return api.put('/api/params/' + encodeURIComponent(pid), { feature_id: featureId, key_id: keyId, name: dp.name || '', value: (typeof dp.value === 'number' ? Math.max(0, Math.min(100, dp.value)) : 0) });
+            return api.put('/api/params/' + encodeURIComponent(pid), { name: dp.name || '', value: (typeof dp.value === 'number' ? Math.max(0, Math.min(100, dp.value)) : 0), revision: oParamsById[pid].revision || 0 })
+              .then(function(){ progress.updatedParamIds[pid] = true; });
             });
           });
 
@@ -1087,7 +1264,7 @@ var AppState = {
           removedParamIds.forEach(function(pid){
             if (progress.deletedParamIds[pid]) return;
             tasks.push(function(){
-            return api.del('/api/params/' + encodeURIComponent(pid)).then(function(){ progress.deletedParamIds[pid] = true; });
+            return api.del('/api/params/' + encodeURIComponent(pid), ifMatch(oParamsById[pid].revision)).then(function(){ progress.deletedParamIds[pid] = true; });
             });
           });
         });
@@ -1096,7 +1273,7 @@ var AppState = {
         removedKeyIds.forEach(function(kid){
           if (progress.deletedKeyIds[kid]) return;
           tasks.push(function(){
-            return api.del('/api/keys/' + encodeURIComponent(kid)).then(function(){ progress.deletedKeyIds[kid] = true; });
+            return api.del('/api/keys/' + encodeURIComponent(kid), ifMatch(originalKeysById[kid].revision)).then(function(){ progress.deletedKeyIds[kid] = true; });
           });
         });
 
@@ -1107,7 +1284,14 @@ var AppState = {
             fetchFeatures();
             close();
           })
-          .catch(function(){
+          .catch(function(err){
+            if (isRevisionConflict(err)) {
+              if (confirmReload()) {
+                fetchFeatures();
+                close();
+              }
+              return;
+            }
             try { window.alert('Не удалось сохранить атрибуты. Повторите попытку.'); } catch (_) {}
           })
           .finally(function(){
@@ -1259,10 +1443,16 @@ var AppState = {
       btn.disabled = true;
       btn.setAttribute('aria-busy', 'true');
 
-      api.del('/api/features/' + encodeURIComponent(id))
+      deleteConfirmingImpact('/api/features/' + encodeURIComponent(id), ifMatch(features[index].revision))
         .then(function(){ fetchFeatures(); })
-        .catch(function(){
-          try { window.alert('Не удалось удалить фичу. Повторите попытку.'); } catch (_) {}
+        .catch(function(err){
+          if (isRevisionConflict(err)) {
+            if (confirmReload()) fetchFeatures();
+            return;
+          }
+          if (!(err && err.cancelled)) {
+            try { window.alert('Не удалось удалить фичу. Повторите попытку.'); } catch (_) {}
+          }
           // restore button state if still in DOM (list may re-render)
           if (btn && btn.isConnected) {
             btn.innerHTML = originalHtml;
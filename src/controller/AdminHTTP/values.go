@@ -0,0 +1,60 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type valueSetReq struct {
+	Value    *int  `json:"value"`
+	Revision int64 `json:"revision"`
+}
+
+func (t *Controller) setFeatureValue(c context.Context, ctx httpSrv.ICtx) {
+	t.setValue(c, ctx, db.ChangeOperationSetFeatureValue, t.features.SetValue)
+}
+
+func (t *Controller) setKeyValue(c context.Context, ctx httpSrv.ICtx) {
+	t.setValue(c, ctx, db.ChangeOperationSetKeyValue, t.keys.SetValue)
+}
+
+func (t *Controller) setParamValue(c context.Context, ctx httpSrv.ICtx) {
+	t.setValue(c, ctx, db.ChangeOperationSetParamValue, t.params.SetValue)
+}
+
+// setValue changes only the value of a feature, key or param and answers with the version it was written in,
+// so callers can wait until subscribers report that version
+func (t *Controller) setValue(c context.Context, ctx httpSrv.ICtx, operation string, set func(c context.Context, id uuid.UUID, value int, revision int64) (int64, error)) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+	var req valueSetReq
+	if err := parseJSON(ctx, &req); err != nil || req.Value == nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := checkPercent("value", *req.Value); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+	revision, ok := expectedRevision(ctx, req.Revision)
+	if !ok {
+		respondBadRequest(ctx, "revision", "invalid revision")
+		return
+	}
+	if t.proposeIfProtected(c, ctx, operation, id, db.ChangeRequestPayload{Value: *req.Value}, revision) {
+		return
+	}
+	v, err := set(c, id, *req.Value, revision)
+	if err != nil {
+		t.respondWriteError(c, ctx, id, err)
+		return
+	}
+	respondJSON(ctx, http.StatusOK, map[string]int64{"version": v})
+}
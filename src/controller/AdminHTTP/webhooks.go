@@ -0,0 +1,183 @@
+package AdminHTTP
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+const defaultDeliveryLogLimit = 100
+
+type webhookReq struct {
+	Url          string   `json:"url"`
+	Secret       string   `json:"secret"`
+	FeatureNames []string `json:"feature_names"`
+	ServiceNames []string `json:"service_names"`
+	EventTypes   []string `json:"event_types"`
+	IsActive     *bool    `json:"is_active"`
+}
+
+var webhookEvents = []string{db.WebhookEventUpdated, db.WebhookEventDeleted, db.WebhookEventRenamed, db.WebhookEventBound, db.WebhookEventUnbound}
+
+func (r *webhookReq) validate() error {
+	u, err := url.Parse(r.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errs.Validation("invalid_url", "url", "url must be an absolute http(s) url")
+	}
+
+	for _, e := range r.EventTypes {
+		if !slices.Contains(webhookEvents, e) {
+			return errs.Validation("unknown_event_type", "event_types", "unknown event type "+e)
+		}
+	}
+
+	return nil
+}
+
+func (r *webhookReq) toWebhook(id uuid.UUID) *db.Webhook {
+	active := true
+	if r.IsActive != nil {
+		active = *r.IsActive
+	}
+
+	return &db.Webhook{
+		Id:           id,
+		Url:          r.Url,
+		Secret:       r.Secret,
+		FeatureNames: r.FeatureNames,
+		ServiceNames: r.ServiceNames,
+		EventTypes:   r.EventTypes,
+		IsActive:     active,
+	}
+}
+
+// Webhook subscriptions CRUD endpoints
+func (t *Controller) listWebhooks(c context.Context, ctx httpSrv.ICtx) {
+	hooks, err := t.webhooks.ListWebhooks(c, projectOf(c).Id)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := make([]Webhook, 0, len(hooks))
+	for _, h := range hooks {
+		out = append(out, Webhook{
+			ID:           h.Id.String(),
+			Url:          h.Url,
+			FeatureNames: h.FeatureNames,
+			ServiceNames: h.ServiceNames,
+			EventTypes:   h.EventTypes,
+			IsActive:     h.IsActive,
+			CreatedAt:    h.CreatedAt,
+		})
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
+
+func (t *Controller) createWebhook(c context.Context, ctx httpSrv.ICtx) {
+	var req webhookReq
+	if err := parseJSON(ctx, &req); err != nil || req.Secret == "" {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	hook := req.toWebhook(uuid.Nil)
+	hook.ProjectId = projectOf(c).Id
+
+	id, err := t.webhooks.CreateWebhook(c, hook)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusCreated, map[string]string{"id": id.String()})
+}
+
+func (t *Controller) updateWebhook(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	var req webhookReq
+	if err := parseJSON(ctx, &req); err != nil {
+		respondBadRequest(ctx, "", "invalid body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	if err := t.webhooks.UpdateWebhook(c, req.toWebhook(id)); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (t *Controller) deleteWebhook(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	if err := t.webhooks.DeleteWebhook(c, id); err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	respondJSON(ctx, http.StatusNoContent, nil)
+}
+
+func (t *Controller) listWebhookDeliveries(c context.Context, ctx httpSrv.ICtx) {
+	id, err := uuid.Parse(ctx.GetRouterValue("id"))
+	if err != nil {
+		respondBadRequest(ctx, "id", "invalid id")
+		return
+	}
+
+	limit := defaultDeliveryLogLimit
+	if l, err := strconv.Atoi(ctx.GetRequest().GetQuery().Get("limit")); err == nil && l > 0 && l <= 1000 {
+		limit = l
+	}
+
+	items, err := t.webhooks.ListDeliveryLog(c, id, limit)
+	if err != nil {
+		t.respondError(c, ctx, err)
+		return
+	}
+
+	out := make([]WebhookDelivery, 0, len(items))
+	for _, it := range items {
+		out = append(out, WebhookDelivery{
+			DeliveryID:  it.DeliveryId,
+			EventID:     it.EventId,
+			Event:       it.EventType,
+			FeatureName: it.FeatureName,
+			Status:      it.Status,
+			Attempt:     it.Attempt,
+			StatusCode:  it.StatusCode,
+			Error:       it.Error,
+			DurationMs:  it.DurationMs,
+			CreatedAt:   it.CreatedAt,
+		})
+	}
+
+	respondJSON(ctx, http.StatusOK, out)
+}
@@ -67,7 +67,7 @@ func (x GetFeatureResponse_DeletedItem_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use GetFeatureResponse_DeletedItem_Type.Descriptor instead.
 func (GetFeatureResponse_DeletedItem_Type) EnumDescriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{4, 0, 0}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{5, 0, 0}
 }
 
 type PropsItem struct {
@@ -133,20 +133,96 @@ func (x *PropsItem) GetItem() map[string]int32 {
 	return nil
 }
 
+// Mutually exclusive experiments: the layer bucket is computed from (Salt, seed),
+// a user is in the experiment only when From <= bucket < To
+type LayerItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Salt string `protobuf:"bytes,2,opt,name=Salt,proto3" json:"Salt,omitempty"`
+	From int32  `protobuf:"varint,3,opt,name=From,proto3" json:"From,omitempty"`
+	To   int32  `protobuf:"varint,4,opt,name=To,proto3" json:"To,omitempty"`
+}
+
+func (x *LayerItem) Reset() {
+	*x = LayerItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_FeatureChaos_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LayerItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LayerItem) ProtoMessage() {}
+
+func (x *LayerItem) ProtoReflect() protoreflect.Message {
+	mi := &file_FeatureChaos_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LayerItem.ProtoReflect.Descriptor instead.
+func (*LayerItem) Descriptor() ([]byte, []int) {
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LayerItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LayerItem) GetSalt() string {
+	if x != nil {
+		return x.Salt
+	}
+	return ""
+}
+
+func (x *LayerItem) GetFrom() int32 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *LayerItem) GetTo() int32 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
 type FeatureItem struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	All   int32        `protobuf:"varint,1,opt,name=All,proto3" json:"All,omitempty"`
-	Name  string       `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
-	Props []*PropsItem `protobuf:"bytes,3,rep,name=Props,proto3" json:"Props,omitempty"`
+	All      int32        `protobuf:"varint,1,opt,name=All,proto3" json:"All,omitempty"`
+	Name     string       `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Props    []*PropsItem `protobuf:"bytes,3,rep,name=Props,proto3" json:"Props,omitempty"`
+	Layer    *LayerItem   `protobuf:"bytes,4,opt,name=Layer,proto3" json:"Layer,omitempty"`       // empty when the feature does not belong to a layer
+	Salt     string       `protobuf:"bytes,5,opt,name=Salt,proto3" json:"Salt,omitempty"`         // hash salt, stable across renames (defaults to the original name)
+	BucketBy string       `protobuf:"bytes,6,opt,name=BucketBy,proto3" json:"BucketBy,omitempty"` // context attribute to hash, empty means the seed chosen by the caller
 }
 
 func (x *FeatureItem) Reset() {
 	*x = FeatureItem{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_FeatureChaos_proto_msgTypes[1]
+		mi := &file_FeatureChaos_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -159,7 +235,7 @@ func (x *FeatureItem) String() string {
 func (*FeatureItem) ProtoMessage() {}
 
 func (x *FeatureItem) ProtoReflect() protoreflect.Message {
-	mi := &file_FeatureChaos_proto_msgTypes[1]
+	mi := &file_FeatureChaos_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -172,7 +248,7 @@ func (x *FeatureItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FeatureItem.ProtoReflect.Descriptor instead.
 func (*FeatureItem) Descriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{1}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *FeatureItem) GetAll() int32 {
@@ -196,19 +272,46 @@ func (x *FeatureItem) GetProps() []*PropsItem {
 	return nil
 }
 
+func (x *FeatureItem) GetLayer() *LayerItem {
+	if x != nil {
+		return x.Layer
+	}
+	return nil
+}
+
+func (x *FeatureItem) GetSalt() string {
+	if x != nil {
+		return x.Salt
+	}
+	return ""
+}
+
+func (x *FeatureItem) GetBucketBy() string {
+	if x != nil {
+		return x.BucketBy
+	}
+	return ""
+}
+
+// Instance fields are optional, they let the admin list which instances run which SDK and configuration version
 type GetAllFeatureRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ServiceName string `protobuf:"bytes,1,opt,name=ServiceName,proto3" json:"ServiceName,omitempty"`
-	LastVersion int64  `protobuf:"varint,2,opt,name=LastVersion,proto3" json:"LastVersion,omitempty"`
+	ServiceName string            `protobuf:"bytes,1,opt,name=ServiceName,proto3" json:"ServiceName,omitempty"`
+	LastVersion int64             `protobuf:"varint,2,opt,name=LastVersion,proto3" json:"LastVersion,omitempty"`
+	InstanceId  string            `protobuf:"bytes,3,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"` // stable for the life of the process, instances without it are not listed
+	Hostname    string            `protobuf:"bytes,4,opt,name=Hostname,proto3" json:"Hostname,omitempty"`
+	SdkName     string            `protobuf:"bytes,5,opt,name=SdkName,proto3" json:"SdkName,omitempty"`
+	SdkVersion  string            `protobuf:"bytes,6,opt,name=SdkVersion,proto3" json:"SdkVersion,omitempty"`
+	Labels      map[string]string `protobuf:"bytes,7,rep,name=Labels,proto3" json:"Labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (x *GetAllFeatureRequest) Reset() {
 	*x = GetAllFeatureRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_FeatureChaos_proto_msgTypes[2]
+		mi := &file_FeatureChaos_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -221,7 +324,7 @@ func (x *GetAllFeatureRequest) String() string {
 func (*GetAllFeatureRequest) ProtoMessage() {}
 
 func (x *GetAllFeatureRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_FeatureChaos_proto_msgTypes[2]
+	mi := &file_FeatureChaos_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -234,7 +337,7 @@ func (x *GetAllFeatureRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAllFeatureRequest.ProtoReflect.Descriptor instead.
 func (*GetAllFeatureRequest) Descriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{2}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *GetAllFeatureRequest) GetServiceName() string {
@@ -251,6 +354,41 @@ func (x *GetAllFeatureRequest) GetLastVersion() int64 {
 	return 0
 }
 
+func (x *GetAllFeatureRequest) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *GetAllFeatureRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *GetAllFeatureRequest) GetSdkName() string {
+	if x != nil {
+		return x.SdkName
+	}
+	return ""
+}
+
+func (x *GetAllFeatureRequest) GetSdkVersion() string {
+	if x != nil {
+		return x.SdkVersion
+	}
+	return ""
+}
+
+func (x *GetAllFeatureRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
 type SendStatsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -263,7 +401,7 @@ type SendStatsRequest struct {
 func (x *SendStatsRequest) Reset() {
 	*x = SendStatsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_FeatureChaos_proto_msgTypes[3]
+		mi := &file_FeatureChaos_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -276,7 +414,7 @@ func (x *SendStatsRequest) String() string {
 func (*SendStatsRequest) ProtoMessage() {}
 
 func (x *SendStatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_FeatureChaos_proto_msgTypes[3]
+	mi := &file_FeatureChaos_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -289,7 +427,7 @@ func (x *SendStatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendStatsRequest.ProtoReflect.Descriptor instead.
 func (*SendStatsRequest) Descriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{3}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SendStatsRequest) GetServiceName() string {
@@ -314,12 +452,18 @@ type GetFeatureResponse struct {
 	Version  int64                             `protobuf:"varint,1,opt,name=Version,proto3" json:"Version,omitempty"`
 	Features []*FeatureItem                    `protobuf:"bytes,2,rep,name=Features,proto3" json:"Features,omitempty"`
 	Deleted  []*GetFeatureResponse_DeletedItem `protobuf:"bytes,3,rep,name=Deleted,proto3" json:"Deleted,omitempty"`
+	// Heartbeats carry no changes: Version is the version the stream has brought the client up to
+	Heartbeat  bool  `protobuf:"varint,4,opt,name=Heartbeat,proto3" json:"Heartbeat,omitempty"`
+	ServerTime int64 `protobuf:"varint,5,opt,name=ServerTime,proto3" json:"ServerTime,omitempty"` // unix milliseconds, set on heartbeats
+	// The server is shutting down: reconnect after ReconnectAfter milliseconds, until then the stream goes on
+	Reconnect      bool  `protobuf:"varint,6,opt,name=Reconnect,proto3" json:"Reconnect,omitempty"`
+	ReconnectAfter int64 `protobuf:"varint,7,opt,name=ReconnectAfter,proto3" json:"ReconnectAfter,omitempty"`
 }
 
 func (x *GetFeatureResponse) Reset() {
 	*x = GetFeatureResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_FeatureChaos_proto_msgTypes[4]
+		mi := &file_FeatureChaos_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -332,7 +476,7 @@ func (x *GetFeatureResponse) String() string {
 func (*GetFeatureResponse) ProtoMessage() {}
 
 func (x *GetFeatureResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_FeatureChaos_proto_msgTypes[4]
+	mi := &file_FeatureChaos_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -345,7 +489,7 @@ func (x *GetFeatureResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFeatureResponse.ProtoReflect.Descriptor instead.
 func (*GetFeatureResponse) Descriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{4}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetFeatureResponse) GetVersion() int64 {
@@ -369,6 +513,90 @@ func (x *GetFeatureResponse) GetDeleted() []*GetFeatureResponse_DeletedItem {
 	return nil
 }
 
+func (x *GetFeatureResponse) GetHeartbeat() bool {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return false
+}
+
+func (x *GetFeatureResponse) GetServerTime() int64 {
+	if x != nil {
+		return x.ServerTime
+	}
+	return 0
+}
+
+func (x *GetFeatureResponse) GetReconnect() bool {
+	if x != nil {
+		return x.Reconnect
+	}
+	return false
+}
+
+func (x *GetFeatureResponse) GetReconnectAfter() int64 {
+	if x != nil {
+		return x.ReconnectAfter
+	}
+	return 0
+}
+
+// Messages of Sync: the first one subscribes, every later one acknowledges the version the client has applied
+type SyncRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Subscribe  *GetAllFeatureRequest `protobuf:"bytes,1,opt,name=Subscribe,proto3" json:"Subscribe,omitempty"`
+	AckVersion int64                 `protobuf:"varint,2,opt,name=AckVersion,proto3" json:"AckVersion,omitempty"`
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_FeatureChaos_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_FeatureChaos_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SyncRequest) GetSubscribe() *GetAllFeatureRequest {
+	if x != nil {
+		return x.Subscribe
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetAckVersion() int64 {
+	if x != nil {
+		return x.AckVersion
+	}
+	return 0
+}
+
 // Explicit deletions since last version
 type GetFeatureResponse_DeletedItem struct {
 	state         protoimpl.MessageState
@@ -384,7 +612,7 @@ type GetFeatureResponse_DeletedItem struct {
 func (x *GetFeatureResponse_DeletedItem) Reset() {
 	*x = GetFeatureResponse_DeletedItem{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_FeatureChaos_proto_msgTypes[6]
+		mi := &file_FeatureChaos_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -397,7 +625,7 @@ func (x *GetFeatureResponse_DeletedItem) String() string {
 func (*GetFeatureResponse_DeletedItem) ProtoMessage() {}
 
 func (x *GetFeatureResponse_DeletedItem) ProtoReflect() protoreflect.Message {
-	mi := &file_FeatureChaos_proto_msgTypes[6]
+	mi := &file_FeatureChaos_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -410,7 +638,7 @@ func (x *GetFeatureResponse_DeletedItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFeatureResponse_DeletedItem.ProtoReflect.Descriptor instead.
 func (*GetFeatureResponse_DeletedItem) Descriptor() ([]byte, []int) {
-	return file_FeatureChaos_proto_rawDescGZIP(), []int{4, 0}
+	return file_FeatureChaos_proto_rawDescGZIP(), []int{5, 0}
 }
 
 func (x *GetFeatureResponse_DeletedItem) GetKind() GetFeatureResponse_DeletedItem_Type {
@@ -458,61 +686,108 @@ var file_FeatureChaos_proto_rawDesc = []byte{
 	0x65, 0x6d, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
 	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
-	0x02, 0x38, 0x01, 0x22, 0x62, 0x0a, 0x0b, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x74,
-	0x65, 0x6d, 0x12, 0x10, 0x0a, 0x03, 0x41, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x03, 0x41, 0x6c, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x50, 0x72, 0x6f, 0x70,
-	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x73, 0x49, 0x74, 0x65, 0x6d,
-	0x52, 0x05, 0x50, 0x72, 0x6f, 0x70, 0x73, 0x22, 0x5a, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x41, 0x6c,
-	0x6c, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x20, 0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d,
-	0x65, 0x12, 0x20, 0x0a, 0x0b, 0x4c, 0x61, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x4c, 0x61, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x22, 0x56, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x46, 0x65, 0x61,
-	0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
-	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x87, 0x03, 0x0a, 0x12,
+	0x02, 0x38, 0x01, 0x22, 0x57, 0x0a, 0x09, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x74, 0x65, 0x6d,
+	0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x61, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x53, 0x61, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x46, 0x72, 0x6f, 0x6d,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02,
+	0x54, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x54, 0x6f, 0x22, 0xc1, 0x01, 0x0a,
+	0x0b, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x10, 0x0a, 0x03,
+	0x41, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x41, 0x6c, 0x6c, 0x12, 0x12,
+	0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x50, 0x72, 0x6f, 0x70, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73,
+	0x2e, 0x50, 0x72, 0x6f, 0x70, 0x73, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x50, 0x72, 0x6f, 0x70,
+	0x73, 0x12, 0x2d, 0x0a, 0x05, 0x4c, 0x61, 0x79, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e,
+	0x4c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x4c, 0x61, 0x79, 0x65, 0x72,
+	0x12, 0x12, 0x0a, 0x04, 0x53, 0x61, 0x6c, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x53, 0x61, 0x6c, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x42, 0x79,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x42, 0x79,
+	0x22, 0xd3, 0x02, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x46, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x4c,
+	0x61, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0b, 0x4c, 0x61, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a,
+	0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x1a, 0x0a,
+	0x08, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x64, 0x6b,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x53, 0x64, 0x6b, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x53, 0x64, 0x6b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x53, 0x64, 0x6b, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x46, 0x0a, 0x06, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61,
+	0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x06, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c,
+	0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x56, 0x0a, 0x10, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x8b,
+	0x04, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x35, 0x0a, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73,
+	0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x08, 0x46, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x46, 0x0a, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x64, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x12, 0x1e, 0x0a, 0x0a,
+	0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09,
+	0x52, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x09, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x52, 0x65,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0e, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x41, 0x66, 0x74,
+	0x65, 0x72, 0x1a, 0xd7, 0x01, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x49, 0x74,
+	0x65, 0x6d, 0x12, 0x45, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x31, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e,
 	0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x08,
-	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19,
-	0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x46, 0x65,
-	0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75,
-	0x72, 0x65, 0x73, 0x12, 0x46, 0x0a, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x03,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68,
-	0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x49, 0x74,
-	0x65, 0x6d, 0x52, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x1a, 0xd7, 0x01, 0x0a, 0x0b,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x45, 0x0a, 0x04, 0x4b,
-	0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x31, 0x2e, 0x46, 0x65, 0x61, 0x74,
-	0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74,
-	0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x4b, 0x69,
-	0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
-	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x4b, 0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4b, 0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c,
-	0x0a, 0x09, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x27, 0x0a, 0x04,
-	0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x10,
-	0x00, 0x12, 0x07, 0x0a, 0x03, 0x4b, 0x45, 0x59, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x41,
-	0x52, 0x41, 0x4d, 0x10, 0x02, 0x32, 0xa8, 0x01, 0x0a, 0x0e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x53, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73,
-	0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x22, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43,
-	0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x46, 0x65, 0x61, 0x74, 0x75,
-	0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x46, 0x65, 0x61, 0x74,
-	0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74,
-	0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x41, 0x0a,
-	0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1e, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
-	0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x28, 0x01,
-	0x42, 0x0f, 0x5a, 0x0d, 0x2f, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f,
-	0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x2e, 0x54,
+	0x79, 0x70, 0x65, 0x52, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x46, 0x65, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x4b,
+	0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4b, 0x65,
+	0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x4e, 0x61,
+	0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x4e,
+	0x61, 0x6d, 0x65, 0x22, 0x27, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x46,
+	0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x4b, 0x45, 0x59, 0x10,
+	0x01, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x41, 0x52, 0x41, 0x4d, 0x10, 0x02, 0x22, 0x6f, 0x0a, 0x0b,
+	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x40, 0x0a, 0x09, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22,
+	0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65,
+	0x74, 0x41, 0x6c, 0x6c, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x52, 0x09, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x1e, 0x0a,
+	0x0a, 0x41, 0x63, 0x6b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0a, 0x41, 0x63, 0x6b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x32, 0xf1, 0x01,
+	0x0a, 0x0e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x53, 0x0a, 0x09, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x22, 0x2e,
+	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74,
+	0x41, 0x6c, 0x6c, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x20, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73,
+	0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x47, 0x0a, 0x04, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x19, 0x2e,
+	0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x53, 0x79, 0x6e,
+	0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x41,
+	0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1e, 0x2e, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x43, 0x68, 0x61, 0x6f, 0x73, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x28,
+	0x01, 0x42, 0x0f, 0x5a, 0x0d, 0x2f, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x61,
+	0x6f, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -528,33 +803,41 @@ func file_FeatureChaos_proto_rawDescGZIP() []byte {
 }
 
 var file_FeatureChaos_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_FeatureChaos_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_FeatureChaos_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_FeatureChaos_proto_goTypes = []any{
 	(GetFeatureResponse_DeletedItem_Type)(0), // 0: FeatureChaos.GetFeatureResponse.DeletedItem.Type
 	(*PropsItem)(nil),                        // 1: FeatureChaos.PropsItem
-	(*FeatureItem)(nil),                      // 2: FeatureChaos.FeatureItem
-	(*GetAllFeatureRequest)(nil),             // 3: FeatureChaos.GetAllFeatureRequest
-	(*SendStatsRequest)(nil),                 // 4: FeatureChaos.SendStatsRequest
-	(*GetFeatureResponse)(nil),               // 5: FeatureChaos.GetFeatureResponse
-	nil,                                      // 6: FeatureChaos.PropsItem.ItemEntry
-	(*GetFeatureResponse_DeletedItem)(nil),   // 7: FeatureChaos.GetFeatureResponse.DeletedItem
-	(*emptypb.Empty)(nil),                    // 8: google.protobuf.Empty
+	(*LayerItem)(nil),                        // 2: FeatureChaos.LayerItem
+	(*FeatureItem)(nil),                      // 3: FeatureChaos.FeatureItem
+	(*GetAllFeatureRequest)(nil),             // 4: FeatureChaos.GetAllFeatureRequest
+	(*SendStatsRequest)(nil),                 // 5: FeatureChaos.SendStatsRequest
+	(*GetFeatureResponse)(nil),               // 6: FeatureChaos.GetFeatureResponse
+	(*SyncRequest)(nil),                      // 7: FeatureChaos.SyncRequest
+	nil,                                      // 8: FeatureChaos.PropsItem.ItemEntry
+	nil,                                      // 9: FeatureChaos.GetAllFeatureRequest.LabelsEntry
+	(*GetFeatureResponse_DeletedItem)(nil),   // 10: FeatureChaos.GetFeatureResponse.DeletedItem
+	(*emptypb.Empty)(nil),                    // 11: google.protobuf.Empty
 }
 var file_FeatureChaos_proto_depIdxs = []int32{
-	6, // 0: FeatureChaos.PropsItem.Item:type_name -> FeatureChaos.PropsItem.ItemEntry
-	1, // 1: FeatureChaos.FeatureItem.Props:type_name -> FeatureChaos.PropsItem
-	2, // 2: FeatureChaos.GetFeatureResponse.Features:type_name -> FeatureChaos.FeatureItem
-	7, // 3: FeatureChaos.GetFeatureResponse.Deleted:type_name -> FeatureChaos.GetFeatureResponse.DeletedItem
-	0, // 4: FeatureChaos.GetFeatureResponse.DeletedItem.Kind:type_name -> FeatureChaos.GetFeatureResponse.DeletedItem.Type
-	3, // 5: FeatureChaos.FeatureService.Subscribe:input_type -> FeatureChaos.GetAllFeatureRequest
-	4, // 6: FeatureChaos.FeatureService.Stats:input_type -> FeatureChaos.SendStatsRequest
-	5, // 7: FeatureChaos.FeatureService.Subscribe:output_type -> FeatureChaos.GetFeatureResponse
-	8, // 8: FeatureChaos.FeatureService.Stats:output_type -> google.protobuf.Empty
-	7, // [7:9] is the sub-list for method output_type
-	5, // [5:7] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	8,  // 0: FeatureChaos.PropsItem.Item:type_name -> FeatureChaos.PropsItem.ItemEntry
+	1,  // 1: FeatureChaos.FeatureItem.Props:type_name -> FeatureChaos.PropsItem
+	2,  // 2: FeatureChaos.FeatureItem.Layer:type_name -> FeatureChaos.LayerItem
+	9,  // 3: FeatureChaos.GetAllFeatureRequest.Labels:type_name -> FeatureChaos.GetAllFeatureRequest.LabelsEntry
+	3,  // 4: FeatureChaos.GetFeatureResponse.Features:type_name -> FeatureChaos.FeatureItem
+	10, // 5: FeatureChaos.GetFeatureResponse.Deleted:type_name -> FeatureChaos.GetFeatureResponse.DeletedItem
+	4,  // 6: FeatureChaos.SyncRequest.Subscribe:type_name -> FeatureChaos.GetAllFeatureRequest
+	0,  // 7: FeatureChaos.GetFeatureResponse.DeletedItem.Kind:type_name -> FeatureChaos.GetFeatureResponse.DeletedItem.Type
+	4,  // 8: FeatureChaos.FeatureService.Subscribe:input_type -> FeatureChaos.GetAllFeatureRequest
+	7,  // 9: FeatureChaos.FeatureService.Sync:input_type -> FeatureChaos.SyncRequest
+	5,  // 10: FeatureChaos.FeatureService.Stats:input_type -> FeatureChaos.SendStatsRequest
+	6,  // 11: FeatureChaos.FeatureService.Subscribe:output_type -> FeatureChaos.GetFeatureResponse
+	6,  // 12: FeatureChaos.FeatureService.Sync:output_type -> FeatureChaos.GetFeatureResponse
+	11, // 13: FeatureChaos.FeatureService.Stats:output_type -> google.protobuf.Empty
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_FeatureChaos_proto_init() }
@@ -576,7 +859,7 @@ func file_FeatureChaos_proto_init() {
 			}
 		}
 		file_FeatureChaos_proto_msgTypes[1].Exporter = func(v any, i int) any {
-			switch v := v.(*FeatureItem); i {
+			switch v := v.(*LayerItem); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -588,7 +871,7 @@ func file_FeatureChaos_proto_init() {
 			}
 		}
 		file_FeatureChaos_proto_msgTypes[2].Exporter = func(v any, i int) any {
-			switch v := v.(*GetAllFeatureRequest); i {
+			switch v := v.(*FeatureItem); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -600,7 +883,7 @@ func file_FeatureChaos_proto_init() {
 			}
 		}
 		file_FeatureChaos_proto_msgTypes[3].Exporter = func(v any, i int) any {
-			switch v := v.(*SendStatsRequest); i {
+			switch v := v.(*GetAllFeatureRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -612,6 +895,18 @@ func file_FeatureChaos_proto_init() {
 			}
 		}
 		file_FeatureChaos_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SendStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_FeatureChaos_proto_msgTypes[5].Exporter = func(v any, i int) any {
 			switch v := v.(*GetFeatureResponse); i {
 			case 0:
 				return &v.state
@@ -624,6 +919,18 @@ func file_FeatureChaos_proto_init() {
 			}
 		}
 		file_FeatureChaos_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*SyncRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_FeatureChaos_proto_msgTypes[9].Exporter = func(v any, i int) any {
 			switch v := v.(*GetFeatureResponse_DeletedItem); i {
 			case 0:
 				return &v.state
@@ -642,7 +949,7 @@ func file_FeatureChaos_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_FeatureChaos_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   7,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,6 +21,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	FeatureService_Subscribe_FullMethodName = "/FeatureChaos.FeatureService/Subscribe"
+	FeatureService_Sync_FullMethodName      = "/FeatureChaos.FeatureService/Sync"
 	FeatureService_Stats_FullMethodName     = "/FeatureChaos.FeatureService/Stats"
 )
 
@@ -29,6 +30,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type FeatureServiceClient interface {
 	Subscribe(ctx context.Context, in *GetAllFeatureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetFeatureResponse], error)
+	// Sync is Subscribe with acknowledgements: every response, heartbeats included, must be acknowledged
+	// within the ack timeout or the server closes the stream
+	Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncRequest, GetFeatureResponse], error)
 	Stats(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SendStatsRequest, emptypb.Empty], error)
 }
 
@@ -59,9 +63,22 @@ func (c *featureServiceClient) Subscribe(ctx context.Context, in *GetAllFeatureR
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FeatureService_SubscribeClient = grpc.ServerStreamingClient[GetFeatureResponse]
 
+func (c *featureServiceClient) Sync(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncRequest, GetFeatureResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FeatureService_ServiceDesc.Streams[1], FeatureService_Sync_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SyncRequest, GetFeatureResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FeatureService_SyncClient = grpc.BidiStreamingClient[SyncRequest, GetFeatureResponse]
+
 func (c *featureServiceClient) Stats(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SendStatsRequest, emptypb.Empty], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &FeatureService_ServiceDesc.Streams[1], FeatureService_Stats_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &FeatureService_ServiceDesc.Streams[2], FeatureService_Stats_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +94,9 @@ type FeatureService_StatsClient = grpc.ClientStreamingClient[SendStatsRequest, e
 // for forward compatibility.
 type FeatureServiceServer interface {
 	Subscribe(*GetAllFeatureRequest, grpc.ServerStreamingServer[GetFeatureResponse]) error
+	// Sync is Subscribe with acknowledgements: every response, heartbeats included, must be acknowledged
+	// within the ack timeout or the server closes the stream
+	Sync(grpc.BidiStreamingServer[SyncRequest, GetFeatureResponse]) error
 	Stats(grpc.ClientStreamingServer[SendStatsRequest, emptypb.Empty]) error
 	mustEmbedUnimplementedFeatureServiceServer()
 }
@@ -91,6 +111,9 @@ type UnimplementedFeatureServiceServer struct{}
 func (UnimplementedFeatureServiceServer) Subscribe(*GetAllFeatureRequest, grpc.ServerStreamingServer[GetFeatureResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
 }
+func (UnimplementedFeatureServiceServer) Sync(grpc.BidiStreamingServer[SyncRequest, GetFeatureResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
 func (UnimplementedFeatureServiceServer) Stats(grpc.ClientStreamingServer[SendStatsRequest, emptypb.Empty]) error {
 	return status.Errorf(codes.Unimplemented, "method Stats not implemented")
 }
@@ -126,6 +149,13 @@ func _FeatureService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FeatureService_SubscribeServer = grpc.ServerStreamingServer[GetFeatureResponse]
 
+func _FeatureService_Sync_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FeatureServiceServer).Sync(&grpc.GenericServerStream[SyncRequest, GetFeatureResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FeatureService_SyncServer = grpc.BidiStreamingServer[SyncRequest, GetFeatureResponse]
+
 func _FeatureService_Stats_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(FeatureServiceServer).Stats(&grpc.GenericServerStream[SendStatsRequest, emptypb.Empty]{ServerStream: stream})
 }
@@ -146,6 +176,12 @@ var FeatureService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _FeatureService_Subscribe_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Sync",
+			Handler:       _FeatureService_Sync_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "Stats",
 			Handler:       _FeatureService_Stats_Handler,
@@ -1,16 +1,25 @@
 package FeatureChaos
 
 import (
+	"errors"
 	"io"
 	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/controller"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/pkg/server/grpc"
+	"gitlab.com/devpro_studio/go_utils/decode"
 	grpc2 "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -19,6 +28,16 @@ type Controller struct {
 	UnimplementedFeatureServiceServer
 	featureService FeatureService.Interface
 	statsService   StatsService.Interface
+	clients        ClientService.Interface
+
+	config Config
+}
+
+type Config struct {
+	// HeartbeatInterval is how long a quiet stream waits before it sends a heartbeat
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// AckTimeout closes Sync streams that left a response unacknowledged for this long
+	AckTimeout time.Duration `yaml:"ack_timeout"`
 }
 
 func NewController(name string) *Controller {
@@ -29,95 +48,170 @@ func NewController(name string) *Controller {
 	}
 }
 
-func (t *Controller) Init(app interfaces.IEngine, _ map[string]interface{}) error {
-	app.GetPkg(interfaces.PkgServer, names.GrpcServer).(grpc.IGrpc).RegisterService(&FeatureService_ServiceDesc, t)
+func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.HeartbeatInterval == 0 {
+		t.config.HeartbeatInterval = 15 * time.Second
+	}
+	if t.config.AckTimeout == 0 {
+		t.config.AckTimeout = 3 * t.config.HeartbeatInterval
+	}
+
+	server := app.GetPkg(interfaces.PkgServer, names.GrpcServer).(grpc.IGrpc)
+	server.RegisterService(&FeatureService_ServiceDesc, t)
+	if health, ok := app.GetModule(interfaces.ModuleService, names.HealthService).(HealthService.Interface); ok {
+		server.RegisterService(&grpc_health_v1.Health_ServiceDesc, &healthServer{health: health})
+	}
 	t.featureService = app.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface)
 	t.statsService = app.GetModule(interfaces.ModuleService, names.StatsService).(StatsService.Interface)
+	// The client registry needs the database, relays run without it
+	t.clients, _ = app.GetModule(interfaces.ModuleService, names.ClientService).(ClientService.Interface)
 	return nil
 }
 
 func (t *Controller) Subscribe(request *GetAllFeatureRequest, response grpc2.ServerStreamingServer[GetFeatureResponse]) error {
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
-	lastVersion := request.LastVersion
+	c := response.Context()
 
-	for {
-		select {
-		case <-response.Context().Done():
-			return nil
+	client := toClient(request)
+	if t.clients != nil {
+		t.clients.Connect(c, client)
+		defer t.clients.Disconnect(c, client)
+	}
 
-		case <-ticker.C:
-			version, features := t.featureService.GetNewFeature(response.Context(), request.ServiceName, lastVersion)
+	err := t.featureService.Watch(c, request.ServiceName, request.LastVersion, t.config.HeartbeatInterval, func(version int64, after time.Duration) error {
+		return response.Send(toReconnect(version, after))
+	}, func(version int64, features []*dto.Feature) error {
+		if err := response.Send(toResponse(version, features)); err != nil {
+			return err
+		}
 
-			if len(features) > 0 {
-				resp := &GetFeatureResponse{
-					Features: make([]*FeatureItem, 0, len(features)),
-					Deleted:  make([]*GetFeatureResponse_DeletedItem, 0),
-				}
+		// Subscribe has no acknowledgements, unlike Sync, a delivered response is the best evidence of the client's version
+		if t.clients != nil {
+			t.clients.Advance(client, version)
+		}
+		return nil
+	})
 
-				for _, feature := range features {
-					// If feature is deleted, record and skip deeper levels
-					if feature.IsDeleted {
-						resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
-							Kind:        GetFeatureResponse_DeletedItem_FEATURE,
-							FeatureName: feature.Name,
-						})
-						continue
-					}
-
-					props := make([]*PropsItem, 0, len(feature.Keys))
-
-					for _, key := range feature.Keys {
-						// If key is deleted, record and skip params
-						if key.IsDeleted {
-							resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
-								Kind:        GetFeatureResponse_DeletedItem_KEY,
-								FeatureName: feature.Name,
-								KeyName:     key.Key,
-							})
-							continue
-						}
-
-						items := make(map[string]int32, len(key.Params))
-
-						for _, param := range key.Params {
-							// If param is deleted, record and skip adding to map
-							if param.IsDeleted {
-								resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
-									Kind:        GetFeatureResponse_DeletedItem_PARAM,
-									FeatureName: feature.Name,
-									KeyName:     key.Key,
-									ParamName:   param.Name,
-								})
-								continue
-							}
-							items[param.Name] = int32(param.Value)
-						}
-
-						props = append(props, &PropsItem{
-							All:  int32(key.Value),
-							Name: key.Key,
-							Item: items,
-						})
-					}
-
-					resp.Features = append(resp.Features, &FeatureItem{
-						All:   int32(feature.Value),
-						Name:  feature.Name,
-						Props: props,
-					})
-				}
+	return watchError(err)
+}
+
+func toClient(request *GetAllFeatureRequest) *db.Client {
+	return &db.Client{
+		ServiceName: request.ServiceName,
+		InstanceId:  request.InstanceId,
+		Hostname:    request.Hostname,
+		SdkName:     request.SdkName,
+		SdkVersion:  request.SdkVersion,
+		Labels:      request.Labels,
+		Transport:   db.ClientTransportGrpc,
+		Version:     request.LastVersion,
+	}
+}
+
+// toResponse is a delta, or a heartbeat when there are no features
+func toResponse(version int64, features []*dto.Feature) *GetFeatureResponse {
+	if len(features) == 0 {
+		return &GetFeatureResponse{Version: version, Heartbeat: true, ServerTime: time.Now().UnixMilli()}
+	}
+
+	return ToFeatureResponse(version, features)
+}
+
+// toReconnect asks the client to move to another instance after the delay, version is where the stream is at
+func toReconnect(version int64, after time.Duration) *GetFeatureResponse {
+	return &GetFeatureResponse{Version: version, Reconnect: true, ReconnectAfter: after.Milliseconds()}
+}
+
+// watchError ends a stream, a draining server answers Unavailable so clients retry on another instance
+func watchError(err error) error {
+	if errors.Is(err, FeatureService.ErrDraining) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	return err
+}
+
+// ToFeatureResponse converts a change set into the wire message, bootstrap snapshots reuse it
+func ToFeatureResponse(version int64, features []*dto.Feature) *GetFeatureResponse {
+	resp := &GetFeatureResponse{
+		Features: make([]*FeatureItem, 0, len(features)),
+		Deleted:  make([]*GetFeatureResponse_DeletedItem, 0),
+	}
+
+	for _, feature := range features {
+		// If feature is deleted, record and skip deeper levels
+		if feature.IsDeleted {
+			resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
+				Kind:        GetFeatureResponse_DeletedItem_FEATURE,
+				FeatureName: feature.Name,
+			})
+			continue
+		}
+
+		props := make([]*PropsItem, 0, len(feature.Keys))
+
+		for _, key := range feature.Keys {
+			// If key is deleted, record and skip params
+			if key.IsDeleted {
+				resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
+					Kind:        GetFeatureResponse_DeletedItem_KEY,
+					FeatureName: feature.Name,
+					KeyName:     key.Key,
+				})
+				continue
+			}
 
-				resp.Version = version
+			items := make(map[string]int32, len(key.Params))
 
-				if err := response.Send(resp); err != nil {
-					return err
+			for _, param := range key.Params {
+				// If param is deleted, record and skip adding to map
+				if param.IsDeleted {
+					resp.Deleted = append(resp.Deleted, &GetFeatureResponse_DeletedItem{
+						Kind:        GetFeatureResponse_DeletedItem_PARAM,
+						FeatureName: feature.Name,
+						KeyName:     key.Key,
+						ParamName:   param.Name,
+					})
+					continue
 				}
+				items[param.Name] = int32(param.Value)
 			}
 
-			lastVersion = version
+			props = append(props, &PropsItem{
+				All:  int32(key.Value),
+				Name: key.Key,
+				Item: items,
+			})
+		}
+
+		item := &FeatureItem{
+			All:      int32(feature.Value),
+			Name:     feature.Name,
+			Props:    props,
+			Salt:     feature.Salt,
+			BucketBy: feature.BucketBy,
 		}
+
+		if feature.Layer != nil {
+			item.Layer = &LayerItem{
+				Name: feature.Layer.Name,
+				Salt: feature.Layer.Salt,
+				From: int32(feature.Layer.From),
+				To:   int32(feature.Layer.To),
+			}
+		}
+
+		resp.Features = append(resp.Features, item)
 	}
+
+	resp.Version = version
+
+	return resp
 }
 
 func (t *Controller) Stats(request grpc2.ClientStreamingServer[SendStatsRequest, emptypb.Empty]) error {
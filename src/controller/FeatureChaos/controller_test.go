@@ -0,0 +1,156 @@
+package FeatureChaos
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+	grpc2 "google.golang.org/grpc"
+)
+
+// subscribeStream collects the first response and ends the subscription
+type subscribeStream struct {
+	grpc2.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	sent   []*GetFeatureResponse
+}
+
+func (t *subscribeStream) Context() context.Context { return t.ctx }
+
+func (t *subscribeStream) Send(resp *GetFeatureResponse) error {
+	t.sent = append(t.sent, resp)
+	t.cancel()
+	return nil
+}
+
+func subscribe(t *testing.T, rows [][]any, renames [][]any) *GetFeatureResponse {
+	t.Helper()
+
+	pg := &postgres.Mock{
+		QueryFunc: func(_ context.Context, query string, _ ...any) (postgres.SQLRows, error) {
+			if strings.Contains(query, "FROM renames") {
+				return &postgres.MockRows{Values: renames}, nil
+			}
+			return &postgres.MockRows{Values: rows}, nil
+		},
+	}
+	versions := VersionRepository.NewForTest(pg, &redis.Mock{Data: map[string]string{"feature_version": "5"}}, mock_log.New(true))
+	c := &Controller{
+		featureService: FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, versions, mock_log.New(true))),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream := &subscribeStream{ctx: ctx, cancel: cancel}
+
+	if err := c.Subscribe(&GetAllFeatureRequest{ServiceName: "billing", LastVersion: 4}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 1 {
+		t.Fatalf("expected one response, got %d", len(stream.sent))
+	}
+
+	return stream.sent[0]
+}
+
+func TestController_SubscribeBound(t *testing.T) {
+	featureId, keyId := uuid.New().String(), uuid.New().String()
+
+	// Values older than last_version arrive because the binding itself is new
+	resp := subscribe(t, [][]any{
+		{featureId, "checkout", nil, nil, nil, nil, 40, int64(1), nil, nil, nil, nil, nil, nil, nil},
+		{featureId, "checkout", keyId, "country", nil, nil, 10, int64(1), nil, nil, nil, nil, nil, nil, nil},
+	}, nil)
+
+	if resp.Version != 5 || len(resp.Features) != 1 || len(resp.Deleted) != 0 {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	if f := resp.Features[0]; f.Name != "checkout" || f.All != 40 || len(f.Props) != 1 || f.Props[0].Name != "country" {
+		t.Errorf("newly bound feature must arrive in full, got %v", f)
+	}
+}
+
+func TestController_SubscribeUnbound(t *testing.T) {
+	resp := subscribe(t, [][]any{
+		{uuid.New().String(), "checkout", nil, nil, nil, nil, 40, int64(1), time.Now(), nil, nil, nil, nil, nil, nil},
+	}, nil)
+
+	if resp.Version != 5 || len(resp.Features) != 0 || len(resp.Deleted) != 1 {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	if d := resp.Deleted[0]; d.Kind != GetFeatureResponse_DeletedItem_FEATURE || d.FeatureName != "checkout" {
+		t.Errorf("unbound feature must arrive as a FEATURE tombstone, got %v", d)
+	}
+}
+
+func TestController_SubscribeRenames(t *testing.T) {
+	featureId, keyId, paramId := uuid.New(), uuid.New(), uuid.New()
+	live := func(feature string, key string, param string) [][]any {
+		return [][]any{
+			{featureId.String(), feature, nil, nil, nil, nil, 40, int64(5), nil, nil, nil, nil, nil, nil, nil},
+			{featureId.String(), feature, keyId.String(), key, nil, nil, 10, int64(5), nil, nil, nil, nil, nil, nil, nil},
+			{featureId.String(), feature, keyId.String(), key, paramId.String(), param, 100, int64(5), nil, nil, nil, nil, nil, nil, nil},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rows    [][]any
+		renames [][]any
+		deleted []*GetFeatureResponse_DeletedItem
+	}{
+		{
+			name:    "feature",
+			rows:    live("checkout_v2", "country", "DE"),
+			renames: [][]any{{0, featureId.String(), nil, "checkout", "", ""}},
+			deleted: []*GetFeatureResponse_DeletedItem{{Kind: GetFeatureResponse_DeletedItem_FEATURE, FeatureName: "checkout"}},
+		},
+		{
+			name:    "key",
+			rows:    live("checkout", "region", "DE"),
+			renames: [][]any{{1, featureId.String(), keyId.String(), "checkout", "country", ""}},
+			deleted: []*GetFeatureResponse_DeletedItem{{Kind: GetFeatureResponse_DeletedItem_KEY, FeatureName: "checkout", KeyName: "country"}},
+		},
+		{
+			name:    "param",
+			rows:    live("checkout", "country", "DE"),
+			renames: [][]any{{2, featureId.String(), keyId.String(), "checkout", "country", "GERMANY"}},
+			deleted: []*GetFeatureResponse_DeletedItem{{Kind: GetFeatureResponse_DeletedItem_PARAM, FeatureName: "checkout", KeyName: "country", ParamName: "GERMANY"}},
+		},
+		{
+			// Renamed and renamed back within one delta, the live name must not be deleted
+			name:    "renamed back",
+			rows:    live("checkout", "country", "DE"),
+			renames: [][]any{{0, featureId.String(), nil, "checkout", "", ""}, {0, featureId.String(), nil, "checkout_v2", "", ""}},
+			deleted: []*GetFeatureResponse_DeletedItem{{Kind: GetFeatureResponse_DeletedItem_FEATURE, FeatureName: "checkout_v2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := subscribe(t, tt.rows, tt.renames)
+
+			if len(resp.Features) != 1 || len(resp.Features[0].Props) != 1 || len(resp.Features[0].Props[0].Item) != 1 {
+				t.Fatalf("the renamed entity must arrive whole under its new name, got %v", resp.Features)
+			}
+			if len(resp.Deleted) != len(tt.deleted) {
+				t.Fatalf("expected deletions %v, got %v", tt.deleted, resp.Deleted)
+			}
+			for i, want := range tt.deleted {
+				got := resp.Deleted[i]
+				if got.Kind != want.Kind || got.FeatureName != want.FeatureName || got.KeyName != want.KeyName || got.ParamName != want.ParamName {
+					t.Errorf("deletion %d: expected %v, got %v", i, want, got)
+				}
+			}
+		})
+	}
+}
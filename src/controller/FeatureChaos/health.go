@@ -0,0 +1,83 @@
+package FeatureChaos
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
+	grpc2 "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// livenessService is the health service name of the liveness probe, the empty name and
+// FeatureChaos.FeatureService report readiness
+const livenessService = "liveness"
+
+// How often Watch re-runs the checks of a watched service
+const healthWatchEvery = 5 * time.Second
+
+// healthServer is the standard grpc.health.v1 service over HealthService
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	health HealthService.Interface
+}
+
+func (t *healthServer) Check(c context.Context, request *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	report, ok := t.report(c, request.Service)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus(report)}, nil
+}
+
+func (t *healthServer) Watch(request *grpc_health_v1.HealthCheckRequest, stream grpc2.ServerStreamingServer[grpc_health_v1.HealthCheckResponse]) error {
+	c := stream.Context()
+
+	ticker := time.NewTicker(healthWatchEvery)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for {
+		current := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		if report, ok := t.report(c, request.Service); ok {
+			current = servingStatus(report)
+		}
+
+		// Only changes are sent
+		if current != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-c.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *healthServer) report(c context.Context, service string) (dto.HealthReport, bool) {
+	switch service {
+	case livenessService:
+		return t.health.Live(c), true
+	case "", FeatureService_ServiceDesc.ServiceName:
+		return t.health.Ready(c), true
+	default:
+		return dto.HealthReport{}, false
+	}
+}
+
+func servingStatus(report dto.HealthReport) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if report.Ok {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,43 @@
+package FeatureChaos
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type fakeHealth struct {
+	HealthService.Interface
+	ready bool
+}
+
+func (t *fakeHealth) Live(context.Context) dto.HealthReport  { return dto.HealthReport{Ok: true} }
+func (t *fakeHealth) Ready(context.Context) dto.HealthReport { return dto.HealthReport{Ok: t.ready} }
+
+func TestHealthServer_Check(t *testing.T) {
+	server := &healthServer{health: &fakeHealth{}}
+
+	tests := []struct {
+		service string
+		want    grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{service: "", want: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{service: "FeatureChaos.FeatureService", want: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{service: livenessService, want: grpc_health_v1.HealthCheckResponse_SERVING},
+	}
+	for _, tt := range tests {
+		resp, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+		if err != nil || resp.Status != tt.want {
+			t.Errorf("%q: expected %v, got %v %v", tt.service, tt.want, resp, err)
+		}
+	}
+
+	if _, err := server.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown service must be NotFound, got %v", err)
+	}
+}
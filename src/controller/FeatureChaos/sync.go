@@ -0,0 +1,126 @@
+package FeatureChaos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	grpc2 "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errAckTimeout = errors.New("response was not acknowledged in time")
+
+// acks tracks the responses of a Sync stream the client has not acknowledged yet
+type acks struct {
+	mu      sync.Mutex
+	version int64
+	// since is when the oldest unacknowledged response was sent, zero when everything is acknowledged
+	since time.Time
+}
+
+// sent records a response before it goes out, so an acknowledgement racing the send is not lost
+func (a *acks) sent(version int64, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.version = version
+	if a.since.IsZero() {
+		a.since = now
+	}
+}
+
+// ack settles every response up to version
+func (a *acks) ack(version int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if version >= a.version {
+		a.since = time.Time{}
+	}
+}
+
+func (a *acks) expired(now time.Time, timeout time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return !a.since.IsZero() && now.Sub(a.since) > timeout
+}
+
+func (t *Controller) Sync(stream grpc2.BidiStreamingServer[SyncRequest, GetFeatureResponse]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	request := first.GetSubscribe()
+	if request == nil {
+		return status.Error(codes.InvalidArgument, "the first message must subscribe")
+	}
+
+	c, cancel := context.WithCancelCause(stream.Context())
+	defer cancel(nil)
+
+	client := toClient(request)
+	if t.clients != nil {
+		t.clients.Connect(c, client)
+		defer t.clients.Disconnect(c, client)
+	}
+
+	pending := &acks{version: request.LastVersion}
+	go t.receiveAcks(cancel, stream, pending, client)
+	if t.config.AckTimeout > 0 {
+		go t.expireAcks(c, cancel, pending)
+	}
+
+	err = t.featureService.Watch(c, request.ServiceName, request.LastVersion, t.config.HeartbeatInterval, func(version int64, after time.Duration) error {
+		pending.sent(version, time.Now())
+		return stream.Send(toReconnect(version, after))
+	}, func(version int64, features []*dto.Feature) error {
+		pending.sent(version, time.Now())
+		return stream.Send(toResponse(version, features))
+	})
+	if errors.Is(context.Cause(c), errAckTimeout) {
+		return status.Error(codes.DeadlineExceeded, errAckTimeout.Error())
+	}
+
+	return watchError(err)
+}
+
+// receiveAcks applies acknowledgements until the client stops sending, an acknowledged version is what
+// the client registry reports for the instance
+func (t *Controller) receiveAcks(cancel context.CancelCauseFunc, stream grpc2.BidiStreamingServer[SyncRequest, GetFeatureResponse], pending *acks, client *db.Client) {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			// A half-closed or broken stream cannot acknowledge anything anymore
+			cancel(err)
+			return
+		}
+
+		pending.ack(msg.AckVersion)
+		if t.clients != nil && msg.AckVersion > 0 {
+			t.clients.Advance(client, msg.AckVersion)
+		}
+	}
+}
+
+func (t *Controller) expireAcks(c context.Context, cancel context.CancelCauseFunc, pending *acks) {
+	ticker := time.NewTicker(t.config.AckTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case now := <-ticker.C:
+			if pending.expired(now, t.config.AckTimeout) {
+				cancel(errAckTimeout)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package FeatureChaos
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	grpc2 "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quietFeatures never has changes, its streams only carry heartbeats
+type quietFeatures struct {
+	FeatureService.Interface
+}
+
+func (t *quietFeatures) Watch(c context.Context, _ string, lastVersion int64, heartbeat time.Duration, _ func(int64, time.Duration) error, send func(int64, []*dto.Feature) error) error {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return nil
+		case <-ticker.C:
+			if err := send(lastVersion, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// syncStream subscribes, then acknowledges every response when ack is set
+type syncStream struct {
+	grpc2.ServerStream
+	ctx  context.Context
+	ack  bool
+	in   chan *SyncRequest
+	sent chan *GetFeatureResponse
+}
+
+func newSyncStream(ctx context.Context, ack bool) *syncStream {
+	s := &syncStream{ctx: ctx, ack: ack, in: make(chan *SyncRequest, 16), sent: make(chan *GetFeatureResponse, 16)}
+	s.in <- &SyncRequest{Subscribe: &GetAllFeatureRequest{ServiceName: "billing", LastVersion: 5}}
+	return s
+}
+
+func (t *syncStream) Context() context.Context { return t.ctx }
+
+func (t *syncStream) Send(resp *GetFeatureResponse) error {
+	if t.ack {
+		t.in <- &SyncRequest{AckVersion: resp.Version}
+	}
+	select {
+	case t.sent <- resp:
+	default:
+	}
+	return nil
+}
+
+func (t *syncStream) Recv() (*SyncRequest, error) {
+	select {
+	case msg := <-t.in:
+		return msg, nil
+	case <-t.ctx.Done():
+		return nil, io.EOF
+	}
+}
+
+func TestController_SyncHeartbeats(t *testing.T) {
+	c := &Controller{
+		featureService: &quietFeatures{},
+		config:         Config{HeartbeatInterval: 10 * time.Millisecond, AckTimeout: 40 * time.Millisecond},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	stream := newSyncStream(ctx, true)
+
+	if err := c.Sync(stream); err != nil {
+		t.Fatalf("acknowledging client must stay connected, got %v", err)
+	}
+
+	resp := <-stream.sent
+	if !resp.Heartbeat || resp.Version != 5 || resp.ServerTime == 0 || len(resp.Features) != 0 {
+		t.Errorf("expected a heartbeat at version 5, got %v", resp)
+	}
+}
+
+func TestController_SyncClosesUnacknowledged(t *testing.T) {
+	c := &Controller{
+		featureService: &quietFeatures{},
+		config:         Config{HeartbeatInterval: 10 * time.Millisecond, AckTimeout: 40 * time.Millisecond},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := c.Sync(newSyncStream(ctx, false))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("silent client must be disconnected, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("stream must be closed by the ack timeout, not the test deadline")
+	}
+}
+
+func TestController_SyncRequiresSubscribe(t *testing.T) {
+	c := &Controller{featureService: &quietFeatures{}}
+
+	stream := newSyncStream(context.Background(), false)
+	<-stream.in
+	stream.in <- &SyncRequest{AckVersion: 3}
+
+	if err := c.Sync(stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package PublicHTTP
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+// Polling with GET keeps the instance fields out of the URL so shared caches still serve every instance alike
+const (
+	headerInstanceId = "X-Instance-Id"
+	headerHostname   = "X-Hostname"
+	headerSdkName    = "X-Sdk-Name"
+	headerSdkVersion = "X-Sdk-Version"
+	headerLabels     = "X-Labels"
+)
+
+// clientInfo identifies the SDK instance behind a request, it mirrors the instance fields of GetAllFeatureRequest
+type clientInfo struct {
+	InstanceId string            `json:"instance_id,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	SdkName    string            `json:"sdk_name,omitempty"`
+	SdkVersion string            `json:"sdk_version,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+func (i clientInfo) client(serviceName string, transport string, version int64) *db.Client {
+	return &db.Client{
+		ServiceName: serviceName,
+		InstanceId:  i.InstanceId,
+		Hostname:    i.Hostname,
+		SdkName:     i.SdkName,
+		SdkVersion:  i.SdkVersion,
+		Labels:      i.Labels,
+		Transport:   transport,
+		Version:     version,
+	}
+}
+
+func clientFromHeader(h interface{ Get(string) string }) clientInfo {
+	return clientInfo{
+		InstanceId: h.Get(headerInstanceId),
+		Hostname:   h.Get(headerHostname),
+		SdkName:    h.Get(headerSdkName),
+		SdkVersion: h.Get(headerSdkVersion),
+		Labels:     parseLabels(h.Get(headerLabels)),
+	}
+}
+
+// clientFromQuery serves SSE, browsers cannot set headers on an EventSource
+func clientFromQuery(q url.Values) clientInfo {
+	return clientInfo{
+		InstanceId: q.Get("instance_id"),
+		Hostname:   q.Get("hostname"),
+		SdkName:    q.Get("sdk_name"),
+		SdkVersion: q.Get("sdk_version"),
+		Labels:     parseLabels(q.Get("labels")),
+	}
+}
+
+// parseLabels reads "k1=v1,k2=v2", pairs without a key are skipped
+func parseLabels(v string) map[string]string {
+	if v == "" {
+		return nil
+	}
+
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+
+	return labels
+}
+
+// seen registers a polling client, the registry is absent in relay mode
+func (t *Controller) seen(c context.Context, info clientInfo, serviceName string, lastVersion int64) {
+	if t.clients == nil {
+		return
+	}
+	t.clients.Seen(c, info.client(serviceName, db.ClientTransportPoll, lastVersion))
+}
+
+// connect registers an update stream, the returned functions record deliveries and the disconnect
+func (t *Controller) connect(c context.Context, info clientInfo, serviceName string, transport string, lastVersion int64) (func(int64), func()) {
+	if t.clients == nil {
+		return func(int64) {}, func() {}
+	}
+
+	client := info.client(serviceName, transport, lastVersion)
+	t.clients.Connect(c, client)
+
+	return func(version int64) { t.clients.Advance(client, version) },
+		func() { t.clients.Disconnect(c, client) }
+}
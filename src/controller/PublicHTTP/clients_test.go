@@ -0,0 +1,56 @@
+package PublicHTTP
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type recordingClients struct {
+	ClientService.Interface
+	seen []*db.Client
+}
+
+func (r *recordingClients) Seen(_ context.Context, client *db.Client) {
+	r.seen = append(r.seen, client)
+}
+
+func TestParseLabels(t *testing.T) {
+	got := parseLabels(" region = eu ,canary=true,=skipped,bare")
+	want := map[string]string{"region": "eu", "canary": "true", "bare": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if parseLabels("") != nil {
+		t.Errorf("empty labels must be nil")
+	}
+}
+
+func TestController_pollersAreRegistered(t *testing.T) {
+	clients := &recordingClients{}
+	c := newPollController(map[string]string{"feature_version": "2"}, nil)
+	c.clients = clients
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("POST", "/api/updates", bytes.NewBufferString(
+		`{"service_name":"billing","last_version":2,"instance_id":"a1","sdk_name":"go","sdk_version":"1.4.0","labels":{"region":"eu"}}`)))
+	c.getUpdates(context.Background(), ctx)
+
+	poll(c, "/api/updates?service_name=billing&last_version=1", map[string]string{
+		headerInstanceId: "b2", headerHostname: "billing-7f9c", headerSdkName: "py", headerLabels: "region=us",
+	})
+
+	want := []*db.Client{
+		{ServiceName: "billing", InstanceId: "a1", SdkName: "go", SdkVersion: "1.4.0", Labels: map[string]string{"region": "eu"}, Transport: db.ClientTransportPoll, Version: 2},
+		{ServiceName: "billing", InstanceId: "b2", Hostname: "billing-7f9c", SdkName: "py", Labels: map[string]string{"region": "us"}, Transport: db.ClientTransportPoll, Version: 1},
+	}
+	if !reflect.DeepEqual(clients.seen, want) {
+		t.Errorf("expected %+v, got %+v", want, clients.seen)
+	}
+}
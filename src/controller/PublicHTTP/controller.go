@@ -2,39 +2,136 @@ package PublicHTTP
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/BootstrapService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ClientService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/EvaluationService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ExperimentService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/controller"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+	"gitlab.com/devpro_studio/go_utils/decode"
 )
 
 type Controller struct {
 	controller.Mock
+	logger         interfaces.ILogger
 	featureService FeatureService.Interface
 	statsService   StatsService.Interface
+	experiments    ExperimentService.Interface
+	evaluation     EvaluationService.Interface
+	signer         SignService.Interface
+	bootstrap      BootstrapService.Interface
+	clients        ClientService.Interface
+	health         HealthService.Interface
+
+	config Config
+	stream *streamServer
+}
+
+type Config struct {
+	// StreamAddr enables the streaming listener (SSE), the buffered public server cannot hold connections open
+	StreamAddr string        `yaml:"stream_addr"`
+	KeepAlive  time.Duration `yaml:"keep_alive"`
+	// HeartbeatTimeout closes WebSocket connections that stayed silent for this long
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout"`
+	// MaxWait caps long-polling on GET /api/updates, keep it below the server write timeout
+	MaxWait time.Duration `yaml:"max_wait"`
 }
 
+// Upper bound of events accepted in one request, keeps a single insert well below the postgres bind limit
+const maxEventsPerRequest = 1000
+
 func New(name string) *Controller {
 	return &Controller{Mock: controller.Mock{NamePkg: name}}
 }
 
-func (t *Controller) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+func (t *Controller) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.KeepAlive == 0 {
+		t.config.KeepAlive = 15 * time.Second
+	}
+	if t.config.HeartbeatTimeout == 0 {
+		t.config.HeartbeatTimeout = 3 * t.config.KeepAlive
+	}
+	if t.config.MaxWait == 0 {
+		t.config.MaxWait = 30 * time.Second
+	}
+
 	// resolve dependencies
+	t.logger = app.GetLogger()
 	t.featureService = app.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface)
 	t.statsService = app.GetModule(interfaces.ModuleService, names.StatsService).(StatsService.Interface)
+	// Database backed services are absent in relay mode, their routes are not mounted then
+	t.experiments, _ = app.GetModule(interfaces.ModuleService, names.ExperimentService).(ExperimentService.Interface)
+	t.evaluation, _ = app.GetModule(interfaces.ModuleService, names.EvaluationService).(EvaluationService.Interface)
+	t.signer, _ = app.GetModule(interfaces.ModuleService, names.SignService).(SignService.Interface)
+	t.bootstrap, _ = app.GetModule(interfaces.ModuleService, names.BootstrapService).(BootstrapService.Interface)
+	t.clients, _ = app.GetModule(interfaces.ModuleService, names.ClientService).(ClientService.Interface)
+	t.health, _ = app.GetModule(interfaces.ModuleService, names.HealthService).(HealthService.Interface)
 
 	// mount routes on public HTTP server
 	http := app.GetPkg(interfaces.PkgServer, names.HttpPublicServer).(httpSrv.IHttp)
 	http.PushRoute("POST", "/api/updates", t.getUpdates, nil)
+	http.PushRoute("GET", "/api/updates", t.pollUpdates, nil)
 	http.PushRoute("POST", "/api/stats", t.postStats, nil)
+	if t.health != nil {
+		http.PushRoute("GET", "/healthz", t.healthz, nil)
+		http.PushRoute("GET", "/readyz", t.readyz, nil)
+	}
+	if t.experiments != nil {
+		http.PushRoute("POST", "/api/exposures", t.postExposures, nil)
+		http.PushRoute("POST", "/api/metrics", t.postMetrics, nil)
+	}
+	if t.evaluation != nil {
+		http.PushRoute("POST", "/api/evaluate", t.evaluate, nil)
+	}
+	if t.bootstrap != nil {
+		http.PushRoute("GET", "/api/bootstrap", t.getBootstrap, nil)
+	}
+	if t.signer != nil {
+		// Verifies evaluation tokens and bootstrap files alike
+		http.PushRoute("GET", "/api/evaluate/keys", t.evaluateKeys, nil)
+	}
+
+	if t.config.StreamAddr != "" {
+		stream, err := t.startStream(t.config.StreamAddr)
+		if err != nil {
+			return err
+		}
+		t.stream = stream
+	}
+
 	return nil
 }
 
+func (t *Controller) Stop() error {
+	if t.stream == nil {
+		return nil
+	}
+	return t.stream.stop()
+}
+
 // helpers
 func respondJSON(ctx httpSrv.ICtx, status int, v any) {
 	b, _ := json.Marshal(v)
@@ -56,7 +153,152 @@ func (t *Controller) getUpdates(c context.Context, ctx httpSrv.ICtx) {
 		return
 	}
 
+	t.seen(c, req.clientInfo, req.ServiceName, req.LastVersion)
+
 	version, features := t.featureService.GetNewFeature(c, req.ServiceName, req.LastVersion)
+	resp := toUpdatesResponse(version, features)
+
+	respondJSON(ctx, http.StatusOK, resp)
+}
+
+// pollUpdates is the cacheable variant of getUpdates, a given URL only changes its answer with the global version
+func (t *Controller) pollUpdates(c context.Context, ctx httpSrv.ICtx) {
+	query := ctx.GetRequest().GetQuery()
+
+	serviceName := query.Get("service_name")
+	if serviceName == "" {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "service_name required"})
+		return
+	}
+
+	var lastVersion int64
+	if v := query.Get("last_version"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid last_version"})
+			return
+		}
+		lastVersion = parsed
+	}
+
+	wait, err := parseWait(query.Get("wait"))
+	if err != nil {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid wait"})
+		return
+	}
+	if wait > t.config.MaxWait {
+		wait = t.config.MaxWait
+	}
+
+	t.seen(c, clientFromHeader(ctx.GetRequest().GetHeader()), serviceName, lastVersion)
+
+	var (
+		version  int64
+		features []*dto.Feature
+	)
+	if wait > 0 {
+		version, features = t.waitForUpdates(c, serviceName, lastVersion, wait)
+	} else {
+		version, features = t.featureService.GetNewFeature(c, serviceName, lastVersion)
+	}
+
+	etag := `"` + strconv.FormatInt(version, 10) + `"`
+	header := ctx.GetResponse().Header()
+	header.Set("ETag", etag)
+	// Shared caches may store the answer but must revalidate, a new version changes the ETag
+	header.Set("Cache-Control", "public, no-cache")
+
+	// The publish time is only meaningful if nothing was bumped in between
+	current, modifiedAt := t.featureService.GetVersion(c)
+	if current != version {
+		modifiedAt = time.Time{}
+	}
+	if !modifiedAt.IsZero() {
+		header.Set("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+
+	reqHeader := ctx.GetRequest().GetHeader()
+	if notModified(reqHeader.Get("If-None-Match"), reqHeader.Get("If-Modified-Since"), etag, modifiedAt) {
+		ctx.GetResponse().SetStatus(http.StatusNotModified)
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, toUpdatesResponse(version, features))
+}
+
+// waitForUpdates holds the request until the service has something newer than lastVersion or wait elapses
+func (t *Controller) waitForUpdates(c context.Context, serviceName string, lastVersion int64, wait time.Duration) (int64, []*dto.Feature) {
+	wc, cancel := context.WithTimeout(c, wait)
+	defer cancel()
+
+	var (
+		version  int64
+		features []*dto.Feature
+	)
+	// A draining server answers at once, the next poll goes to another instance
+	_ = t.featureService.Watch(wc, serviceName, lastVersion, 0, nil, func(v int64, f []*dto.Feature) error {
+		version, features = v, f
+		return errUpdatesFound
+	})
+	if len(features) > 0 {
+		return version, features
+	}
+
+	// Timed out, answer with the current state
+	return t.featureService.GetNewFeature(c, serviceName, lastVersion)
+}
+
+var errUpdatesFound = errors.New("updates found")
+
+// parseWait accepts seconds ("30") or a duration ("1500ms")
+func parseWait(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, errors.New("negative wait")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("negative wait")
+	}
+	return d, nil
+}
+
+// notModified gives If-None-Match precedence over If-Modified-Since as RFC 9110 requires
+func notModified(ifNoneMatch string, ifModifiedSince string, etag string, modifiedAt time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince == "" || modifiedAt.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	return !modifiedAt.Truncate(time.Second).After(since)
+}
+
+// toUpdatesResponse is shared by polling and streaming transports
+func toUpdatesResponse(version int64, features []*dto.Feature) updatesResponse {
 	resp := updatesResponse{Version: version, Features: make([]featureItem, 0, len(features)), Deleted: make([]deletedItem, 0)}
 
 	for _, feature := range features {
@@ -92,10 +334,15 @@ func (t *Controller) getUpdates(c context.Context, ctx httpSrv.ICtx) {
 			continue
 		}
 
-		resp.Features = append(resp.Features, featureItem{All: int32(feature.Value), Name: feature.Name, Props: props})
+		item := featureItem{All: int32(feature.Value), Name: feature.Name, Props: props, Salt: feature.Salt, BucketBy: feature.BucketBy}
+		if feature.Layer != nil {
+			item.Layer = &layerItem{Name: feature.Layer.Name, Salt: feature.Layer.Salt, From: int32(feature.Layer.From), To: int32(feature.Layer.To)}
+		}
+
+		resp.Features = append(resp.Features, item)
 	}
 
-	respondJSON(ctx, http.StatusOK, resp)
+	return resp
 }
 
 func (t *Controller) postStats(c context.Context, ctx httpSrv.ICtx) {
@@ -122,3 +369,144 @@ func (t *Controller) postStats(c context.Context, ctx httpSrv.ICtx) {
 
 	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+func (t *Controller) postExposures(c context.Context, ctx httpSrv.ICtx) {
+	var req exposuresRequest
+	if err := parseJSON(ctx, &req); err != nil {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = []exposureEvent{req.exposureEvent}
+	}
+	if len(events) > maxEventsPerRequest {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "too many events"})
+		return
+	}
+
+	now := time.Now()
+	items := make([]*db.ExperimentExposure, 0, len(events))
+	for _, e := range events {
+		if e.FeatureName == "" || e.Variant == "" || e.UnitId == "" {
+			respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "feature_name, variant and unit_id required"})
+			return
+		}
+		if e.Timestamp.IsZero() {
+			e.Timestamp = now
+		}
+		if e.ServiceName == "" {
+			e.ServiceName = req.ServiceName
+		}
+		project, _ := ProjectRepository.SplitServiceName(e.ServiceName)
+		items = append(items, &db.ExperimentExposure{Project: project, FeatureName: e.FeatureName, Variant: e.Variant, UnitId: e.UnitId, ExposedAt: e.Timestamp})
+	}
+
+	if err := t.experiments.TrackExposures(c, items); err != nil {
+		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": "failed to store events"})
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (t *Controller) postMetrics(c context.Context, ctx httpSrv.ICtx) {
+	var req metricsRequest
+	if err := parseJSON(ctx, &req); err != nil {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = []metricEvent{req.metricEvent}
+	}
+	if len(events) > maxEventsPerRequest {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "too many events"})
+		return
+	}
+
+	now := time.Now()
+	items := make([]*db.ExperimentMetric, 0, len(events))
+	for _, e := range events {
+		if e.UnitId == "" || e.Metric == "" {
+			respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "unit_id and metric required"})
+			return
+		}
+		// A metric without a value is a plain conversion event
+		value := 1.0
+		if e.Value != nil {
+			value = *e.Value
+		}
+		if e.Timestamp.IsZero() {
+			e.Timestamp = now
+		}
+		items = append(items, &db.ExperimentMetric{UnitId: e.UnitId, Metric: e.Metric, Value: value, CreatedAt: e.Timestamp})
+	}
+
+	if err := t.experiments.TrackMetrics(c, items); err != nil {
+		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": "failed to store events"})
+		return
+	}
+
+	respondJSON(ctx, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// evaluate serves client-side mode, only features flagged client_side for the service are evaluated and nothing of their rules leaves the server
+func (t *Controller) evaluate(c context.Context, ctx httpSrv.ICtx) {
+	var req evaluateRequest
+	if err := parseJSON(ctx, &req); err != nil || req.ServiceName == "" {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
+	}
+
+	result, token, err := t.evaluation.Evaluate(c, req.ServiceName, req.Seed, req.Attributes)
+	if err != nil {
+		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": "evaluation failed"})
+		return
+	}
+
+	// Results are per user, shared caches must not store them
+	ctx.GetResponse().Header().Set("Cache-Control", "private, no-store")
+	respondJSON(ctx, http.StatusOK, evaluateResponse{Evaluation: result, Token: token})
+}
+
+func (t *Controller) evaluateKeys(_ context.Context, ctx httpSrv.ICtx) {
+	keyId, pub := t.signer.PublicKey()
+
+	respondJSON(ctx, http.StatusOK, map[string][]jwk{
+		"keys": {{Kty: "OKP", Crv: "Ed25519", Kid: keyId, Alg: "EdDSA", Use: "sig", X: base64.RawURLEncoding.EncodeToString(pub)}},
+	})
+}
+
+// getBootstrap lets SDKs refresh the snapshot they persist to disk, unchanged versions cost a 304
+func (t *Controller) getBootstrap(c context.Context, ctx httpSrv.ICtx) {
+	serviceName := ctx.GetRequest().GetQuery().Get("service_name")
+	if serviceName == "" {
+		respondJSON(ctx, http.StatusBadRequest, map[string]string{"error": "service_name required"})
+		return
+	}
+
+	header := ctx.GetResponse().Header()
+	header.Set("Cache-Control", "public, no-cache")
+
+	// Answer revalidations before signing anything
+	current, _ := t.featureService.GetVersion(c)
+	if etag := `"` + strconv.FormatInt(current, 10) + `"`; notModified(ctx.GetRequest().GetHeader().Get("If-None-Match"), "", etag, time.Time{}) {
+		header.Set("ETag", etag)
+		ctx.GetResponse().SetStatus(http.StatusNotModified)
+		return
+	}
+
+	file, version, err := t.bootstrap.Build(c, serviceName)
+	if err != nil {
+		respondJSON(ctx, http.StatusInternalServerError, map[string]string{"error": "failed to build bootstrap"})
+		return
+	}
+
+	header.Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+	header.Set("Content-Type", "application/jose")
+	ctx.GetResponse().SetStatus(http.StatusOK)
+	ctx.GetResponse().SetBody(file)
+}
@@ -3,16 +3,27 @@ package PublicHTTP
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/BootstrapService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/EvaluationService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/ExperimentService"
 	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
 	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
@@ -53,6 +64,7 @@ func TestController_getUpdates(t *testing.T) {
 						All:   100,
 						Name:  "test_feature",
 						Props: []propsItem{},
+						Salt:  "test_feature",
 					},
 				},
 				Deleted: []deletedItem{},
@@ -71,6 +83,12 @@ func TestController_getUpdates(t *testing.T) {
 								100,                 // value
 								int64(1),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 						},
 					}, nil
@@ -92,6 +110,7 @@ func TestController_getUpdates(t *testing.T) {
 					{
 						All:  100,
 						Name: "test_feature",
+						Salt: "test_feature",
 						Props: []propsItem{
 							{
 								All:  99,
@@ -127,6 +146,12 @@ func TestController_getUpdates(t *testing.T) {
 								100,                // value
 								int64(1),           // v
 								nil,                // deleted_at
+								nil,                // layer_name
+								nil,                // layer_salt
+								nil,                // layer_from
+								nil,                // layer_to
+								nil,                // feature_salt
+								nil,                // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -138,6 +163,12 @@ func TestController_getUpdates(t *testing.T) {
 								99,                  // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(), // feature_id
@@ -149,6 +180,12 @@ func TestController_getUpdates(t *testing.T) {
 								98,                 // value
 								int64(2),           // v
 								nil,                // deleted_at
+								nil,                // layer_name
+								nil,                // layer_salt
+								nil,                // layer_from
+								nil,                // layer_to
+								nil,                // feature_salt
+								nil,                // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -160,6 +197,12 @@ func TestController_getUpdates(t *testing.T) {
 								1,                   // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -171,6 +214,12 @@ func TestController_getUpdates(t *testing.T) {
 								2,                   // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 						},
 					}, nil
@@ -192,6 +241,7 @@ func TestController_getUpdates(t *testing.T) {
 					{
 						All:  -1,
 						Name: "test_feature",
+						Salt: "test_feature",
 						Props: []propsItem{
 							{
 								All:  99,
@@ -227,6 +277,12 @@ func TestController_getUpdates(t *testing.T) {
 								99,                  // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -238,6 +294,12 @@ func TestController_getUpdates(t *testing.T) {
 								1,                   // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -249,6 +311,12 @@ func TestController_getUpdates(t *testing.T) {
 								2,                   // value
 								int64(2),            // v
 								nil,                 // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 						},
 					}, nil
@@ -260,6 +328,107 @@ func TestController_getUpdates(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "layered feature data",
+			reqBody: `{"service_name": "test", "last_version": 0}`,
+			resCode: http.StatusOK,
+			resData: &updatesResponse{
+				Version: 1,
+				Features: []featureItem{
+					{
+						All:   100,
+						Name:  "test_feature",
+						Props: []propsItem{},
+						Salt:  "test_feature",
+						Layer: &layerItem{
+							Name: "checkout",
+							Salt: "checkout_salt",
+							From: 10,
+							To:   60,
+						},
+					},
+				},
+				Deleted: []deletedItem{},
+			},
+			mockPg: &postgres.Mock{
+				QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+					return &postgres.MockRows{
+						Values: [][]any{
+							{
+								uuid.New().String(), // feature_id
+								"test_feature",      // feature_name
+								nil,                 // key_id
+								nil,                 // key_name
+								nil,                 // param_id
+								nil,                 // param_name
+								100,                 // value
+								int64(1),            // v
+								nil,                 // deleted_at
+								"checkout",          // layer_name
+								"checkout_salt",     // layer_salt
+								10,                  // layer_from
+								60,                  // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
+							},
+						},
+					}, nil
+				},
+			},
+			mockRedis: &redis.Mock{
+				Data: map[string]string{
+					"feature_version": "1",
+				},
+			},
+		},
+		{
+			name:    "renamed feature keeps salt",
+			reqBody: `{"service_name": "test", "last_version": 0}`,
+			resCode: http.StatusOK,
+			resData: &updatesResponse{
+				Version: 1,
+				Features: []featureItem{
+					{
+						All:      100,
+						Name:     "test_feature_renamed",
+						Props:    []propsItem{},
+						Salt:     "test_feature",
+						BucketBy: "company_id",
+					},
+				},
+				Deleted: []deletedItem{},
+			},
+			mockPg: &postgres.Mock{
+				QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+					return &postgres.MockRows{
+						Values: [][]any{
+							{
+								uuid.New().String(),    // feature_id
+								"test_feature_renamed", // feature_name
+								nil,                    // key_id
+								nil,                    // key_name
+								nil,                    // param_id
+								nil,                    // param_name
+								100,                    // value
+								int64(1),               // v
+								nil,                    // deleted_at
+								nil,                    // layer_name
+								nil,                    // layer_salt
+								nil,                    // layer_from
+								nil,                    // layer_to
+								"test_feature",         // feature_salt
+								"company_id",           // bucket_by
+							},
+						},
+					}, nil
+				},
+			},
+			mockRedis: &redis.Mock{
+				Data: map[string]string{
+					"feature_version": "1",
+				},
+			},
+		},
 		{
 			name:    "deleted features data",
 			reqBody: `{"service_name": "test", "last_version": 1}`,
@@ -307,6 +476,12 @@ func TestController_getUpdates(t *testing.T) {
 								100,                 // value
 								int64(1),            // v
 								time.Now(),          // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								uuid.New().String(), // feature_id
@@ -318,6 +493,12 @@ func TestController_getUpdates(t *testing.T) {
 								99,                  // value
 								int64(2),            // v
 								time.Now(),          // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -329,6 +510,12 @@ func TestController_getUpdates(t *testing.T) {
 								1,                   // value
 								int64(2),            // v
 								time.Now(),          // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 							{
 								featureId.String(),  // feature_id
@@ -340,6 +527,12 @@ func TestController_getUpdates(t *testing.T) {
 								2,                   // value
 								int64(2),            // v
 								time.Now(),          // deleted_at
+								nil,                 // layer_name
+								nil,                 // layer_salt
+								nil,                 // layer_from
+								nil,                 // layer_to
+								nil,                 // feature_salt
+								nil,                 // bucket_by
 							},
 						},
 					}, nil
@@ -359,7 +552,9 @@ func TestController_getUpdates(t *testing.T) {
 				featureService: FeatureService.NewForTest(
 					ActivationValuesRepository.NewForTest(
 						tt.mockPg,
-						tt.mockRedis,
+						nil,
+						nil,
+						VersionRepository.NewForTest(tt.mockPg, tt.mockRedis, mock_log.New(true)),
 						mock_log.New(true),
 					),
 				),
@@ -382,3 +577,415 @@ func TestController_getUpdates(t *testing.T) {
 		})
 	}
 }
+
+func newPollController(cache map[string]string, rows [][]any) *Controller {
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			// Only rows newer than the requested version are returned, like the real query
+			out := make([][]any, 0)
+			for _, r := range rows {
+				if r[7].(int64) > args[2].(int64) {
+					out = append(out, r)
+				}
+			}
+			return &postgres.MockRows{Values: out}, nil
+		},
+	}
+
+	return &Controller{
+		featureService: FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, &redis.Mock{Data: cache}, mock_log.New(true)), mock_log.New(true))),
+		config:         Config{MaxWait: time.Second},
+	}
+}
+
+func pollRow() []any {
+	return []any{uuid.New().String(), "test_feature", nil, nil, nil, nil, 100, int64(2), nil, nil, nil, nil, nil, nil, nil}
+}
+
+func poll(c *Controller, target string, header map[string]string) *httpSrv.HttpCtx {
+	req := httptest.NewRequest("GET", target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(req)
+	c.pollUpdates(context.Background(), ctx)
+	return ctx
+}
+
+func TestController_pollUpdates(t *testing.T) {
+	modifiedAt := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	cache := map[string]string{"feature_version": "2", "feature_version_at": strconv.FormatInt(modifiedAt.Unix(), 10)}
+	lastModified := modifiedAt.Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   map[string]string
+		resCode  int
+		features int
+	}{
+		{name: "missing service", target: "/api/updates", resCode: http.StatusBadRequest},
+		{name: "invalid last_version", target: "/api/updates?service_name=test&last_version=x", resCode: http.StatusBadRequest},
+		{name: "invalid wait", target: "/api/updates?service_name=test&wait=-1", resCode: http.StatusBadRequest},
+		{name: "changes", target: "/api/updates?service_name=test&last_version=0", resCode: http.StatusOK, features: 1},
+		{name: "up to date", target: "/api/updates?service_name=test&last_version=2", resCode: http.StatusOK},
+		{name: "etag matches", target: "/api/updates?service_name=test&last_version=0", header: map[string]string{"If-None-Match": `"1", W/"2"`}, resCode: http.StatusNotModified},
+		{name: "etag differs", target: "/api/updates?service_name=test&last_version=0", header: map[string]string{"If-None-Match": `"1"`}, resCode: http.StatusOK, features: 1},
+		{name: "not modified since", target: "/api/updates?service_name=test&last_version=0", header: map[string]string{"If-Modified-Since": lastModified}, resCode: http.StatusNotModified},
+		{name: "modified since", target: "/api/updates?service_name=test&last_version=0", header: map[string]string{"If-Modified-Since": modifiedAt.Add(-time.Minute).Format(http.TimeFormat)}, resCode: http.StatusOK, features: 1},
+		{name: "etag wins over date", target: "/api/updates?service_name=test&last_version=0", header: map[string]string{"If-None-Match": `"1"`, "If-Modified-Since": lastModified}, resCode: http.StatusOK, features: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := poll(newPollController(cache, [][]any{pollRow()}), tt.target, tt.header)
+
+			if ctx.GetResponse().GetStatus() != tt.resCode {
+				t.Fatalf("expected code %d, got %d", tt.resCode, ctx.GetResponse().GetStatus())
+			}
+			if tt.resCode == http.StatusBadRequest {
+				return
+			}
+
+			if etag := ctx.GetResponse().Header().Get("ETag"); etag != `"2"` {
+				t.Errorf("unexpected etag %q", etag)
+			}
+			if lm := ctx.GetResponse().Header().Get("Last-Modified"); lm != lastModified {
+				t.Errorf("unexpected last-modified %q", lm)
+			}
+
+			if tt.resCode == http.StatusNotModified {
+				if len(ctx.GetResponse().GetBody()) != 0 {
+					t.Errorf("304 must not have a body")
+				}
+				return
+			}
+
+			var body updatesResponse
+			if err := json.Unmarshal(ctx.GetResponse().GetBody(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Version != 2 || len(body.Features) != tt.features {
+				t.Errorf("unexpected body %+v", body)
+			}
+		})
+	}
+}
+
+func TestController_pollUpdatesLongPoll(t *testing.T) {
+	cache := map[string]string{"feature_version": "2"}
+
+	t.Run("returns immediately when changes exist", func(t *testing.T) {
+		start := time.Now()
+		ctx := poll(newPollController(cache, [][]any{pollRow()}), "/api/updates?service_name=test&last_version=0&wait=30", nil)
+
+		if time.Since(start) > 500*time.Millisecond {
+			t.Errorf("request was held despite pending changes")
+		}
+		if ctx.GetResponse().GetStatus() != http.StatusOK {
+			t.Fatalf("unexpected code %d", ctx.GetResponse().GetStatus())
+		}
+		if ctx.GetResponse().Header().Get("Last-Modified") != "" {
+			t.Errorf("unknown publish time must not produce Last-Modified")
+		}
+	})
+
+	t.Run("holds until timeout without changes", func(t *testing.T) {
+		start := time.Now()
+		ctx := poll(newPollController(cache, [][]any{pollRow()}), "/api/updates?service_name=test&last_version=2&wait=300ms", map[string]string{"If-None-Match": `"2"`})
+
+		if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("unexpected hold time %s", elapsed)
+		}
+		if ctx.GetResponse().GetStatus() != http.StatusNotModified {
+			t.Fatalf("unexpected code %d", ctx.GetResponse().GetStatus())
+		}
+	})
+
+	t.Run("wait is capped", func(t *testing.T) {
+		c := newPollController(cache, nil)
+		c.config.MaxWait = 100 * time.Millisecond
+
+		start := time.Now()
+		poll(c, "/api/updates?service_name=test&last_version=2&wait=60", nil)
+		if time.Since(start) > time.Second {
+			t.Errorf("wait was not capped")
+		}
+	})
+}
+
+func TestController_evaluate(t *testing.T) {
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			return &postgres.MockRows{
+				Values: [][]any{
+					{uuid.New().String(), "checkout", nil, nil, nil, nil, 100, int64(1), nil, nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "1"}}
+	signer := SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+
+	c := Controller{
+		evaluation: EvaluationService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)), signer),
+		signer:     signer,
+	}
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("POST", "/api/evaluate", bytes.NewBufferString(`{"seed": "user-1"}`)))
+	c.evaluate(context.Background(), ctx)
+	if ctx.GetResponse().GetStatus() != http.StatusBadRequest {
+		t.Fatalf("expected 400 without service_name, got %d", ctx.GetResponse().GetStatus())
+	}
+
+	ctx = httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("POST", "/api/evaluate", bytes.NewBufferString(`{"service_name": "web", "seed": "user-1", "attributes": {"country": "US"}}`)))
+	c.evaluate(context.Background(), ctx)
+	if ctx.GetResponse().GetStatus() != http.StatusOK {
+		t.Fatalf("unexpected code %d", ctx.GetResponse().GetStatus())
+	}
+
+	var body struct {
+		Features map[string]bool `json:"features"`
+		Token    string          `json:"token"`
+		Props    any             `json:"props"`
+	}
+	if err := json.Unmarshal(ctx.GetResponse().GetBody(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.Features["checkout"] || body.Token == "" || body.Props != nil {
+		t.Fatalf("unexpected body %s", ctx.GetResponse().GetBody())
+	}
+
+	// The published key verifies the token
+	ctx = httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("GET", "/api/evaluate/keys", nil))
+	c.evaluateKeys(context.Background(), ctx)
+
+	var keys struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(ctx.GetResponse().GetBody(), &keys); err != nil || len(keys.Keys) != 1 {
+		t.Fatalf("unexpected keys %s", ctx.GetResponse().GetBody())
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(keys.Keys[0].X)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims dto.Evaluation
+	if err := SignService.VerifyToken(ed25519.PublicKey(pub), body.Token, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.ServiceName != "web" || claims.Subject != "user-1" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestController_getBootstrap(t *testing.T) {
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			return &postgres.MockRows{
+				Values: [][]any{
+					{uuid.New().String(), "checkout", nil, nil, nil, nil, 100, int64(4), nil, nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "4"}}
+	features := FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)))
+	signer := SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+
+	c := Controller{featureService: features, bootstrap: BootstrapService.NewForTest(features, signer)}
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("GET", "/api/bootstrap?service_name=web", nil))
+	c.getBootstrap(context.Background(), ctx)
+
+	if ctx.GetResponse().GetStatus() != http.StatusOK || ctx.GetResponse().Header().Get("ETag") != `"4"` {
+		t.Fatalf("unexpected response %d %v", ctx.GetResponse().GetStatus(), ctx.GetResponse().Header())
+	}
+
+	_, pub := signer.PublicKey()
+	b, snapshot, err := BootstrapService.Parse(pub, ctx.GetResponse().GetBody(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != 4 || len(snapshot.Features) != 1 {
+		t.Errorf("unexpected bootstrap %+v %v", b, snapshot)
+	}
+
+	req := httptest.NewRequest("GET", "/api/bootstrap?service_name=web", nil)
+	req.Header.Set("If-None-Match", `"4"`)
+	ctx = httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(req)
+	c.getBootstrap(context.Background(), ctx)
+	if ctx.GetResponse().GetStatus() != http.StatusNotModified || len(ctx.GetResponse().GetBody()) != 0 {
+		t.Errorf("expected 304, got %d", ctx.GetResponse().GetStatus())
+	}
+}
+
+func TestController_pollUpdatesProjectService(t *testing.T) {
+	tests := []struct {
+		serviceName string
+		project     string
+		service     string
+	}{
+		{serviceName: "billing", project: "default", service: "billing"},
+		{serviceName: "shop/billing", project: "shop", service: "billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serviceName, func(t *testing.T) {
+			var args []any
+			pg := &postgres.Mock{
+				QueryFunc: func(c context.Context, query string, a ...any) (postgres.SQLRows, error) {
+					args = a
+					return &postgres.MockRows{}, nil
+				},
+			}
+			c := &Controller{
+				featureService: FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, &redis.Mock{Data: map[string]string{"feature_version": "2"}}, mock_log.New(true)), mock_log.New(true))),
+				config:         Config{MaxWait: time.Second},
+			}
+
+			ctx := poll(c, "/api/updates?service_name="+tt.serviceName+"&last_version=0", nil)
+
+			if ctx.GetResponse().GetStatus() != http.StatusOK {
+				t.Fatalf("unexpected code %d", ctx.GetResponse().GetStatus())
+			}
+			if len(args) != 4 || args[0] != tt.project || args[1] != tt.service {
+				t.Errorf("service must resolve within project %q, query args %v", tt.project, args)
+			}
+		})
+	}
+}
+
+// bindingRows answers the delta query the way Postgres does after a binding change of "billing"
+func bindingRows(rows [][]any, query *string) *postgres.Mock {
+	return &postgres.Mock{
+		QueryFunc: func(c context.Context, q string, args ...any) (postgres.SQLRows, error) {
+			if strings.Contains(q, "FROM renames") {
+				return &postgres.MockRows{}, nil
+			}
+			*query = q
+			return &postgres.MockRows{Values: rows}, nil
+		},
+	}
+}
+
+func TestController_getUpdatesBindingChanges(t *testing.T) {
+	featureId, keyId := uuid.New().String(), uuid.New().String()
+	unboundAt := time.Date(2025, 10, 21, 11, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rows [][]any
+		want updatesResponse
+	}{
+		{
+			// The binding is newer than last_version, the whole live state arrives although no value changed
+			name: "bound",
+			rows: [][]any{
+				{featureId, "checkout", nil, nil, nil, nil, 40, int64(1), nil, nil, nil, nil, nil, nil, nil},
+				{featureId, "checkout", keyId, "country", nil, nil, 10, int64(1), nil, nil, nil, nil, nil, nil, nil},
+			},
+			want: updatesResponse{
+				Version: 5,
+				Features: []featureItem{{
+					All: 40, Name: "checkout", Salt: "checkout",
+					Props: []propsItem{{All: 10, Name: "country", Item: map[string]int32{}}},
+				}},
+				Deleted: []deletedItem{},
+			},
+		},
+		{
+			// Unbinding reads as the feature-level value deleted at the unbind time
+			name: "unbound",
+			rows: [][]any{
+				{featureId, "checkout", nil, nil, nil, nil, 40, int64(1), unboundAt, nil, nil, nil, nil, nil, nil},
+			},
+			want: updatesResponse{
+				Version:  5,
+				Features: []featureItem{},
+				Deleted:  []deletedItem{{Kind: 0, FeatureName: "checkout"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			pg := bindingRows(tt.rows, &query)
+			c := Controller{
+				featureService: FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, &redis.Mock{Data: map[string]string{"feature_version": "5"}}, mock_log.New(true)), mock_log.New(true))),
+			}
+
+			ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+			ctx.Fill(httptest.NewRequest("POST", "/api/updates", bytes.NewBufferString(`{"service_name": "billing", "last_version": 4}`)))
+			c.getUpdates(context.Background(), ctx)
+
+			var got updatesResponse
+			if err := json.Unmarshal(ctx.GetResponse().GetBody(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !strings.Contains(query, "sa.v > $3") || !strings.Contains(query, "sa.deleted_at IS NOT NULL") {
+				t.Errorf("delta query must follow binding versions:\n%s", query)
+			}
+		})
+	}
+}
+
+type recordingExperiments struct {
+	ExperimentService.Interface
+	exposures []*db.ExperimentExposure
+}
+
+func (r *recordingExperiments) TrackExposures(_ context.Context, events []*db.ExperimentExposure) error {
+	r.exposures = append(r.exposures, events...)
+	return nil
+}
+
+func TestController_postExposuresProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		projects []string
+	}{
+		{name: "bare event", body: `{"feature_name":"checkout","variant":"a","unit_id":"u1"}`, projects: []string{"default"}},
+		{name: "event service", body: `{"service_name":"shop/billing","feature_name":"checkout","variant":"a","unit_id":"u1"}`, projects: []string{"shop"}},
+		{
+			name:     "batch service",
+			body:     `{"service_name":"shop/billing","events":[{"feature_name":"checkout","variant":"a","unit_id":"u1"},{"service_name":"games/lobby","feature_name":"checkout","variant":"b","unit_id":"u2"}]}`,
+			projects: []string{"shop", "games"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			experiments := &recordingExperiments{}
+			c := &Controller{experiments: experiments}
+
+			ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+			ctx.Fill(httptest.NewRequest("POST", "/api/exposures", strings.NewReader(tt.body)))
+			c.postExposures(context.Background(), ctx)
+
+			if ctx.GetResponse().GetStatus() != http.StatusOK {
+				t.Fatalf("expected 200, got %d", ctx.GetResponse().GetStatus())
+			}
+			projects := make([]string, 0, len(experiments.exposures))
+			for _, e := range experiments.exposures {
+				projects = append(projects, e.Project)
+			}
+			if !reflect.DeepEqual(projects, tt.projects) {
+				t.Errorf("expected projects %v, got %v", tt.projects, projects)
+			}
+		})
+	}
+}
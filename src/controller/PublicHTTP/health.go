@@ -0,0 +1,62 @@
+package PublicHTTP
+
+import (
+	"context"
+	"net/http"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+const (
+	healthOk   = "ok"
+	healthFail = "fail"
+)
+
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Advisory   bool   `json:"advisory,omitempty"`
+}
+
+// healthz is the liveness probe
+func (t *Controller) healthz(c context.Context, ctx httpSrv.ICtx) {
+	t.respondHealth(ctx, t.health.Live(c))
+}
+
+// readyz is the readiness probe, it fails while a dependency is down or the server drains
+func (t *Controller) readyz(c context.Context, ctx httpSrv.ICtx) {
+	t.respondHealth(ctx, t.health.Ready(c))
+}
+
+// respondHealth answers 200 or 503, the checks are listed only when the health service allows details
+func (t *Controller) respondHealth(ctx httpSrv.ICtx, report dto.HealthReport) {
+	resp := healthResponse{Status: healthOk}
+	status := http.StatusOK
+	if !report.Ok {
+		resp.Status = healthFail
+		status = http.StatusServiceUnavailable
+	}
+
+	if t.health.Details() {
+		resp.Checks = make([]healthCheck, 0, len(report.Checks))
+		for _, check := range report.Checks {
+			item := healthCheck{Name: check.Name, Status: healthOk, Error: check.Error, DurationMs: check.Duration.Milliseconds(), Advisory: check.Advisory}
+			if check.Error != "" {
+				item.Status = healthFail
+			}
+			resp.Checks = append(resp.Checks, item)
+		}
+	}
+
+	// Probes must see the current state, never a cached one
+	ctx.GetResponse().Header().Set("Cache-Control", "no-store")
+	respondJSON(ctx, status, resp)
+}
@@ -0,0 +1,58 @@
+package PublicHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/HealthService"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type fakeHealth struct {
+	HealthService.Interface
+	details bool
+}
+
+func (t *fakeHealth) Live(context.Context) dto.HealthReport { return dto.HealthReport{Ok: true} }
+func (t *fakeHealth) Details() bool                         { return t.details }
+
+func (t *fakeHealth) Ready(context.Context) dto.HealthReport {
+	return dto.HealthReport{Checks: []dto.HealthCheck{
+		{Name: "postgres", Duration: 3 * time.Millisecond},
+		{Name: "migrations", Error: "schema is at migration 1, the binary expects 2"},
+	}}
+}
+
+func probe(c *Controller, handler func(*Controller, context.Context, httpSrv.ICtx)) (int, healthResponse) {
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("GET", "/", nil))
+	handler(c, context.Background(), ctx)
+
+	var resp healthResponse
+	_ = json.Unmarshal(ctx.GetResponse().GetBody(), &resp)
+	return ctx.GetResponse().GetStatus(), resp
+}
+
+func TestController_health(t *testing.T) {
+	c := &Controller{health: &fakeHealth{}}
+
+	if status, resp := probe(c, (*Controller).healthz); status != http.StatusOK || resp.Status != healthOk {
+		t.Errorf("expected live, got %d %+v", status, resp)
+	}
+
+	status, resp := probe(c, (*Controller).readyz)
+	if status != http.StatusServiceUnavailable || resp.Status != healthFail || len(resp.Checks) != 0 {
+		t.Errorf("expected a bare 503 without details, got %d %+v", status, resp)
+	}
+
+	c.health = &fakeHealth{details: true}
+	_, resp = probe(c, (*Controller).readyz)
+	if len(resp.Checks) != 2 || resp.Checks[0].Status != healthOk || resp.Checks[0].DurationMs != 3 || resp.Checks[1].Status != healthFail || resp.Checks[1].Error == "" {
+		t.Errorf("expected both checks in the details, got %+v", resp.Checks)
+	}
+}
@@ -1,9 +1,16 @@
 package PublicHTTP
 
+import (
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
 // Request/Response shapes mirror the gRPC proto messages but in JSON (single-shot polling)
 type updatesRequest struct {
 	ServiceName string `json:"service_name"`
 	LastVersion int64  `json:"last_version"`
+	clientInfo
 }
 
 type statsRequest struct {
@@ -18,10 +25,43 @@ type propsItem struct {
 	Item map[string]int32 `json:"item"`
 }
 
+type layerItem struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	From int32  `json:"from"`
+	To   int32  `json:"to"`
+}
+
 type featureItem struct {
-	All   int32       `json:"all"`
-	Name  string      `json:"name"`
-	Props []propsItem `json:"props"`
+	All      int32       `json:"all"`
+	Name     string      `json:"name"`
+	Props    []propsItem `json:"props"`
+	Layer    *layerItem  `json:"layer,omitempty"`
+	Salt     string      `json:"salt"`
+	BucketBy string      `json:"bucket_by,omitempty"`
+}
+
+// Client-side evaluation: the browser posts its context and gets only per-feature results
+type evaluateRequest struct {
+	ServiceName string            `json:"service_name"`
+	Seed        string            `json:"seed"`
+	Attributes  map[string]string `json:"attributes"`
+}
+
+type evaluateResponse struct {
+	*dto.Evaluation
+	// Token is a compact JWS of the result, safe to keep in local storage and verify with /api/evaluate/keys
+	Token string `json:"token"`
+}
+
+// jwk is an Ed25519 public key in RFC 8037 form
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	X   string `json:"x"`
 }
 
 // Deleted item kinds: 0=FEATURE, 1=KEY, 2=PARAM (matches proto enum order)
@@ -37,3 +77,61 @@ type updatesResponse struct {
 	Features []featureItem `json:"features"`
 	Deleted  []deletedItem `json:"deleted"`
 }
+
+// Experiment events: either a single event or a batch in "events", timestamps default to the receive time.
+// The service name picks the project of the feature, a batch level one applies to events without their own
+type exposureEvent struct {
+	ServiceName string    `json:"service_name"`
+	FeatureName string    `json:"feature_name"`
+	Variant     string    `json:"variant"`
+	UnitId      string    `json:"unit_id"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type exposuresRequest struct {
+	exposureEvent
+	Events []exposureEvent `json:"events"`
+}
+
+type metricEvent struct {
+	UnitId    string    `json:"unit_id"`
+	Metric    string    `json:"metric"`
+	Value     *float64  `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type metricsRequest struct {
+	metricEvent
+	Events []metricEvent `json:"events"`
+}
+
+// WebSocket protocol, every frame is one JSON message discriminated by "type"
+const (
+	wsTypeSubscribe = "subscribe"
+	wsTypeDelta     = "delta"
+	wsTypeAck       = "ack"
+	wsTypeStats     = "stats"
+	wsTypePing      = "ping"
+	wsTypePong      = "pong"
+	wsTypeError     = "error"
+	wsTypeReconnect = "reconnect"
+)
+
+type wsClientMessage struct {
+	Type        string   `json:"type"`
+	ServiceName string   `json:"service_name,omitempty"`
+	LastVersion int64    `json:"last_version,omitempty"`
+	Version     int64    `json:"version,omitempty"`
+	Features    []string `json:"features,omitempty"`
+	FeatureName string   `json:"feature_name,omitempty"`
+	// Instance fields of a subscribe message
+	clientInfo
+}
+
+type wsServerMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+	// AfterMs of a reconnect message is how long the client should wait before reconnecting
+	AfterMs int64 `json:"after_ms,omitempty"`
+	*updatesResponse
+}
@@ -0,0 +1,201 @@
+package PublicHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+)
+
+// Client reconnect delay advertised in the SSE stream
+const sseRetry = 3 * time.Second
+
+// Time allowed to write an event, a client that stops reading must not hold the stream
+const sseWriteWait = 10 * time.Second
+
+const shutdownTimeout = 5 * time.Second
+
+// streamServer is a plain net/http listener for long-lived connections
+type streamServer struct {
+	srv    *http.Server
+	addr   net.Addr
+	cancel context.CancelFunc
+}
+
+func (t *Controller) startStream(addr string) (*streamServer, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stream", t.sse)
+	mux.HandleFunc("GET /api/ws", t.ws)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every request context derives from base, cancelling it ends open streams on shutdown
+	base, cancel := context.WithCancel(context.Background())
+	s := &streamServer{
+		srv: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return base },
+		},
+		addr:   ln.Addr(),
+		cancel: cancel,
+	}
+
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.logger.Error(base, err)
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *streamServer) stop() error {
+	s.cancel()
+
+	c, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return s.srv.Shutdown(c)
+}
+
+// sse streams updatesResponse events, the event id is the version so Last-Event-ID resumes the stream
+func (t *Controller) sse(w http.ResponseWriter, r *http.Request) {
+	serviceName := r.URL.Query().Get("service_name")
+	if serviceName == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "service_name required"})
+		return
+	}
+
+	var lastVersion int64
+	if v := r.URL.Query().Get("last_version"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid last_version"})
+			return
+		}
+		lastVersion = parsed
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		v, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid Last-Event-ID"})
+			return
+		}
+		lastVersion = v
+	}
+
+	if !t.featureService.Ready() {
+		respondDraining(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	c, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	var mu sync.Mutex
+	write := func(b []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_ = rc.SetWriteDeadline(time.Now().Add(sseWriteWait))
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := write(fmt.Appendf(nil, "retry: %d\n\n", sseRetry.Milliseconds())); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t.keepAlive(c, cancel, write)
+	}()
+
+	delivered, disconnect := t.connect(c, clientFromQuery(r.URL.Query()), serviceName, db.ClientTransportSSE, lastVersion)
+	defer disconnect()
+
+	err := t.featureService.Watch(c, serviceName, lastVersion, 0, func(_ int64, after time.Duration) error {
+		// retry makes EventSource reconnect with the hint once the stream closes
+		return write(fmt.Appendf(nil, "retry: %d\nevent: reconnect\ndata: {\"after_ms\":%d}\n\n", after.Milliseconds(), after.Milliseconds()))
+	}, func(version int64, features []*dto.Feature) error {
+		data, err := json.Marshal(toUpdatesResponse(version, features))
+		if err != nil {
+			return err
+		}
+		if err := write(fmt.Appendf(nil, "id: %d\nevent: update\ndata: %s\n\n", version, data)); err != nil {
+			return err
+		}
+
+		delivered(version)
+		return nil
+	})
+	if err != nil && c.Err() == nil && !errors.Is(err, FeatureService.ErrDraining) {
+		t.logger.Error(c, err)
+	}
+
+	// The writer must not be touched after the handler returns
+	cancel()
+	wg.Wait()
+}
+
+// keepAlive sends SSE comments so proxies do not drop idle streams and dead clients are noticed
+func (t *Controller) keepAlive(c context.Context, cancel context.CancelFunc, write func([]byte) error) {
+	ticker := time.NewTicker(t.config.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			if err := write([]byte(": keepalive\n\n")); err != nil {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// respondDraining turns new streams away while the server drains, the load balancer sends the retry elsewhere
+func respondDraining(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "server is shutting down"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,159 @@
+package PublicHTTP
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+func newStreamController() *Controller {
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			return &postgres.MockRows{
+				Values: [][]any{
+					{uuid.New().String(), "test_feature", nil, nil, nil, nil, 100, int64(1), nil, nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "1"}}
+
+	return &Controller{
+		logger:         mock_log.New(true),
+		featureService: FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true))),
+		config:         Config{KeepAlive: 50 * time.Millisecond, HeartbeatTimeout: time.Second},
+	}
+}
+
+// readEvent collects lines up to the next blank line, the SSE event separator
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+
+	lines := make([]string, 0)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestSSE_StreamsUpdates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(newStreamController().sse))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/stream?service_name=test&last_version=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	if ev := readEvent(t, r); len(ev) != 1 || ev[0] != "retry: 3000" {
+		t.Fatalf("expected retry hint, got %v", ev)
+	}
+
+	ev := readEvent(t, r)
+	if len(ev) != 3 || ev[0] != "id: 1" || ev[1] != "event: update" || !strings.HasPrefix(ev[2], "data: ") {
+		t.Fatalf("unexpected event %v", ev)
+	}
+
+	var body updatesResponse
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(ev[2], "data: ")), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Version != 1 || len(body.Features) != 1 || body.Features[0].Name != "test_feature" || body.Features[0].All != 100 {
+		t.Errorf("unexpected payload %+v", body)
+	}
+}
+
+func TestSSE_ResumeWithLastEventID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(newStreamController().sse))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/api/stream?service_name=test&last_version=0", nil)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	readEvent(t, r) // retry hint
+
+	// Nothing is newer than the resumed version, only keepalive comments arrive
+	if ev := readEvent(t, r); len(ev) != 1 || ev[0] != ": keepalive" {
+		t.Fatalf("expected keepalive comment, got %v", ev)
+	}
+}
+
+func TestSSE_RequiresServiceName(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newStreamController().sse(rec, httptest.NewRequest("GET", "/api/stream", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestSSE_RejectsInvalidLastVersion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newStreamController().sse(rec, httptest.NewRequest("GET", "/api/stream?service_name=test&last_version=x", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestSSE_GracefulShutdown(t *testing.T) {
+	c := newStreamController()
+	stream, err := c.startStream("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get("http://" + stream.addr.String() + "/api/stream?service_name=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	readEvent(t, r)
+
+	done := make(chan error, 1)
+	go func() { done <- stream.stop() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stop: %v", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("open stream blocked shutdown")
+	}
+}
@@ -0,0 +1,267 @@
+package PublicHTTP
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+)
+
+const (
+	wsMaxMessageSize = 64 << 10
+	wsWriteWait      = 10 * time.Second
+	// Deltas sent but not yet acknowledged, beyond that the watcher waits and later changes coalesce into one delta
+	wsMaxInFlight = 4
+)
+
+var errSlowConsumer = errors.New("client does not acknowledge deltas")
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// SDKs connect from any origin, the endpoint only serves public configuration like /api/updates
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+type wsSession struct {
+	t    *Controller
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	subscribed bool
+	sent       int
+	acked      int
+	ackCh      chan struct{}
+	// acknowledged records the version of an ack in the client registry
+	acknowledged func(int64)
+	// closeCode ends the connection, service restart when the server drains
+	closeCode int
+}
+
+// ws carries subscriptions and stats over one connection, mirroring the gRPC Subscribe and Stats calls
+func (t *Controller) ws(w http.ResponseWriter, r *http.Request) {
+	if !t.featureService.Ready() {
+		respondDraining(w)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		return
+	}
+	defer conn.Close()
+
+	c, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s := &wsSession{t: t, conn: conn, ackCh: make(chan struct{}, 1), closeCode: websocket.CloseNormalClosure}
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(t.config.HeartbeatTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(t.config.HeartbeatTimeout))
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.heartbeat(c)
+	}()
+
+	// Unblock the reader when the server shuts down or the watcher fails
+	go func() {
+		<-c.Done()
+		_ = conn.SetReadDeadline(time.Now())
+	}()
+
+	s.readLoop(c, cancel, &wg)
+
+	cancel()
+	wg.Wait()
+
+	s.mu.Lock()
+	code := s.closeCode
+	s.mu.Unlock()
+
+	s.writeMu.Lock()
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(time.Second))
+	s.writeMu.Unlock()
+}
+
+func (s *wsSession) readLoop(c context.Context, cancel context.CancelFunc, wg *sync.WaitGroup) {
+	for {
+		var msg wsClientMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				_ = s.write(wsServerMessage{Type: wsTypeError, Error: "invalid message"})
+				continue
+			}
+			return
+		}
+
+		// Any client message proves liveness
+		_ = s.conn.SetReadDeadline(time.Now().Add(s.t.config.HeartbeatTimeout))
+
+		switch msg.Type {
+		case wsTypeSubscribe:
+			if !s.subscribe(c, cancel, wg, msg) {
+				_ = s.write(wsServerMessage{Type: wsTypeError, Error: "service_name required, one subscription per connection"})
+			}
+		case wsTypeAck:
+			s.ack(msg.Version)
+		case wsTypeStats:
+			s.stats(c, msg)
+		case wsTypePing:
+			_ = s.write(wsServerMessage{Type: wsTypePong})
+		default:
+			_ = s.write(wsServerMessage{Type: wsTypeError, Error: "unknown message type"})
+		}
+	}
+}
+
+func (s *wsSession) subscribe(c context.Context, cancel context.CancelFunc, wg *sync.WaitGroup, msg wsClientMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribed || msg.ServiceName == "" {
+		return false
+	}
+	s.subscribed = true
+
+	// Clients report the version they applied with every ack
+	acknowledged, disconnect := s.t.connect(c, msg.clientInfo, msg.ServiceName, db.ClientTransportWS, msg.LastVersion)
+	s.acknowledged = acknowledged
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer disconnect()
+
+		err := s.t.featureService.Watch(c, msg.ServiceName, msg.LastVersion, 0, func(_ int64, after time.Duration) error {
+			return s.write(wsServerMessage{Type: wsTypeReconnect, AfterMs: after.Milliseconds()})
+		}, func(version int64, features []*dto.Feature) error {
+			if err := s.waitForAcks(c); err != nil {
+				return err
+			}
+
+			resp := toUpdatesResponse(version, features)
+			if err := s.write(wsServerMessage{Type: wsTypeDelta, updatesResponse: &resp}); err != nil {
+				return err
+			}
+
+			s.mu.Lock()
+			s.sent++
+			s.mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, FeatureService.ErrDraining) {
+				s.mu.Lock()
+				s.closeCode = websocket.CloseServiceRestart
+				s.mu.Unlock()
+			}
+			cancel()
+		}
+	}()
+
+	return true
+}
+
+// waitForAcks holds the watcher while too many deltas are unacknowledged, a client silent for the heartbeat timeout is dropped
+func (s *wsSession) waitForAcks(c context.Context) error {
+	timeout := time.NewTimer(s.t.config.HeartbeatTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.mu.Lock()
+		inFlight := s.sent - s.acked
+		s.mu.Unlock()
+
+		if inFlight < wsMaxInFlight {
+			return nil
+		}
+
+		select {
+		case <-c.Done():
+			return c.Err()
+		case <-timeout.C:
+			return errSlowConsumer
+		case <-s.ackCh:
+		}
+	}
+}
+
+func (s *wsSession) ack(version int64) {
+	s.mu.Lock()
+	if s.acked < s.sent {
+		s.acked++
+	}
+	acknowledged := s.acknowledged
+	s.mu.Unlock()
+
+	if acknowledged != nil && version > 0 {
+		acknowledged(version)
+	}
+
+	select {
+	case s.ackCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *wsSession) stats(c context.Context, msg wsClientMessage) {
+	if msg.ServiceName == "" {
+		_ = s.write(wsServerMessage{Type: wsTypeError, Error: "service_name required"})
+		return
+	}
+
+	if msg.FeatureName != "" {
+		s.t.statsService.SetStat(c, msg.ServiceName, msg.FeatureName)
+	}
+	for _, feat := range msg.Features {
+		if feat == "" {
+			continue
+		}
+		s.t.statsService.SetStat(c, msg.ServiceName, feat)
+	}
+}
+
+// heartbeat pings the client, the pong handler pushes the read deadline forward
+func (s *wsSession) heartbeat(c context.Context) {
+	ticker := time.NewTicker(s.t.config.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			s.writeMu.Lock()
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			s.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) write(msg wsServerMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return s.conn.WriteJSON(msg)
+}
@@ -0,0 +1,190 @@
+package PublicHTTP
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type recordingStats struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (r *recordingStats) SetStat(_ context.Context, serviceName string, featureName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = append(r.seen, serviceName+"/"+featureName)
+}
+
+func (r *recordingStats) IsUsed(context.Context, string) bool        { return false }
+func (r *recordingStats) IsServiceUsed(context.Context, string) bool { return false }
+func (r *recordingStats) FeatureSeenAt(context.Context, string) (time.Time, bool) {
+	return time.Time{}, false
+}
+func (r *recordingStats) ServiceSeenAt(context.Context, string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (r *recordingStats) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.seen...)
+}
+
+func dialWS(t *testing.T, ctrl *Controller) (*websocket.Conn, func()) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(ctrl.ws))
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/ws", nil)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		srv.Close()
+	}
+}
+
+// wsReceived decodes server messages, the embedded pointer of wsServerMessage is encode-only
+type wsReceived struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+	updatesResponse
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) wsReceived {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg wsReceived
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	return msg
+}
+
+func TestWS_SubscribeSendsDelta(t *testing.T) {
+	conn, done := dialWS(t, newStreamController())
+	defer done()
+
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeSubscribe, ServiceName: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := readWS(t, conn)
+	if msg.Type != wsTypeDelta {
+		t.Fatalf("expected delta, got %+v", msg)
+	}
+	if msg.Version != 1 || len(msg.Features) != 1 || msg.Features[0].Name != "test_feature" {
+		t.Fatalf("unexpected delta %+v", msg.updatesResponse)
+	}
+
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeAck, Version: msg.Version}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second subscription on the same connection is rejected
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeSubscribe, ServiceName: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readWS(t, conn); msg.Type != wsTypeError {
+		t.Fatalf("expected error, got %+v", msg)
+	}
+}
+
+func TestWS_SubscribeRequiresServiceName(t *testing.T) {
+	conn, done := dialWS(t, newStreamController())
+	defer done()
+
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeSubscribe}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readWS(t, conn); msg.Type != wsTypeError {
+		t.Fatalf("expected error, got %+v", msg)
+	}
+}
+
+func TestWS_StatsAndPing(t *testing.T) {
+	ctrl := newStreamController()
+	stats := &recordingStats{}
+	ctrl.statsService = stats
+
+	conn, done := dialWS(t, ctrl)
+	defer done()
+
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeStats, ServiceName: "svc", Features: []string{"a", "", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypeStats, ServiceName: "svc", FeatureName: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	// Messages are handled in order, the pong means the stats above were recorded
+	if err := conn.WriteJSON(wsClientMessage{Type: wsTypePing}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readWS(t, conn); msg.Type != wsTypePong {
+		t.Fatalf("expected pong, got %+v", msg)
+	}
+
+	got := stats.snapshot()
+	if strings.Join(got, ",") != "svc/a,svc/b,svc/c" {
+		t.Fatalf("unexpected stats %v", got)
+	}
+}
+
+func TestWS_UnknownMessage(t *testing.T) {
+	conn, done := dialWS(t, newStreamController())
+	defer done()
+
+	if err := conn.WriteJSON(map[string]string{"type": "bogus"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readWS(t, conn); msg.Type != wsTypeError {
+		t.Fatalf("expected error, got %+v", msg)
+	}
+}
+
+func TestWS_HeartbeatTimeout(t *testing.T) {
+	ctrl := newStreamController()
+	ctrl.config.HeartbeatTimeout = 100 * time.Millisecond
+
+	conn, done := dialWS(t, ctrl)
+	defer done()
+
+	// Answering pings needs a reader, without one the client looks dead and the server hangs up
+	time.Sleep(300 * time.Millisecond)
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) && !strings.Contains(err.Error(), "EOF") {
+				t.Fatalf("expected close, got %v", err)
+			}
+			return
+		}
+	}
+}
+
+func TestWS_SlowConsumerIsDropped(t *testing.T) {
+	ctrl := newStreamController()
+	ctrl.config.HeartbeatTimeout = 100 * time.Millisecond
+
+	s := &wsSession{t: ctrl, ackCh: make(chan struct{}, 1), sent: wsMaxInFlight}
+	if err := s.waitForAcks(context.Background()); err != errSlowConsumer {
+		t.Fatalf("expected slow consumer error, got %v", err)
+	}
+
+	s.ack(0)
+	if err := s.waitForAcks(context.Background()); err != nil {
+		t.Fatalf("expected room after ack, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+)
+
+type featureModule interface {
+	interfaces.IModules
+	FeatureService.Interface
+}
+
+// Features counts open update streams and delivered change sets, and reports how far the streams of each
+// service lag
+type Features struct {
+	featureModule
+
+	next    atomic.Uint64
+	mu      sync.Mutex
+	streams map[uint64]*stream
+}
+
+// stream is an open Watch, version is the newest version it has been brought up to
+type stream struct {
+	service string
+	version atomic.Int64
+}
+
+type streamCtxKey struct{}
+
+func NewFeatures(inner featureModule) *Features {
+	return &Features{
+		featureModule: inner,
+		streams:       make(map[uint64]*stream),
+	}
+}
+
+func (t *Features) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if err := t.featureModule.Init(app, cfg); err != nil {
+		return err
+	}
+
+	return Registry.Register(t)
+}
+
+func (t *Features) Watch(c context.Context, serviceName string, lastVersion int64, heartbeat time.Duration, reconnect func(version int64, after time.Duration) error, send func(version int64, features []*dto.Feature) error) error {
+	s := &stream{service: serviceName}
+	s.version.Store(lastVersion)
+
+	id := t.next.Add(1)
+	t.mu.Lock()
+	t.streams[id] = s
+	t.mu.Unlock()
+	subscribeStreams.WithLabelValues(serviceName).Inc()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.streams, id)
+		t.mu.Unlock()
+		subscribeStreams.WithLabelValues(serviceName).Dec()
+	}()
+
+	// Values sees the stream through the context and moves it forward on every lookup, not only on sends
+	return t.featureModule.Watch(context.WithValue(c, streamCtxKey{}, s), serviceName, lastVersion, heartbeat, reconnect, func(version int64, features []*dto.Feature) error {
+		if err := send(version, features); err != nil {
+			return err
+		}
+
+		s.advance(version)
+		if len(features) == 0 {
+			// Heartbeat
+			return nil
+		}
+		deltasSent.WithLabelValues(serviceName).Inc()
+		deltaSize.Observe(float64(len(features)))
+
+		return nil
+	})
+}
+
+func (t *Features) Describe(ch chan<- *prometheus.Desc) {
+	ch <- streamLagDesc
+}
+
+func (t *Features) Collect(ch chan<- prometheus.Metric) {
+	version, _ := t.featureModule.GetVersion(context.Background())
+	if version < 0 {
+		return
+	}
+
+	// Streams come and go with every client, a series per stream would grow without bound
+	lags := make(map[string]int64)
+	t.mu.Lock()
+	for _, s := range t.streams {
+		lag := version - s.version.Load()
+		if lag < 0 {
+			lag = 0
+		}
+		if current, ok := lags[s.service]; !ok || lag > current {
+			lags[s.service] = lag
+		}
+	}
+	t.mu.Unlock()
+
+	for service, lag := range lags {
+		ch <- prometheus.MustNewConstMetric(streamLagDesc, prometheus.GaugeValue, float64(lag), service)
+	}
+}
+
+// advance moves the stream to version, it never goes backwards
+func (s *stream) advance(version int64) {
+	for current := s.version.Load(); version > current; current = s.version.Load() {
+		if s.version.CompareAndSwap(current, version) {
+			return
+		}
+	}
+}
+
+// streamOf returns the stream a request runs for, nil outside of Watch
+func streamOf(c context.Context) *stream {
+	s, _ := c.Value(streamCtxKey{}).(*stream)
+	return s
+}
@@ -0,0 +1,95 @@
+// Package metrics instruments the server with Prometheus collectors. The decorators in this package wrap
+// modules and packages under their own names, so callers keep resolving the same interfaces
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/common/expfmt"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// Registry holds every FeatureChaos collector together with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var (
+	subscribeStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "featurechaos_subscribe_streams",
+		Help: "Open update streams (gRPC Subscribe, SSE, WebSocket and long-polls) per service.",
+	}, []string{"service"})
+
+	deltasSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "featurechaos_deltas_sent_total",
+		Help: "Change sets delivered to update streams per service.",
+	}, []string{"service"})
+
+	deltaSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "featurechaos_delta_features",
+		Help:    "Features in a delivered change set.",
+		Buckets: []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	getNewLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "featurechaos_get_new_by_service_name_seconds",
+		Help:    "Latency of GetNewByServiceName, the per-service delta lookup.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	statsEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "featurechaos_stats_events_total",
+		Help: "Usage events ingested from clients per service.",
+	}, []string{"service"})
+
+	adminMutations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "featurechaos_admin_mutations_total",
+		Help: "Admin API mutations by method and route.",
+	}, []string{"method", "route"})
+
+	streamLagDesc = prometheus.NewDesc(
+		"featurechaos_stream_version_lag",
+		"Global version minus the version the furthest behind open update stream of a service has been brought up to.",
+		[]string{"service"}, nil,
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		subscribeStreams,
+		deltasSent,
+		deltaSize,
+		getNewLatency,
+		statsEvents,
+		adminMutations,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler(c context.Context, ctx httpSrv.ICtx) {
+	families, err := Registry.Gather()
+	if err != nil {
+		ctx.GetResponse().SetStatus(http.StatusInternalServerError)
+		ctx.GetResponse().SetBody([]byte(err.Error()))
+		return
+	}
+
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	var body bytes.Buffer
+	encoder := expfmt.NewEncoder(&body, format)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			ctx.GetResponse().SetStatus(http.StatusInternalServerError)
+			ctx.GetResponse().SetBody([]byte(err.Error()))
+			return
+		}
+	}
+
+	ctx.GetResponse().Header().Set("Content-Type", string(format))
+	ctx.GetResponse().SetStatus(http.StatusOK)
+	ctx.GetResponse().SetBody(body.Bytes())
+}
@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto2 "github.com/prometheus/client_model/go"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+type fakeFeatures struct {
+	service.Mock
+	FeatureService.Interface
+	values *Values
+	during func()
+}
+
+func (t *fakeFeatures) GetVersion(context.Context) (int64, time.Time) {
+	return 7, time.Time{}
+}
+
+// Watch looks the delta up through values and delivers it, like FeatureService does
+func (t *fakeFeatures) Watch(c context.Context, serviceName string, lastVersion int64, _ time.Duration, _ func(int64, time.Duration) error, send func(version int64, features []*dto.Feature) error) error {
+	version, features, _ := t.values.GetNewByServiceName(c, serviceName, lastVersion)
+	if err := send(version, features); err != nil {
+		return err
+	}
+
+	t.during()
+	return nil
+}
+
+type fakeValues struct {
+	repository.Mock
+	ActivationValuesRepository.Interface
+}
+
+func (t *fakeValues) GetNewByServiceName(context.Context, string, int64) (int64, []*dto.Feature, error) {
+	return 5, []*dto.Feature{{Name: "a"}, {Name: "b"}}, nil
+}
+
+type fakeServer struct {
+	httpSrv.IHttp
+	routes map[string]httpSrv.RouteFunc
+}
+
+func (t *fakeServer) PushRoute(method string, path string, handler httpSrv.RouteFunc, _ []string) {
+	t.routes[method+" "+path] = handler
+}
+
+// value reads the metric of a registered family with the given label values
+func value(t *testing.T, name string, labels ...string) float64 {
+	t.Helper()
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if !hasLabels(m, labels) {
+				continue
+			}
+			switch {
+			case m.GetGauge() != nil:
+				return m.GetGauge().GetValue()
+			case m.GetCounter() != nil:
+				return m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
+
+func hasLabels(m *dto2.Metric, labels []string) bool {
+	values := make([]string, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		values = append(values, l.GetValue())
+	}
+	return strings.Join(values, ",") == strings.Join(labels, ",")
+}
+
+func TestFeatures_Watch(t *testing.T) {
+	inner := &fakeFeatures{values: NewValues(&fakeValues{})}
+	features := NewFeatures(inner)
+
+	sentBefore := value(t, "featurechaos_deltas_sent_total", "billing")
+	lookupsBefore := value(t, "featurechaos_get_new_by_service_name_seconds")
+
+	inner.during = func() {
+		if got := value(t, "featurechaos_subscribe_streams", "billing"); got != 1 {
+			t.Errorf("expected one open stream, got %v", got)
+		}
+
+		ch := make(chan prometheus.Metric, 1)
+		features.Collect(ch)
+		var m dto2.Metric
+		if err := (<-ch).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m.GetGauge().GetValue() != 2 {
+			t.Errorf("stream at version 5 must lag global version 7 by 2, got %v", m.GetGauge().GetValue())
+		}
+	}
+
+	err := features.Watch(context.Background(), "billing", 1, 0, nil, func(int64, []*dto.Feature) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := value(t, "featurechaos_subscribe_streams", "billing"); got != 0 {
+		t.Errorf("closed stream must be released, got %v", got)
+	}
+	if got := value(t, "featurechaos_deltas_sent_total", "billing") - sentBefore; got != 1 {
+		t.Errorf("expected one delta sent, got %v", got)
+	}
+	if got := value(t, "featurechaos_get_new_by_service_name_seconds") - lookupsBefore; got != 1 {
+		t.Errorf("expected one timed lookup, got %v", got)
+	}
+}
+
+func TestFeatures_CollectPerService(t *testing.T) {
+	features := NewFeatures(&fakeFeatures{})
+	for i, v := range []int64{6, 3, 7} {
+		s := &stream{service: "billing"}
+		s.version.Store(v)
+		features.streams[uint64(i)] = s
+	}
+
+	ch := make(chan prometheus.Metric, 3)
+	features.Collect(ch)
+	close(ch)
+
+	if len(ch) != 1 {
+		t.Fatalf("expected one series per service, got %d", len(ch))
+	}
+	var m dto2.Metric
+	if err := (<-ch).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetGauge().GetValue() != 4 || len(m.GetLabel()) != 1 || m.GetLabel()[0].GetValue() != "billing" {
+		t.Errorf("expected the furthest behind stream lagging by 4, got %v", m.String())
+	}
+}
+
+func TestPublicServer_PushRoute(t *testing.T) {
+	inner := &fakeServer{routes: map[string]httpSrv.RouteFunc{}}
+	server := NewPublicServer(inner)
+	server.PushRoute("POST", "/api/updates", func(context.Context, httpSrv.ICtx) {}, nil)
+
+	inner.routes["POST /api/updates"](context.Background(), nil)
+	if got := value(t, "featurechaos_admin_mutations_total", "POST", "/api/updates"); got != 0 {
+		t.Errorf("public routes must not be counted as admin mutations, got %v", got)
+	}
+}
+
+func TestServer_PushRoute(t *testing.T) {
+	inner := &fakeServer{routes: map[string]httpSrv.RouteFunc{}}
+	server := NewServer(inner)
+
+	called := 0
+	handler := func(context.Context, httpSrv.ICtx) { called++ }
+	server.PushRoute("GET", "/api/projects/{project}/features", handler, nil)
+	server.PushRoute("POST", "/api/projects/{project}/features", handler, nil)
+
+	before := value(t, "featurechaos_admin_mutations_total", "POST", "/api/projects/{project}/features")
+	inner.routes["GET /api/projects/{project}/features"](context.Background(), nil)
+	inner.routes["POST /api/projects/{project}/features"](context.Background(), nil)
+
+	if called != 2 {
+		t.Fatalf("handlers must still run, called %d times", called)
+	}
+	if got := value(t, "featurechaos_admin_mutations_total", "POST", "/api/projects/{project}/features") - before; got != 1 {
+		t.Errorf("expected one counted mutation, got %v", got)
+	}
+	if got := value(t, "featurechaos_admin_mutations_total", "GET", "/api/projects/{project}/features"); got != 0 {
+		t.Errorf("reads must not be counted, got %v", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	adminMutations.WithLabelValues("DELETE", "/api/projects/{project}").Inc()
+
+	ctx := httpSrv.HttpCtxPool.Get().(*httpSrv.HttpCtx)
+	ctx.Fill(httptest.NewRequest("GET", "/metrics", nil))
+	Handler(context.Background(), ctx)
+
+	if ctx.GetResponse().GetStatus() != 200 {
+		t.Fatalf("unexpected status %d", ctx.GetResponse().GetStatus())
+	}
+	if body := string(ctx.GetResponse().GetBody()); !strings.Contains(body, `featurechaos_admin_mutations_total{method="DELETE",route="/api/projects/{project}"}`) {
+		t.Errorf("metric missing from exposition:\n%s", body)
+	}
+}
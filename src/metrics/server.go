@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+
+	httpSrv "gitlab.com/devpro_studio/Paranoia/pkg/server/http"
+)
+
+// Server wraps the admin HTTP server: it serves /metrics and counts every mutating route it registers
+type Server struct {
+	httpSrv.IHttp
+	admin bool
+}
+
+func NewServer(inner httpSrv.IHttp) *Server {
+	return &Server{IHttp: inner, admin: true}
+}
+
+// NewPublicServer serves /metrics on the public HTTP server where there is no admin one, as in relay mode.
+// Its routes are not admin mutations and are not counted
+func NewPublicServer(inner httpSrv.IHttp) *Server {
+	return &Server{IHttp: inner}
+}
+
+func (t *Server) Init(cfg map[string]interface{}) error {
+	if err := t.IHttp.Init(cfg); err != nil {
+		return err
+	}
+
+	t.IHttp.PushRoute("GET", "/metrics", Handler, nil)
+
+	return nil
+}
+
+func (t *Server) PushRoute(method string, path string, handler httpSrv.RouteFunc, middlewares []string) {
+	if t.admin && method != "GET" {
+		mutations := adminMutations.WithLabelValues(method, path)
+		next := handler
+		handler = func(c context.Context, ctx httpSrv.ICtx) {
+			mutations.Inc()
+			next(c, ctx)
+		}
+	}
+
+	t.IHttp.PushRoute(method, path, handler, middlewares)
+}
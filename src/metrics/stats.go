@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"context"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/StatsService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+)
+
+type statsModule interface {
+	interfaces.IModules
+	StatsService.Interface
+}
+
+// Stats counts usage events ingested from clients
+type Stats struct {
+	statsModule
+}
+
+func NewStats(inner statsModule) *Stats {
+	return &Stats{statsModule: inner}
+}
+
+func (t *Stats) SetStat(c context.Context, serviceName string, featureName string) {
+	statsEvents.WithLabelValues(serviceName).Inc()
+	t.statsModule.SetStat(c, serviceName, featureName)
+}
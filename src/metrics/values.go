@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+)
+
+type valuesModule interface {
+	interfaces.IModules
+	ActivationValuesRepository.Interface
+}
+
+// Values times the per-service delta lookup and moves the calling stream to the version it returned
+type Values struct {
+	valuesModule
+}
+
+func NewValues(inner valuesModule) *Values {
+	return &Values{valuesModule: inner}
+}
+
+func (t *Values) GetNewByServiceName(c context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature, error) {
+	start := time.Now()
+	version, features, err := t.valuesModule.GetNewByServiceName(c, serviceName, lastVersion)
+	getNewLatency.Observe(time.Since(start).Seconds())
+
+	if s := streamOf(c); s != nil && err == nil {
+		s.advance(version)
+	}
+
+	return version, features, err
+}
@@ -9,6 +9,8 @@ import (
 type ActivationValues struct {
 	FeatureID   uuid.UUID
 	FeatureName string
+	FeatureSalt *string
+	BucketBy    *string
 	KeyId       *uuid.UUID
 	KeyName     *string
 	ParamId     *uuid.UUID
@@ -16,12 +18,18 @@ type ActivationValues struct {
 	Value       int
 	V           int64
 	DeletedAt   *time.Time
+	LayerName   *string
+	LayerSalt   *string
+	LayerFrom   *int
+	LayerTo     *int
 }
 
 type ActivationValuesFull struct {
 	FeatureId          uuid.UUID
 	FeatureName        string
 	FeatureDescription string
+	FeatureSalt        string
+	FeatureBucketBy    string
 	FeatureCreatedAt   time.Time
 	FeatureUpdatedAt   time.Time
 	KeyId              *uuid.UUID
@@ -29,4 +37,5 @@ type ActivationValuesFull struct {
 	ParamId            *uuid.UUID
 	ParamName          *string
 	Value              int
+	V                  int64
 }
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChangeOperationUpdateFeature = "update_feature"
+	ChangeOperationDeleteFeature = "delete_feature"
+	ChangeOperationUpdateKey     = "update_key"
+	ChangeOperationDeleteKey     = "delete_key"
+	ChangeOperationUpdateParam   = "update_param"
+	ChangeOperationDeleteParam   = "delete_param"
+
+	ChangeOperationSetFeatureValue = "set_feature_value"
+	ChangeOperationSetKeyValue     = "set_key_value"
+	ChangeOperationSetParamValue   = "set_param_value"
+
+	// Structural changes target the feature, except create_param which targets the key
+	ChangeOperationCreateKey     = "create_key"
+	ChangeOperationCreateParam   = "create_param"
+	ChangeOperationAddService    = "add_service"
+	ChangeOperationRemoveService = "remove_service"
+	ChangeOperationSetClientSide = "set_client_side"
+	ChangeOperationSetLayer      = "set_layer"
+	ChangeOperationRemoveLayer   = "remove_layer"
+
+	// Service and layer changes target the service or layer, they are checked against the protected feature they reach
+	ChangeOperationDeleteService = "delete_service"
+	ChangeOperationUpdateLayer   = "update_layer"
+	ChangeOperationDeleteLayer   = "delete_layer"
+)
+
+const (
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+	ChangeStatusApplied  = "applied"
+	ChangeStatusConflict = "conflict"
+)
+
+type ChangeRequest struct {
+	Id          uuid.UUID
+	Operation   string
+	TargetId    uuid.UUID
+	FeatureId   uuid.UUID
+	BaseVersion int64
+	Payload     ChangeRequestPayload
+	Status      string
+	ProposedBy  string
+	ReviewedBy  *string
+	Comment     string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ChangeRequestPayload is the stored diff, fields mirror the arguments of the repository update methods
+type ChangeRequestPayload struct {
+	KeyId       uuid.UUID `json:"key_id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Salt        *string   `json:"salt,omitempty"`
+	BucketBy    *string   `json:"bucket_by,omitempty"`
+	Value       int       `json:"value"`
+	ServiceId   uuid.UUID `json:"service_id,omitempty"`
+	ClientSide  *bool     `json:"client_side,omitempty"`
+	LayerId     uuid.UUID `json:"layer_id,omitempty"`
+	From        int       `json:"from,omitempty"`
+	To          int       `json:"to,omitempty"`
+}
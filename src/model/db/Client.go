@@ -0,0 +1,27 @@
+package db
+
+import "time"
+
+const (
+	ClientTransportGrpc = "grpc"
+	ClientTransportSSE  = "sse"
+	ClientTransportWS   = "ws"
+	ClientTransportPoll = "poll"
+)
+
+// Client is one SDK instance as the registry knows it, ServiceName is qualified like usage stats
+type Client struct {
+	ServiceName string
+	InstanceId  string
+	Hostname    string
+	SdkName     string
+	SdkVersion  string
+	Labels      map[string]string
+	Transport   string
+	// Version is the configuration version the instance holds, VersionAt is when it reached it
+	Version     int64
+	VersionAt   time.Time
+	Streaming   bool
+	ConnectedAt *time.Time
+	SeenAt      time.Time
+}
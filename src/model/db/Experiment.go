@@ -0,0 +1,28 @@
+package db
+
+import "time"
+
+// ExperimentExposure names the feature as the client sees it, Project and FeatureName resolve to the feature on insert
+type ExperimentExposure struct {
+	Project     string
+	FeatureName string
+	Variant     string
+	UnitId      string
+	ExposedAt   time.Time
+}
+
+type ExperimentMetric struct {
+	UnitId    string
+	Metric    string
+	Value     float64
+	CreatedAt time.Time
+}
+
+// ExperimentVariantStats holds per-variant sums over exposed units, metric values are summed per unit first
+type ExperimentVariantStats struct {
+	Variant     string
+	Units       int64
+	Conversions int64
+	Sum         float64
+	SumSquares  float64
+}
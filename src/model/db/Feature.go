@@ -6,6 +6,8 @@ type Feature struct {
 	Id          uuid.UUID
 	Name        string
 	Description string
+	Salt        string
+	BucketBy    string
 	Value       int
 	Version     int64
 }
@@ -0,0 +1,19 @@
+package db
+
+import "github.com/google/uuid"
+
+type Layer struct {
+	Id          uuid.UUID
+	Name        string
+	Salt        string
+	Description string
+}
+
+type LayerAllocation struct {
+	Id          uuid.UUID
+	LayerId     uuid.UUID
+	FeatureId   uuid.UUID
+	FeatureName string
+	From        int
+	To          int
+}
@@ -0,0 +1,13 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Project struct {
+	Id        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
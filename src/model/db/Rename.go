@@ -0,0 +1,22 @@
+package db
+
+import "github.com/google/uuid"
+
+// Rename kinds match the kinds of deleted items in the update stream
+const (
+	RenameFeature = 0
+	RenameKey     = 1
+	RenameParam   = 2
+)
+
+// Rename is the old name of a renamed feature, key or param; KeyId is set for keys and params
+type Rename struct {
+	Kind      int
+	FeatureId uuid.UUID
+	KeyId     *uuid.UUID
+	// ParamId is set for params, only the webhook event uses it
+	ParamId     *uuid.UUID
+	FeatureName string
+	KeyName     string
+	ParamName   string
+}
@@ -7,4 +7,8 @@ type ServiceAccess struct {
 	FeatureId uuid.UUID
 	ServiceId uuid.UUID
 	Name      string
+	// ProjectName owns the service, clients address it as "<project>/<name>"
+	ProjectName string
+	// ClientSide exposes the feature to client-side evaluation for this service
+	ClientSide bool
 }
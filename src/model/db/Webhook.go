@@ -0,0 +1,73 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventUpdated = "updated"
+	WebhookEventDeleted = "deleted"
+	WebhookEventRenamed = "renamed"
+	WebhookEventBound   = "bound"
+	WebhookEventUnbound = "unbound"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	Id           uuid.UUID
+	ProjectId    uuid.UUID
+	Url          string
+	Secret       string
+	FeatureNames []string
+	ServiceNames []string
+	EventTypes   []string
+	IsActive     bool
+	CreatedAt    time.Time
+}
+
+// WebhookEvent is one committed change taken from the outbox
+type WebhookEvent struct {
+	Id          int64
+	EventType   string
+	FeatureName string
+	KeyName     *string
+	ParamName   *string
+	Before      *int
+	After       *int
+	// ServiceName is the service of a bound or unbound event
+	ServiceName *string
+	// PreviousName is the old name of the renamed feature, key or param
+	PreviousName *string
+	Version      int64
+	CreatedAt    time.Time
+}
+
+// WebhookDelivery is a claimed attempt to push an event to one subscription
+type WebhookDelivery struct {
+	Id      int64
+	Attempt int
+	Url     string
+	Secret  string
+	Event   WebhookEvent
+}
+
+type WebhookDeliveryLog struct {
+	Id          int64
+	DeliveryId  int64
+	EventId     int64
+	EventType   string
+	FeatureName string
+	Status      string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	DurationMs  int
+	CreatedAt   time.Time
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+
+// Client is a registered instance with its standing against the configuration of its service
+type Client struct {
+	db.Client
+	// Lag is how many global versions the instance is behind, 0 while it holds every change of its service
+	Lag int64
+	// Stuck marks an instance that misses changes and has not moved for a while
+	Stuck bool
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/google/uuid"
+
+// EntityState is the current state of a feature, key or param as the admin UI edits it
+type EntityState struct {
+	Id          uuid.UUID
+	FeatureId   uuid.UUID
+	KeyId       *uuid.UUID
+	ParamId     *uuid.UUID
+	Name        string
+	Description string
+	Value       int
+	Revision    int64
+}
@@ -0,0 +1,11 @@
+package dto
+
+// Evaluation is the signed client-side payload, the json names are part of the token format
+type Evaluation struct {
+	ServiceName string          `json:"service_name"`
+	Subject     string          `json:"sub"`
+	Version     int64           `json:"version"`
+	IssuedAt    int64           `json:"iat"`
+	ExpiresAt   int64           `json:"exp"`
+	Features    map[string]bool `json:"features"`
+}
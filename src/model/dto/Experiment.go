@@ -0,0 +1,27 @@
+package dto
+
+type ExperimentResult struct {
+	FeatureName string
+	Metric      string
+	Control     string
+	Variants    []ExperimentVariant
+}
+
+type ExperimentVariant struct {
+	Variant        string
+	Units          int64
+	Conversions    int64
+	ConversionRate float64
+	Mean           float64
+	StdDev         float64
+	// Comparison against the control variant, nil for the control itself
+	ConversionTest *SignificanceTest
+	MeanTest       *SignificanceTest
+}
+
+type SignificanceTest struct {
+	Statistic        float64
+	DegreesOfFreedom float64
+	PValue           float64
+	Significant      bool
+}
@@ -10,10 +10,14 @@ type Feature struct {
 	Id          uuid.UUID
 	Name        string
 	Description string
+	Salt        string
+	BucketBy    string
 	Version     int64
 	Value       int
+	Revision    int64
 	IsDeleted   bool
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Keys        []FeatureKey
+	Layer       *FeatureLayer
 }
@@ -7,6 +7,7 @@ type FeatureKey struct {
 	Key         string
 	Description string
 	Value       int
+	Revision    int64
 	IsDeleted   bool
 	Params      []FeatureParam
 }
@@ -0,0 +1,9 @@
+package dto
+
+// FeatureLayer describes the bucket range [From, To) an experiment owns inside its layer
+type FeatureLayer struct {
+	Name string
+	Salt string
+	From int
+	To   int
+}
@@ -6,5 +6,6 @@ type FeatureParam struct {
 	Id        uuid.UUID
 	Name      string
 	Value     int
+	Revision  int64
 	IsDeleted bool
 }
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// HealthCheck is the outcome of one dependency check
+type HealthCheck struct {
+	Name string
+	// Error is empty when the check passed
+	Error    string
+	Duration time.Duration
+	// Advisory checks are reported but do not decide readiness
+	Advisory bool
+}
+
+// HealthReport is ok when every check that is not advisory passed
+type HealthReport struct {
+	Ok     bool
+	Checks []HealthCheck
+}
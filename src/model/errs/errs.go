@@ -0,0 +1,124 @@
+// Package errs is the error model shared by repositories, services and the Admin API. Every domain error
+// has a kind, which decides the HTTP status, a stable code clients can switch on, a message safe to show
+// and, for input errors, the offending field
+package errs
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Kinds, match them with errors.Is
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrInUse           = errors.New("in use")
+	ErrValidation      = errors.New("validation failed")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Field   string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match both the error itself and its kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func NotFound(code string, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func Conflict(code string, field string, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Field: field, Message: message}
+}
+
+func InUse(code string, message string) *Error {
+	return &Error{Kind: ErrInUse, Code: code, Message: message}
+}
+
+func Validation(code string, field string, message string) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Field: field, Message: message}
+}
+
+func Unauthenticated(code string, message string) *Error {
+	return &Error{Kind: ErrUnauthenticated, Code: code, Message: message}
+}
+
+func Forbidden(code string, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+// As returns the domain error in err's chain, nil for anything else
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
+
+// PostgreSQL error codes mapped by FromDB
+const (
+	sqlStateForeignKey   = "23503"
+	sqlStateUnique       = "23505"
+	sqlStateCheck        = "23514"
+	sqlStateInvalidInput = "22P02"
+	sqlStateTooLong      = "22001"
+)
+
+// uniques names what a unique index guards, so a violation reads as the taken name instead of the index
+var uniques = map[string]*Error{
+	"projects_name_key":                                Conflict("name_taken", "name", "project name is already taken"),
+	"ux_features_project_name":                         Conflict("name_taken", "name", "feature name is already taken in the project"),
+	"ux_services_project_name":                         Conflict("name_taken", "name", "service name is already taken in the project"),
+	"ux_layers_project_name":                           Conflict("name_taken", "name", "layer name is already taken in the project"),
+	"ux_activation_keys_feature_key_not_deleted":       Conflict("name_taken", "key", "key already exists in the feature"),
+	"ux_activation_params_activation_name_not_deleted": Conflict("name_taken", "name", "param already exists in the key"),
+	"ux_layer_allocations_feature":                     Conflict("already_allocated", "feature_id", "feature is already allocated in a layer"),
+}
+
+var constraintName = regexp.MustCompile(`constraint "([^"]+)"`)
+
+// FromDB turns constraint violations and rejected input reported by PostgreSQL into domain errors, the driver
+// message with table and column names stays out of them. Other errors are returned unchanged
+func FromDB(err error) error {
+	var pgErr interface{ SQLState() string }
+	if err == nil || !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.SQLState() {
+	case sqlStateUnique:
+		if m := constraintName.FindStringSubmatch(err.Error()); m != nil {
+			if e, ok := uniques[m[1]]; ok {
+				return e
+			}
+		}
+		return Conflict("already_exists", "", "entity already exists")
+	case sqlStateForeignKey:
+		// Deleting a parent that is still referenced, otherwise the referenced parent is missing
+		if strings.Contains(err.Error(), "update or delete on table") {
+			return InUse("in_use", "entity is still referenced")
+		}
+		return NotFound("reference_not_found", "referenced entity not found")
+	case sqlStateCheck:
+		return Validation("check_failed", "", "value is out of the allowed range")
+	case sqlStateInvalidInput:
+		return Validation("invalid_input", "", "value has an invalid format")
+	case sqlStateTooLong:
+		return Validation("too_long", "", "value is too long")
+	}
+
+	return err
+}
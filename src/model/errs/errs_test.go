@@ -0,0 +1,90 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pgError mimics the driver error, which exposes its SQLSTATE and quotes the constraint in the message
+type pgError struct {
+	code    string
+	message string
+}
+
+func (e *pgError) Error() string    { return "ERROR: " + e.message + " (SQLSTATE " + e.code + ")" }
+func (e *pgError) SQLState() string { return e.code }
+
+func TestFromDB(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		kind  error
+		code  string
+		field string
+	}{
+		{
+			name:  "unique key",
+			err:   &pgError{sqlStateUnique, `duplicate key value violates unique constraint "ux_activation_keys_feature_key_not_deleted"`},
+			kind:  ErrConflict,
+			code:  "name_taken",
+			field: "key",
+		},
+		{
+			name: "unknown unique",
+			err:  fmt.Errorf("insert: %w", &pgError{sqlStateUnique, `duplicate key value violates unique constraint "ux_other"`}),
+			kind: ErrConflict,
+			code: "already_exists",
+		},
+		{
+			name: "missing parent",
+			err:  &pgError{sqlStateForeignKey, `insert or update on table "activation_keys" violates foreign key constraint "activation_keys_feature_id_fkey"`},
+			kind: ErrNotFound,
+			code: "reference_not_found",
+		},
+		{
+			name: "referenced parent",
+			err:  &pgError{sqlStateForeignKey, `update or delete on table "layers" violates foreign key constraint "x" on table "layer_allocations"`},
+			kind: ErrInUse,
+			code: "in_use",
+		},
+		{
+			name: "check",
+			err:  &pgError{sqlStateCheck, `new row violates check constraint "chk_layer_allocations_range"`},
+			kind: ErrValidation,
+			code: "check_failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromDB(tt.err)
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("expected kind %v, got %v", tt.kind, err)
+			}
+
+			e := As(err)
+			if e.Code != tt.code || e.Field != tt.field {
+				t.Errorf("expected %s/%s, got %s/%s", tt.code, tt.field, e.Code, e.Field)
+			}
+			if e.Message == tt.err.Error() {
+				t.Errorf("driver message must not leak, got %q", e.Message)
+			}
+		})
+	}
+}
+
+func TestFromDB_passThrough(t *testing.T) {
+	plain := errors.New("connection refused")
+	if err := FromDB(plain); err != plain {
+		t.Errorf("errors without SQLSTATE must be returned unchanged, got %v", err)
+	}
+	if err := FromDB(nil); err != nil {
+		t.Errorf("nil must stay nil, got %v", err)
+	}
+
+	notFound := NotFound("feature_not_found", "feature not found")
+	if err := FromDB(notFound); err != notFound {
+		t.Errorf("domain errors must be returned unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package ActivationValuesRepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+// deltaTTL keeps a computed delta long enough for every pod of a service to pick it up after a change
+const deltaTTL = time.Minute
+
+// deltaCall is a delta query in flight, callers asking for the same range wait on done
+type deltaCall struct {
+	done     chan struct{}
+	features []*dto.Feature
+	err      error
+}
+
+// getDelta returns the features of serviceName changed in (from, to] and queries them once per range:
+// the result is kept in the memory cache and concurrent callers share the single in-flight query.
+// A range reaching past what the database has committed is not cached, its missing rows are still to come.
+// The slice is shared between callers and must not be modified
+func (t *Repository) getDelta(c context.Context, serviceName string, from int64, to int64) ([]*dto.Feature, error) {
+	key := fmt.Sprintf("delta:%s:%d:%d", serviceName, from, to)
+
+	if t.deltas != nil {
+		if cached, err := t.deltas.Get(c, key); err == nil {
+			if features, ok := cached.([]*dto.Feature); ok {
+				return features, nil
+			}
+		}
+	}
+
+	t.mu.Lock()
+	if call, ok := t.inflight[key]; ok {
+		t.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.features, call.err
+		case <-c.Done():
+			return nil, c.Err()
+		}
+	}
+	call := &deltaCall{done: make(chan struct{})}
+	t.inflight[key] = call
+	t.mu.Unlock()
+
+	var complete bool
+	call.features, complete, call.err = t.queryDelta(c, serviceName, from, to)
+	if call.err == nil && complete && t.deltas != nil {
+		_ = t.deltas.Set(c, key, call.features, deltaTTL)
+	}
+
+	t.mu.Lock()
+	delete(t.inflight, key)
+	t.mu.Unlock()
+	close(call.done)
+
+	return call.features, call.err
+}
+
+// queryDelta also reports whether the range was complete: writers commit in version order, so once the committed
+// version read before the rows reaches to, no row of the range can appear later
+func (t *Repository) queryDelta(c context.Context, serviceName string, from int64, to int64) ([]*dto.Feature, bool, error) {
+	project, service := ProjectRepository.SplitServiceName(serviceName)
+
+	// The replica is only picked once it has applied to, the primary is asked. The answer only decides caching,
+	// a failed check serves the delta uncached
+	reader := t.readerFor(c, to)
+	complete := reader != t.db
+	if !complete {
+		committed, err := t.committedVersion(c, reader)
+		if err != nil {
+			t.logger.Error(c, fmt.Errorf("committed version: %w", err))
+		}
+		complete = err == nil && committed >= to
+	}
+
+	// Live bindings get changed values and, when bound within the range, the feature's whole live state;
+	// bindings removed within the range get the feature-level tombstone
+	rows, err := reader.Query(c, valuesSelect+`
+	WHERE p.name = $1 AND s.name = $2 AND (
+	    (sa.deleted_at IS NULL AND (
+	        (av.v > $3 AND av.v <= $4)
+	        OR (sa.v > $3 AND sa.v <= $4 AND av.deleted_at IS NULL)
+	    ))
+	    OR (sa.deleted_at IS NOT NULL AND sa.v > $3 AND sa.v <= $4 AND av.activation_key_id IS NULL)
+	)
+`, project, service, from, to)
+	if err != nil {
+		return nil, false, err
+	}
+
+	features := aggregateValues(t.scanValues(c, rows))
+	rows.Close()
+
+	renames, err := t.queryRenames(c, reader, project, service, from, to)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return mergeRenames(features, renames), complete, nil
+}
+
+func (t *Repository) committedVersion(c context.Context, reader postgres.IPostgres) (int64, error) {
+	row, err := reader.QueryRow(c, maxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	var committed int64
+	err = row.Scan(&committed)
+	return committed, err
+}
+
+// readerFor picks the replica once it has applied version, a lagging or failing replica falls back to the primary
+func (t *Repository) readerFor(c context.Context, version int64) postgres.IPostgres {
+	if t.replica == nil {
+		return t.db
+	}
+
+	if t.replicaSeen.Load() >= version {
+		return t.replica
+	}
+
+	applied, err := t.committedVersion(c, t.replica)
+	if err != nil {
+		t.logger.Error(c, fmt.Errorf("replica version: %w", err))
+		return t.db
+	}
+
+	// Versions only grow, remember the highest one so caught-up reads skip the check
+	for seen := t.replicaSeen.Load(); applied > seen; seen = t.replicaSeen.Load() {
+		if t.replicaSeen.CompareAndSwap(seen, applied) {
+			break
+		}
+	}
+
+	if applied < version {
+		t.logger.Debug(c, fmt.Sprintf("replica lags at version %d behind %d, reading from primary", applied, version))
+		return t.db
+	}
+
+	return t.replica
+}
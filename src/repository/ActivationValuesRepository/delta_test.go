@@ -0,0 +1,213 @@
+package ActivationValuesRepository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+// fixedVersion is a version store that always reports v
+type fixedVersion int64
+
+func (v fixedVersion) Bump(context.Context, int64) error { return nil }
+func (v fixedVersion) Get(context.Context) (int64, time.Time, error) {
+	return int64(v), time.Time{}, nil
+}
+func (v fixedVersion) Verify(context.Context) error { return nil }
+
+// memoryCache is an in-process memory.IMemory
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+func (m *memoryCache) Init(map[string]interface{}) error { return nil }
+func (m *memoryCache) Stop() error                       { return nil }
+func (m *memoryCache) Name() string                      { return "memory" }
+func (m *memoryCache) Type() interfaces.PkgType          { return interfaces.PkgCache }
+
+func (m *memoryCache) Has(c context.Context, key string) bool {
+	_, err := m.Get(c, key)
+	return err == nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, v any, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values == nil {
+		m.values = map[string]any{}
+	}
+	m.values[key] = v
+	return nil
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key]
+	if !ok {
+		return nil, redis.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *memoryCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// valuesDb answers value queries with features rows and MAX(v) with applied, counting both; there are no renames
+type valuesDb struct {
+	postgres.Mock
+	features int
+	applied  int64
+	queries  atomic.Int64
+	checks   atomic.Int64
+	delay    time.Duration
+}
+
+func newValuesDb(features int, applied int64) *valuesDb {
+	t := &valuesDb{features: features, applied: applied}
+	t.QueryFunc = func(_ context.Context, query string, _ ...any) (postgres.SQLRows, error) {
+		if strings.Contains(query, "FROM renames") {
+			return &postgres.MockRows{}, nil
+		}
+		t.queries.Add(1)
+		time.Sleep(t.delay)
+
+		rows := make([][]any, 0, t.features)
+		for i := 0; i < t.features; i++ {
+			rows = append(rows, []any{uuid.New().String(), fmt.Sprintf("feature_%d", i), nil, nil, nil, nil, 50, int64(2), nil, nil, nil, nil, nil, nil, nil})
+		}
+		return &postgres.MockRows{Values: rows}, nil
+	}
+	t.QueryRowFunc = func(_ context.Context, query string, _ ...any) (postgres.SQLRow, error) {
+		if !strings.Contains(query, "MAX(v)") {
+			return nil, fmt.Errorf("unexpected query %s", query)
+		}
+		t.checks.Add(1)
+		return &postgres.MockRow{Values: []any{t.applied}}, nil
+	}
+	return t
+}
+
+func TestRepository_GetNewByServiceNameDelta(t *testing.T) {
+	primary := newValuesDb(3, 2)
+	primary.delay = 10 * time.Millisecond
+	r := NewForTest(primary, nil, &memoryCache{}, fixedVersion(2), mock_log.New(true))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, features, err := r.GetNewByServiceName(context.Background(), "billing", 1); err != nil || v != 2 || len(features) != 3 {
+				t.Errorf("unexpected %d %d %v", v, len(features), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := primary.queries.Load(); got != 1 {
+		t.Errorf("pods asking for the same delta must hit the database once, got %d", got)
+	}
+
+	if _, _, err := r.GetNewByServiceName(context.Background(), "billing", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := primary.queries.Load(); got != 2 {
+		t.Errorf("another range must be queried, got %d queries", got)
+	}
+}
+
+func TestRepository_GetNewByServiceNameUncommitted(t *testing.T) {
+	// Version 3 is published while the primary has committed only 2
+	primary := newValuesDb(1, 2)
+	r := NewForTest(primary, nil, &memoryCache{}, fixedVersion(3), mock_log.New(true))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := r.GetNewByServiceName(context.Background(), "billing", 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := primary.queries.Load(); got != 2 {
+		t.Errorf("a range past the committed version must not be cached, got %d queries", got)
+	}
+}
+
+func TestRepository_GetNewByServiceNameReplica(t *testing.T) {
+	t.Run("lagging replica", func(t *testing.T) {
+		primary, replica := newValuesDb(1, 2), newValuesDb(1, 1)
+		r := NewForTest(primary, replica, nil, fixedVersion(2), mock_log.New(true))
+
+		if _, _, err := r.GetNewByServiceName(context.Background(), "billing", 0); err != nil {
+			t.Fatal(err)
+		}
+		if primary.queries.Load() != 1 || replica.queries.Load() != 0 {
+			t.Errorf("a replica behind the version must not be read, primary %d replica %d", primary.queries.Load(), replica.queries.Load())
+		}
+	})
+
+	t.Run("caught up replica", func(t *testing.T) {
+		primary, replica := newValuesDb(1, 2), newValuesDb(1, 2)
+		r := NewForTest(primary, replica, nil, fixedVersion(2), mock_log.New(true))
+
+		for i := int64(0); i < 2; i++ {
+			if _, _, err := r.GetNewByServiceName(context.Background(), "billing", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if primary.queries.Load() != 0 || replica.queries.Load() != 2 {
+			t.Errorf("reads must go to the replica, primary %d replica %d", primary.queries.Load(), replica.queries.Load())
+		}
+		if replica.checks.Load() != 1 {
+			t.Errorf("the applied version must be remembered, checked %d times", replica.checks.Load())
+		}
+	})
+}
+
+// BenchmarkGetNewByServiceName compares every poll running the join against the primary with the delta cache,
+// a simulated 1ms round trip stands in for the heavy join
+func BenchmarkGetNewByServiceName(b *testing.B) {
+	b.Run("primary", func(b *testing.B) {
+		pg := newValuesDb(50, 2)
+		pg.delay = time.Millisecond
+		r := NewForTest(pg, nil, nil, fixedVersion(2), mock_log.New(true))
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := r.queryDelta(context.Background(), "billing", 1, 2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.ReportMetric(float64(pg.queries.Load())/float64(b.N), "queries/op")
+	})
+
+	b.Run("delta_cache", func(b *testing.B) {
+		pg := newValuesDb(50, 2)
+		pg.delay = time.Millisecond
+		r := NewForTest(pg, nil, &memoryCache{}, fixedVersion(2), mock_log.New(true))
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := r.GetNewByServiceName(context.Background(), "billing", 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.ReportMetric(float64(pg.queries.Load())/float64(b.N), "queries/op")
+	})
+}
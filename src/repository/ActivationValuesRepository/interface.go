@@ -5,18 +5,31 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
 type Interface interface {
 	InsertValue(c context.Context, tx postgres.SQLTx, featureId uuid.UUID, keyId *uuid.UUID, paramId *uuid.UUID, value int) (int64, error)
+	TouchFeature(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) (int64, error)
+	AllocateVersion(c context.Context, tx postgres.SQLTx) (int64, error)
+	RecordRename(c context.Context, tx postgres.SQLTx, v int64, rename db.Rename) error
+	RecordBinding(c context.Context, tx postgres.SQLTx, v int64, featureId uuid.UUID, serviceId uuid.UUID, bound bool) error
+	// Publish announces a version after the transaction that wrote it has committed
+	Publish(c context.Context, v int64)
+
+	GetVersion(c context.Context, targetId uuid.UUID) (uuid.UUID, int64, error)
+	CheckRevision(c context.Context, tx postgres.SQLTx, targetId uuid.UUID, revision int64) error
+	GetState(c context.Context, targetId uuid.UUID) (*dto.EntityState, error)
 
 	GetNewByServiceName(c context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature, error)
+	GetGlobalVersion(c context.Context) (int64, time.Time, error)
+	GetClientSideByServiceName(c context.Context, serviceName string) (int64, []*dto.Feature, error)
 
-	GetFeatures(c context.Context, serviceId string, page int, pageSize int, find string, isDeprecated bool, deprecatedTime time.Duration) ([]*dto.Feature, int, error)
+	GetFeatures(c context.Context, projectId uuid.UUID, serviceId string, page int, pageSize int, find string, isDeprecated bool, deprecatedTime time.Duration) ([]*dto.Feature, int, error)
 
-	DeleteByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error
-	DeleteByKeyId(c context.Context, tx postgres.SQLTx, keyId uuid.UUID) error
-	DeleteByParamId(c context.Context, tx postgres.SQLTx, paramId uuid.UUID) error
+	DeleteByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) (int64, error)
+	DeleteByKeyId(c context.Context, tx postgres.SQLTx, keyId uuid.UUID) (int64, error)
+	DeleteByParamId(c context.Context, tx postgres.SQLTx, paramId uuid.UUID) (int64, error)
 }
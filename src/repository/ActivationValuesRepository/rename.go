@@ -0,0 +1,149 @@
+package ActivationValuesRepository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+// RecordRename stores the old name in version v and re-versions what clients would lose with it: a renamed
+// feature arrives again with all its keys and params, a renamed key with its params.
+// Webhook subscribers get a renamed event with the old name
+func (t *Repository) RecordRename(c context.Context, tx postgres.SQLTx, v int64, rename db.Rename) error {
+	var keyId, paramId any
+	if rename.KeyId != nil {
+		keyId = *rename.KeyId
+	}
+	if rename.ParamId != nil {
+		paramId = *rename.ParamId
+	}
+
+	if err := tx.Exec(c, `
+INSERT INTO renames (id, kind, feature_id, key_id, feature_name, key_name, param_name, v)
+VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+`, uuid.New(), rename.Kind, rename.FeatureId, keyId, rename.FeatureName, rename.KeyName, rename.ParamName, v); err != nil {
+		return err
+	}
+
+	previousName := rename.FeatureName
+	switch rename.Kind {
+	case db.RenameFeature:
+		if err := tx.Exec(c, `UPDATE activation_values SET v = $2 WHERE feature_id = $1 AND deleted_at IS NULL`, rename.FeatureId, v); err != nil {
+			return err
+		}
+	case db.RenameKey:
+		previousName = rename.KeyName
+		if err := tx.Exec(c, `UPDATE activation_values SET v = $2 WHERE activation_key_id = $1 AND deleted_at IS NULL`, keyId, v); err != nil {
+			return err
+		}
+	case db.RenameParam:
+		previousName = rename.ParamName
+	}
+
+	return t.writeOutboxEvent(c, tx, db.WebhookEventRenamed, rename.FeatureId, keyId, paramId, nil, nil, v, nil, previousName)
+}
+
+// queryRenames returns the old names of the service's features renamed in (from, to]
+func (t *Repository) queryRenames(c context.Context, reader postgres.IPostgres, project string, service string, from int64, to int64) ([]db.Rename, error) {
+	rows, err := reader.Query(c, `
+	SELECT r.kind, r.feature_id, r.key_id, r.feature_name, COALESCE(r.key_name, ''), COALESCE(r.param_name, '')
+	FROM renames r
+	JOIN service_access sa ON sa.feature_id = r.feature_id AND sa.deleted_at IS NULL
+	JOIN services s ON s.id = sa.service_id
+	JOIN projects p ON p.id = s.project_id
+	WHERE p.name = $1 AND s.name = $2 AND r.v > $3 AND r.v <= $4
+	ORDER BY r.v
+`, project, service, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	renames := make([]db.Rename, 0)
+	for rows.Next() {
+		var r db.Rename
+		if err := rows.Scan(&r.Kind, &r.FeatureId, &r.KeyId, &r.FeatureName, &r.KeyName, &r.ParamName); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		renames = append(renames, r)
+	}
+
+	return renames, nil
+}
+
+// mergeRenames adds the old names to the delta as deletions. Key and param tombstones go into the renamed
+// entity's feature, which the delta carries because the rename re-versioned it. Names that are live again
+// in the delta, e.g. after renaming back, are not deleted
+func mergeRenames(features []*dto.Feature, renames []db.Rename) []*dto.Feature {
+	if len(renames) == 0 {
+		return features
+	}
+
+	byId := make(map[uuid.UUID]*dto.Feature, len(features))
+	liveNames := make(map[string]bool, len(features))
+	deleted := make(map[string]bool)
+	for _, f := range features {
+		byId[f.Id] = f
+		if f.IsDeleted {
+			deleted[f.Name] = true
+		} else {
+			liveNames[f.Name] = true
+		}
+	}
+
+	for _, r := range renames {
+		switch r.Kind {
+		case db.RenameFeature:
+			if liveNames[r.FeatureName] || deleted[r.FeatureName] {
+				continue
+			}
+			deleted[r.FeatureName] = true
+			features = append(features, &dto.Feature{Id: r.FeatureId, Name: r.FeatureName, IsDeleted: true})
+
+		case db.RenameKey:
+			f, ok := byId[r.FeatureId]
+			if !ok || f.IsDeleted || hasKey(f, r.KeyName) {
+				continue
+			}
+			f.Keys = append(f.Keys, dto.FeatureKey{Key: r.KeyName, Value: -1, IsDeleted: true, Params: []dto.FeatureParam{}})
+
+		case db.RenameParam:
+			f, ok := byId[r.FeatureId]
+			if !ok || f.IsDeleted || r.KeyId == nil {
+				continue
+			}
+			for i := range f.Keys {
+				key := &f.Keys[i]
+				if key.Id != *r.KeyId || key.IsDeleted || hasParam(key, r.ParamName) {
+					continue
+				}
+				key.Params = append(key.Params, dto.FeatureParam{Name: r.ParamName, IsDeleted: true})
+			}
+		}
+	}
+
+	return features
+}
+
+func hasKey(f *dto.Feature, name string) bool {
+	for _, k := range f.Keys {
+		if k.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasParam(k *dto.FeatureKey, name string) bool {
+	for _, p := range k.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
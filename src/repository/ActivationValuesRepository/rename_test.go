@@ -0,0 +1,83 @@
+package ActivationValuesRepository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+func TestRepository_RecordRename(t *testing.T) {
+	featureId, keyId := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		rename db.Rename
+		// reversion is the statement re-versioning live values, empty when only the renamed row changes
+		reversion string
+		// previous is the old name the webhook event carries
+		previous string
+	}{
+		{
+			name:      "feature",
+			rename:    db.Rename{Kind: db.RenameFeature, FeatureId: featureId, FeatureName: "checkout"},
+			reversion: "WHERE feature_id = $1 AND deleted_at IS NULL",
+			previous:  "checkout",
+		},
+		{
+			name:      "key",
+			rename:    db.Rename{Kind: db.RenameKey, FeatureId: featureId, KeyId: &keyId, FeatureName: "checkout", KeyName: "country"},
+			reversion: "WHERE activation_key_id = $1 AND deleted_at IS NULL",
+			previous:  "country",
+		},
+		{
+			name:     "param",
+			rename:   db.Rename{Kind: db.RenameParam, FeatureId: featureId, KeyId: &keyId, FeatureName: "checkout", KeyName: "country", ParamName: "DE"},
+			previous: "DE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			var args [][]any
+			pg := &postgres.Mock{
+				ExecFunc: func(_ context.Context, query string, a ...any) error {
+					queries = append(queries, query)
+					args = append(args, a)
+					return nil
+				},
+			}
+			r := NewForTest(pg, nil, nil, fixedVersion(9), mock_log.New(true))
+			tx, _ := pg.BeginTx(context.Background())
+
+			if err := r.RecordRename(context.Background(), tx, 9, tt.rename); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(queries[0], "INSERT INTO renames") || args[0][1] != tt.rename.Kind || args[0][7] != int64(9) {
+				t.Errorf("old name must be stored in the rename's version, got %s %v", queries[0], args[0])
+			}
+
+			// Webhook subscribers learn the old name from the renamed event, written last in the same transaction
+			last := len(queries) - 1
+			if !strings.Contains(queries[last], "INSERT INTO webhook_outbox") || args[last][0] != db.WebhookEventRenamed || args[last][6] != int64(9) || args[last][8] != tt.previous {
+				t.Errorf("expected a renamed event with previous name %q, got %s %v", tt.previous, queries[last], args[last])
+			}
+
+			if tt.reversion == "" {
+				if len(queries) != 2 {
+					t.Errorf("nothing else must be re-versioned, got %v", queries[1:last])
+				}
+				return
+			}
+			if len(queries) != 3 || !strings.Contains(queries[1], tt.reversion) || args[1][1] != int64(9) {
+				t.Errorf("live values must move to the rename's version, got %v %v", queries, args)
+			}
+		})
+	}
+}
@@ -4,41 +4,80 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
-	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/memory"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
+var (
+	ErrValueNotFound    = errs.NotFound("not_found", "feature, key or param not found")
+	ErrRevisionMismatch = errs.Conflict("revision_mismatch", "revision", "changed by someone else, reload and try again")
+)
+
+// targetWhere matches the live value row of a feature, key or param id passed as $1
+const targetWhere = `deleted_at IS NULL
+  AND (
+    activation_param_id = $1
+    OR (activation_param_id IS NULL AND activation_key_id = $1)
+    OR (activation_key_id IS NULL AND feature_id = $1)
+  )`
+
 type Repository struct {
 	repository.Mock
-	db     postgres.IPostgres
-	cache  redis.IRedis
-	logger interfaces.ILogger
+	db      postgres.IPostgres
+	replica postgres.IPostgres
+	deltas  memory.IMemory
+	version VersionRepository.Interface
+	logger  interfaces.ILogger
+
+	// replicaSeen is the highest version the replica is known to have applied
+	replicaSeen atomic.Int64
+
+	mu       sync.Mutex
+	inflight map[string]*deltaCall
 }
 
 func New(name string) *Repository {
-	return &Repository{Mock: repository.Mock{NamePkg: name}}
+	return &Repository{
+		Mock:     repository.Mock{NamePkg: name},
+		inflight: make(map[string]*deltaCall),
+	}
 }
 
-func NewForTest(db postgres.IPostgres, cache redis.IRedis, logger interfaces.ILogger) *Repository {
+// NewForTest builds the repository over db; replica may be nil to read everything from db, deltas nil to skip the delta cache
+func NewForTest(db postgres.IPostgres, replica postgres.IPostgres, deltas memory.IMemory, version VersionRepository.Interface, logger interfaces.ILogger) *Repository {
 	return &Repository{
-		db:     db,
-		cache:  cache,
-		logger: logger,
+		db:       db,
+		replica:  replica,
+		deltas:   deltas,
+		version:  version,
+		logger:   logger,
+		inflight: make(map[string]*deltaCall),
 	}
 }
 
 func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
 	t.logger = app.GetLogger()
 	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
-	t.cache = app.GetPkg(interfaces.PkgCache, names.CacheRedis).(redis.IRedis)
+	t.deltas = app.GetPkg(interfaces.PkgCache, names.CacheMemory).(memory.IMemory)
+	t.version = app.GetModule(interfaces.ModuleRepository, names.VersionRepository).(VersionRepository.Interface)
+
+	// The read replica is optional, subscriber deltas go to the primary without it
+	if replica, ok := app.GetPkg(interfaces.PkgDatabase, names.DatabaseReplica).(postgres.IPostgres); ok {
+		t.replica = replica
+	}
 
 	return nil
 }
@@ -58,9 +97,28 @@ func (t *Repository) InsertValue(c context.Context, tx postgres.SQLTx, featureId
 		param = *paramId
 	}
 
+	// Remember the live value for the change event before it is overwritten
+	var before *int
+	row, err := tx.QueryRow(c, `
+SELECT value
+FROM activation_values
+WHERE feature_id = $1
+  AND activation_key_id IS NOT DISTINCT FROM $2
+  AND activation_param_id IS NOT DISTINCT FROM $3
+  AND deleted_at IS NULL
+FOR UPDATE
+`, featureId, key, param)
+	if err != nil {
+		return 0, err
+	}
+	var old int
+	if scanErr := row.Scan(&old); scanErr == nil {
+		before = &old
+	}
+
 	// Try to restore/update existing row (handles soft-deleted as well)
 	var updatedId uuid.UUID
-	row, err := tx.QueryRow(c, `
+	row, err = tx.QueryRow(c, `
 UPDATE activation_values
 SET value = $4, deleted_at = NULL, v = $5
 WHERE feature_id = $1
@@ -72,8 +130,8 @@ RETURNING id
 		return 0, err
 	}
 	if scanErr := row.Scan(&updatedId); scanErr == nil {
-		if bumpErr := t.bumpGlobalVersion(c, v); bumpErr != nil {
-			t.logger.Error(c, fmt.Errorf("bump global version: %w", bumpErr))
+		if err := t.writeOutbox(c, tx, db.WebhookEventUpdated, featureId, key, param, before, &value, v); err != nil {
+			return 0, err
 		}
 		return v, nil
 	}
@@ -93,127 +151,367 @@ DO UPDATE SET value = EXCLUDED.value, deleted_at = NULL, v = EXCLUDED.v
 		return 0, err
 	}
 
-	if bumpErr := t.bumpGlobalVersion(c, v); bumpErr != nil {
-		t.logger.Error(c, fmt.Errorf("bump global version: %w", bumpErr))
+	if err := t.writeOutbox(c, tx, db.WebhookEventUpdated, featureId, key, param, before, &value, v); err != nil {
+		return 0, err
 	}
 
 	return v, nil
 }
 
-func (t *Repository) DeleteByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error {
+// TouchFeature re-versions the feature-level value so subscribers receive the feature again
+func (t *Repository) TouchFeature(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) (int64, error) {
 	v, err := t.nextVersion(c, tx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = tx.Exec(c, `UPDATE activation_values SET deleted_at = NOW(), v = $1 WHERE feature_id = $2`, v, featureId)
+	row, err := tx.QueryRow(c, `
+UPDATE activation_values
+SET v = $2
+WHERE feature_id = $1
+  AND activation_key_id IS NULL
+  AND activation_param_id IS NULL
+  AND deleted_at IS NULL
+RETURNING value
+`, featureId, v)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = tx.Exec(c, `DELETE FROM activation_values WHERE feature_id = $1 AND activation_key_id IS NOT NULL`, featureId)
+	// Bucketing changed without a value change, subscribers still need to know
+	var value int
+	if scanErr := row.Scan(&value); scanErr == nil {
+		if err := t.writeOutbox(c, tx, db.WebhookEventUpdated, featureId, nil, nil, &value, &value, v); err != nil {
+			return 0, err
+		}
+	}
+
+	return v, nil
+}
+
+// GetVersion resolves the live value row of a feature, key or param id to its feature and current version
+func (t *Repository) GetVersion(c context.Context, targetId uuid.UUID) (uuid.UUID, int64, error) {
+	row, err := t.db.QueryRow(c, `SELECT feature_id, v FROM activation_values WHERE `+targetWhere+` LIMIT 1`, targetId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, 0, err
+	}
+
+	var featureId uuid.UUID
+	var v int64
+	if err := row.Scan(&featureId, &v); err != nil {
+		return uuid.Nil, 0, ErrValueNotFound
+	}
+
+	return featureId, v, nil
+}
+
+// CheckRevision locks the live value row of a feature, key or param and fails with ErrRevisionMismatch
+// when it was re-versioned since the caller read it. Revision 0 skips the check
+func (t *Repository) CheckRevision(c context.Context, tx postgres.SQLTx, targetId uuid.UUID, revision int64) error {
+	if revision == 0 {
+		return nil
+	}
+
+	row, err := tx.QueryRow(c, `SELECT v FROM activation_values WHERE `+targetWhere+` LIMIT 1 FOR UPDATE`, targetId)
 	if err != nil {
 		return err
 	}
 
-	if bumpErr := t.bumpGlobalVersion(c, v); bumpErr != nil {
-		t.logger.Error(c, fmt.Errorf("bump global version: %w", bumpErr))
+	var v int64
+	if err := row.Scan(&v); err != nil {
+		return ErrValueNotFound
+	}
+	if v != revision {
+		return ErrRevisionMismatch
 	}
 
 	return nil
 }
 
-func (t *Repository) DeleteByKeyId(c context.Context, tx postgres.SQLTx, keyId uuid.UUID) error {
+// GetState returns what the admin UI shows for a feature, key or param, including its revision
+func (t *Repository) GetState(c context.Context, targetId uuid.UUID) (*dto.EntityState, error) {
+	row, err := t.db.QueryRow(c, `
+SELECT av.feature_id, av.activation_key_id, av.activation_param_id,
+       COALESCE(ap.name, ak.key, f.name),
+       CASE WHEN av.activation_param_id IS NOT NULL THEN '' WHEN av.activation_key_id IS NOT NULL THEN ak.description ELSE f.description END,
+       av.value, av.v
+FROM activation_values av
+JOIN features f ON f.id = av.feature_id
+LEFT JOIN activation_keys ak ON ak.id = av.activation_key_id
+LEFT JOIN activation_params ap ON ap.id = av.activation_param_id
+WHERE av.deleted_at IS NULL
+  AND (
+    av.activation_param_id = $1
+    OR (av.activation_param_id IS NULL AND av.activation_key_id = $1)
+    OR (av.activation_key_id IS NULL AND av.feature_id = $1)
+  )
+LIMIT 1
+`, targetId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	var state dto.EntityState
+	if err := row.Scan(&state.FeatureId, &state.KeyId, &state.ParamId, &state.Name, &state.Description, &state.Value, &state.Revision); err != nil {
+		return nil, ErrValueNotFound
+	}
+	state.Id = targetId
+
+	return &state, nil
+}
+
+func (t *Repository) DeleteByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) (int64, error) {
 	v, err := t.nextVersion(c, tx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = tx.Exec(c, `UPDATE activation_values SET deleted_at = NOW(), v = $1 WHERE activation_key_id = $2`, v, keyId)
+	_, before, err := t.liveValueOf(c, tx, `feature_id = $1 AND activation_key_id IS NULL`, featureId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = tx.Exec(c, `DELETE FROM activation_values WHERE activation_key_id = $1 AND activation_param_id IS NOT NULL`, keyId)
+	err = tx.Exec(c, `UPDATE activation_values SET deleted_at = NOW(), v = $1 WHERE feature_id = $2`, v, featureId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if bumpErr := t.bumpGlobalVersion(c, v); bumpErr != nil {
-		t.logger.Error(c, fmt.Errorf("bump global version: %w", bumpErr))
+	if err := t.writeOutbox(c, tx, db.WebhookEventDeleted, featureId, nil, nil, before, nil, v); err != nil {
+		return 0, err
 	}
 
-	return nil
+	err = tx.Exec(c, `DELETE FROM activation_values WHERE feature_id = $1 AND activation_key_id IS NOT NULL`, featureId)
+	if err != nil {
+		return 0, err
+	}
+
+	return v, nil
 }
 
-func (t *Repository) DeleteByParamId(c context.Context, tx postgres.SQLTx, paramId uuid.UUID) error {
+func (t *Repository) DeleteByKeyId(c context.Context, tx postgres.SQLTx, keyId uuid.UUID) (int64, error) {
 	v, err := t.nextVersion(c, tx)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	featureId, before, err := t.liveValueOf(c, tx, `activation_key_id = $1 AND activation_param_id IS NULL`, keyId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Exec(c, `UPDATE activation_values SET deleted_at = NOW(), v = $1 WHERE activation_key_id = $2`, v, keyId)
+	if err != nil {
+		return 0, err
+	}
+
+	if featureId != uuid.Nil {
+		if err := t.writeOutbox(c, tx, db.WebhookEventDeleted, featureId, keyId, nil, before, nil, v); err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Exec(c, `DELETE FROM activation_values WHERE activation_key_id = $1 AND activation_param_id IS NOT NULL`, keyId)
+	if err != nil {
+		return 0, err
+	}
+
+	return v, nil
+}
+
+func (t *Repository) DeleteByParamId(c context.Context, tx postgres.SQLTx, paramId uuid.UUID) (int64, error) {
+	v, err := t.nextVersion(c, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	featureId, before, err := t.liveValueOf(c, tx, `activation_param_id = $1`, paramId)
+	if err != nil {
+		return 0, err
 	}
 
 	err = tx.Exec(c, `UPDATE activation_values SET deleted_at = NOW(), v = $1 WHERE activation_param_id = $2`, v, paramId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if bumpErr := t.bumpGlobalVersion(c, v); bumpErr != nil {
-		t.logger.Error(c, fmt.Errorf("bump global version: %w", bumpErr))
+	if featureId != uuid.Nil {
+		if err := t.writeOutbox(c, tx, db.WebhookEventDeleted, featureId, nil, paramId, before, nil, v); err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return v, nil
+}
+
+// liveValueOf returns the feature and value of the live row matched by where, uuid.Nil when there is none
+func (t *Repository) liveValueOf(c context.Context, tx postgres.SQLTx, where string, id uuid.UUID) (uuid.UUID, *int, error) {
+	row, err := tx.QueryRow(c, `SELECT feature_id, value FROM activation_values WHERE deleted_at IS NULL AND `+where, id)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	var featureId uuid.UUID
+	var value int
+	if err := row.Scan(&featureId, &value); err != nil {
+		return uuid.Nil, nil, nil
+	}
+
+	return featureId, &value, nil
+}
+
+// writeOutbox records the change for webhook delivery, it commits or rolls back together with the change itself
+func (t *Repository) writeOutbox(c context.Context, tx postgres.SQLTx, eventType string, featureId uuid.UUID, keyId any, paramId any, before *int, after *int, v int64) error {
+	return t.writeOutboxEvent(c, tx, eventType, featureId, keyId, paramId, before, after, v, nil, nil)
+}
+
+// writeOutboxEvent is writeOutbox for events that name the service they bound or unbound, it is listed in
+// service_names even when no longer bound, or the previous name of what was renamed
+func (t *Repository) writeOutboxEvent(c context.Context, tx postgres.SQLTx, eventType string, featureId uuid.UUID, keyId any, paramId any, before *int, after *int, v int64, serviceId any, previousName any) error {
+	return tx.Exec(c, `
+INSERT INTO webhook_outbox (event_type, project_id, feature_id, feature_name, key_name, param_name, service_names, value_before, value_after, version, service_name, previous_name)
+SELECT
+    $1,
+    f.project_id,
+    f.id,
+    f.name,
+    COALESCE(
+        (SELECT ak.key FROM activation_keys ak WHERE ak.id = $3),
+        (SELECT ak.key FROM activation_params ap JOIN activation_keys ak ON ak.id = ap.activation_id WHERE ap.id = $4)
+    ),
+    (SELECT ap.name FROM activation_params ap WHERE ap.id = $4),
+    COALESCE((
+        SELECT array_agg(s.name)
+        FROM services s
+        WHERE s.id = $8::uuid
+           OR s.id IN (SELECT sa.service_id FROM service_access sa WHERE sa.feature_id = f.id AND sa.deleted_at IS NULL)
+    ), '{}'),
+    $5,
+    $6,
+    $7,
+    (SELECT s.name FROM services s WHERE s.id = $8::uuid),
+    $9::text
+FROM features f
+WHERE f.id = $2
+`, eventType, featureId, keyId, paramId, before, after, v, serviceId, previousName)
 }
 
+// maxVersion is the newest version applied to values or service bindings, they share one sequence
+const maxVersion = `
+SELECT GREATEST(
+    (SELECT COALESCE(MAX(v), 0) FROM activation_values),
+    (SELECT COALESCE(MAX(v), 0) FROM service_access)
+)`
+
+// versionLock is the advisory lock writers hold from taking a version until they commit: versions then become
+// visible in the order they were taken, and a subscriber that read up to v cannot see a smaller one commit later
+const versionLock = 4651320746193875
+
+// nextVersion takes a number from the sequence, it is never handed out twice even if tx writes nothing or rolls back.
+// The lock is taken first in the subquery, a volatile function keeps it from being flattened into the outer select
 func (t *Repository) nextVersion(c context.Context, tx postgres.SQLTx) (int64, error) {
-	row, err := tx.QueryRow(c, `SELECT COALESCE(MAX(v), 0) FROM activation_values`)
+	row, err := tx.QueryRow(c, `SELECT nextval('feature_version_seq') FROM (SELECT pg_advisory_xact_lock($1)) AS locked`, versionLock)
 	if err != nil {
 		return 0, err
 	}
-	var max int64
-	if err := row.Scan(&max); err != nil {
+	var v int64
+	if err := row.Scan(&v); err != nil {
 		return 0, err
 	}
-	return max + 1, nil
+	return v, nil
 }
 
-func (t *Repository) bumpGlobalVersion(c context.Context, v int64) error {
-	return t.cache.Set(c, "feature_version", v, 365*24*time.Hour)
+// RecordBinding queues the bound or unbound webhook event of a binding change made in tx under version v
+func (t *Repository) RecordBinding(c context.Context, tx postgres.SQLTx, v int64, featureId uuid.UUID, serviceId uuid.UUID, bound bool) error {
+	eventType := db.WebhookEventUnbound
+	if bound {
+		eventType = db.WebhookEventBound
+	}
+
+	return t.writeOutboxEvent(c, tx, eventType, featureId, nil, nil, nil, nil, v, serviceId, nil)
 }
 
-func (t *Repository) GetNewByServiceName(c context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature, error) {
-	cachedVersionStr, err := t.cache.Get(c, "feature_version")
-	if err != nil {
-		cachedVersionStr = "-1"
+// AllocateVersion versions a change made in tx outside of activation_values, such as a service binding
+func (t *Repository) AllocateVersion(c context.Context, tx postgres.SQLTx) (int64, error) {
+	return t.nextVersion(c, tx)
+}
+
+// Publish announces v to subscribers, callers invoke it once the transaction that wrote v has committed:
+// a version published earlier can be polled before its rows are visible and would be skipped for good.
+// A zero v means the transaction versioned nothing
+func (t *Repository) Publish(c context.Context, v int64) {
+	if v <= 0 {
+		return
 	}
+	if err := t.version.Bump(c, v); err != nil {
+		t.logger.Error(c, fmt.Errorf("bump global version: %w", err))
+	}
+}
 
-	cachedVersion, _ := strconv.ParseInt(cachedVersionStr, 10, 64)
+// GetGlobalVersion returns the latest version and when it was published, the time is zero if unknown
+func (t *Repository) GetGlobalVersion(c context.Context) (int64, time.Time, error) {
+	return t.version.Get(c)
+}
+
+func (t *Repository) GetNewByServiceName(c context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature, error) {
+	cachedVersion, _, _ := t.version.Get(c)
 
 	if cachedVersion <= lastVersion {
 		return cachedVersion, nil, nil
 	}
 
-	rows, err := t.db.Query(c, `
-	SELECT av.feature_id, f.name, av.activation_key_id, ak.key, av.activation_param_id, ap.name, av.value, av.v, av.deleted_at
+	features, err := t.getDelta(c, serviceName, lastVersion, cachedVersion)
+	if err != nil {
+		t.logger.Error(c, err)
+		return lastVersion, nil, err
+	}
+
+	return cachedVersion, features, nil
+}
+
+// GetClientSideByServiceName returns the live configuration of features the service exposes to client-side evaluation
+func (t *Repository) GetClientSideByServiceName(c context.Context, serviceName string) (int64, []*dto.Feature, error) {
+	cachedVersion, _, _ := t.version.Get(c)
+
+	project, service := ProjectRepository.SplitServiceName(serviceName)
+
+	rows, err := t.db.Query(c, valuesSelect+`
+	WHERE p.name = $1 AND s.name = $2 AND sa.client_side AND sa.deleted_at IS NULL AND av.deleted_at IS NULL
+`, project, service)
+
+	if err != nil {
+		t.logger.Error(c, err)
+		return cachedVersion, nil, err
+	}
+
+	defer rows.Close()
+
+	return cachedVersion, aggregateValues(t.scanValues(c, rows)), nil
+}
+
+// valuesSelect is the common part of the per-service configuration queries, callers append the WHERE clause;
+// services are addressed by project and name. A removed binding reads as deleted values of the feature
+const valuesSelect = `
+	SELECT av.feature_id, f.name, av.activation_key_id, ak.key, av.activation_param_id, ap.name, av.value, av.v, COALESCE(sa.deleted_at, av.deleted_at),
+	       l.name, l.salt, la.bucket_from, la.bucket_to, f.salt, f.bucket_by
 	FROM activation_values av
 	JOIN service_access sa ON sa.feature_id = av.feature_id
 	JOIN services s ON s.id = sa.service_id
+	JOIN projects p ON p.id = s.project_id
 	JOIN features f ON f.id = av.feature_id
 	LEFT JOIN activation_keys ak ON ak.id = av.activation_key_id
 	LEFT JOIN activation_params ap ON ap.id = av.activation_param_id
-	WHERE s.name = $1 AND av.v > $2
-`, serviceName, lastVersion)
-
-	if err != nil {
-		t.logger.Error(c, err)
-		return lastVersion, nil, err
-	}
+	LEFT JOIN layer_allocations la ON la.feature_id = av.feature_id
+	LEFT JOIN layers l ON l.id = la.layer_id`
 
-	defer rows.Close()
+func (t *Repository) scanValues(c context.Context, rows postgres.SQLRows) []db.ActivationValues {
 	values := make([]db.ActivationValues, 0)
 
 	for rows.Next() {
 		var f db.ActivationValues
-		if err := rows.Scan(&f.FeatureID, &f.FeatureName, &f.KeyId, &f.KeyName, &f.ParamId, &f.ParamName, &f.Value, &f.V, &f.DeletedAt); err != nil {
+		if err := rows.Scan(&f.FeatureID, &f.FeatureName, &f.KeyId, &f.KeyName, &f.ParamId, &f.ParamName, &f.Value, &f.V, &f.DeletedAt,
+			&f.LayerName, &f.LayerSalt, &f.LayerFrom, &f.LayerTo, &f.FeatureSalt, &f.BucketBy); err != nil {
 			t.logger.Error(c, err)
 			continue
 		}
@@ -221,12 +519,19 @@ func (t *Repository) GetNewByServiceName(c context.Context, serviceName string,
 		values = append(values, f)
 	}
 
+	return values
+}
+
+func aggregateValues(values []db.ActivationValues) []*dto.Feature {
 	// Aggregate preserving encounter order and defaulting absent values to -1
 	type featureAgg struct {
 		name      string
+		salt      string
+		bucketBy  string
 		value     int
 		valueSet  bool
 		isDeleted bool
+		layer     *dto.FeatureLayer
 	}
 	type keyAgg struct {
 		featureID uuid.UUID
@@ -246,7 +551,7 @@ func (t *Repository) GetNewByServiceName(c context.Context, serviceName string,
 		if f, ok := featureById[id]; ok {
 			return f
 		}
-		f := &featureAgg{name: name}
+		f := &featureAgg{name: name, salt: name}
 		featureById[id] = f
 		featureOrder = append(featureOrder, id)
 		return f
@@ -263,6 +568,18 @@ func (t *Repository) GetNewByServiceName(c context.Context, serviceName string,
 
 	for _, v := range values {
 		f := ensureFeature(v.FeatureID, v.FeatureName)
+		if v.FeatureSalt != nil && *v.FeatureSalt != "" {
+			f.salt = *v.FeatureSalt
+		}
+		if v.BucketBy != nil {
+			f.bucketBy = *v.BucketBy
+		}
+		if f.layer == nil && v.LayerName != nil && v.LayerFrom != nil && v.LayerTo != nil {
+			f.layer = &dto.FeatureLayer{Name: *v.LayerName, From: *v.LayerFrom, To: *v.LayerTo}
+			if v.LayerSalt != nil {
+				f.layer.Salt = *v.LayerSalt
+			}
+		}
 		// Mark feature deleted only if the feature-level row itself is deleted
 		if v.KeyId == nil && v.ParamId == nil && v.DeletedAt != nil {
 			f.isDeleted = true
@@ -313,8 +630,11 @@ func (t *Repository) GetNewByServiceName(c context.Context, serviceName string,
 		feat := &dto.Feature{
 			Id:        fid,
 			Name:      fAgg.name,
+			Salt:      fAgg.salt,
+			BucketBy:  fAgg.bucketBy,
 			Value:     fAgg.value,
 			IsDeleted: fAgg.isDeleted,
+			Layer:     fAgg.layer,
 		}
 		if !fAgg.valueSet {
 			feat.Value = -1
@@ -340,17 +660,17 @@ func (t *Repository) GetNewByServiceName(c context.Context, serviceName string,
 		result = append(result, feat)
 	}
 
-	return cachedVersion, result, nil
+	return result
 }
 
-func (t *Repository) GetFeatures(c context.Context, serviceId string, page int, pageSize int, find string, isDeprecated bool, deprecatedTime time.Duration) ([]*dto.Feature, int, error) {
+func (t *Repository) GetFeatures(c context.Context, projectId uuid.UUID, serviceId string, page int, pageSize int, find string, isDeprecated bool, deprecatedTime time.Duration) ([]*dto.Feature, int, error) {
 	/* Full Query:
 
-	   SELECT fo.id, fo.name, fo.description, fo.created_at, fo.updated_at, ak.id, ak.key, ap.id, ap.name, av.value
+	   SELECT fo.id, fo.name, fo.description, fo.salt, fo.bucket_by, fo.created_at, fo.updated_at, ak.id, ak.key, ap.id, ap.name, av.value, av.v
 	   FROM
 	       activation_values av
 	       JOIN (
-	           SELECT f.id, f.name, f.description, f.created_at as created_at, av.updated_at as updated_at
+	           SELECT f.id, f.name, f.description, f.salt, f.bucket_by, f.created_at as created_at, av.updated_at as updated_at
 	           FROM features f
 	               JOIN (
 	                   SELECT av.feature_id as feature_id, MAX(av.updated_at) as updated_at
@@ -368,6 +688,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 	               ) sa ON sa.feature_id = f.id
 	           WHERE
 	               f.deleted_at IS NULL
+	   						AND f.project_id = '00000000-0000-0000-0000-000000000001'
 	   						AND (f.name ILIKE '%t%' OR f.description ILIKE '%t%')
 	   						AND av.updated_at < NOW() - '1 day'::interval
 	           ORDER BY f.created_at DESC
@@ -380,7 +701,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 	       av.deleted_at is null
 	*/
 
-	props := make([]any, 0)
+	props := []any{projectId}
 
 	joins := `JOIN (
         SELECT av.feature_id as feature_id, MAX(av.updated_at) as updated_at
@@ -393,7 +714,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 		 `
 
 	var where string
-	n := 1
+	n := 2
 
 	if serviceId != "" {
 		joins += `JOIN (
@@ -401,6 +722,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
         FROM service_access sa
         WHERE
             sa.service_id = $` + strconv.Itoa(n) + `
+            AND sa.deleted_at IS NULL
     ) sa ON sa.feature_id = f.id
 		 `
 
@@ -427,7 +749,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 
 	query := `FROM features f ` + joins
 
-	query += `WHERE f.deleted_at IS NULL `
+	query += `WHERE f.deleted_at IS NULL AND f.project_id = $1 `
 
 	if where != "" {
 		query += ` AND ` + where
@@ -449,7 +771,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 		return nil, 0, nil
 	}
 
-	query = `SELECT f.id, f.name, f.description, f.created_at as created_at, av.updated_at as updated_at ` + query + `
+	query = `SELECT f.id, f.name, f.description, f.salt, f.bucket_by, f.created_at as created_at, av.updated_at as updated_at ` + query + `
 	ORDER BY f.created_at DESC
 	OFFSET $` + strconv.Itoa(n) + `
 	LIMIT $` + strconv.Itoa(n+1) + `
@@ -457,7 +779,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 	n++
 	n++
 
-	query = `SELECT fo.id, fo.name, fo.description, fo.created_at, fo.updated_at, ak.id, ak.key, ap.id, ap.name, av.value
+	query = `SELECT fo.id, fo.name, fo.description, fo.salt, fo.bucket_by, fo.created_at, fo.updated_at, ak.id, ak.key, ap.id, ap.name, av.value, av.v
 	   FROM
 	       activation_values av
 	       JOIN (
@@ -486,7 +808,7 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 	for rows.Next() {
 		var f db.ActivationValuesFull
 
-		if err := rows.Scan(&f.FeatureId, &f.FeatureName, &f.FeatureDescription, &f.FeatureCreatedAt, &f.FeatureUpdatedAt, &f.KeyId, &f.KeyName, &f.ParamId, &f.ParamName, &f.Value); err != nil {
+		if err := rows.Scan(&f.FeatureId, &f.FeatureName, &f.FeatureDescription, &f.FeatureSalt, &f.FeatureBucketBy, &f.FeatureCreatedAt, &f.FeatureUpdatedAt, &f.KeyId, &f.KeyName, &f.ParamId, &f.ParamName, &f.Value, &f.V); err != nil {
 			t.logger.Error(c, err)
 			continue
 		}
@@ -515,7 +837,10 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 			Id:          f.FeatureId,
 			Name:        f.FeatureName,
 			Description: f.FeatureDescription,
+			Salt:        f.FeatureSalt,
+			BucketBy:    f.FeatureBucketBy,
 			Value:       f.Value,
+			Revision:    f.V,
 			CreatedAt:   f.FeatureCreatedAt,
 			UpdatedAt:   f.FeatureUpdatedAt,
 		}
@@ -525,9 +850,10 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 
 			for _, k := range keys {
 				key := dto.FeatureKey{
-					Id:    *k.KeyId,
-					Key:   *k.KeyName,
-					Value: k.Value,
+					Id:       *k.KeyId,
+					Key:      *k.KeyName,
+					Value:    k.Value,
+					Revision: k.V,
 				}
 
 				if params, ok := params[*k.KeyId]; ok {
@@ -535,9 +861,10 @@ func (t *Repository) GetFeatures(c context.Context, serviceId string, page int,
 
 					for _, p := range params {
 						key.Params = append(key.Params, dto.FeatureParam{
-							Id:    *p.ParamId,
-							Name:  *p.ParamName,
-							Value: p.Value,
+							Id:       *p.ParamId,
+							Name:     *p.ParamName,
+							Value:    p.Value,
+							Revision: p.V,
 						})
 					}
 				}
@@ -0,0 +1,17 @@
+package ChangeRequestRepository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	Create(c context.Context, cr *db.ChangeRequest) (uuid.UUID, error)
+	Get(c context.Context, id uuid.UUID) (*db.ChangeRequest, error)
+	List(c context.Context, projectId uuid.UUID, status string) ([]*db.ChangeRequest, error)
+
+	// Transition moves the request to status "to" only when it is currently in one of "from"
+	Transition(c context.Context, id uuid.UUID, from []string, to string, reviewedBy *string, comment *string) error
+}
@@ -0,0 +1,157 @@
+package ChangeRequestRepository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+var (
+	ErrNotFound     = errs.NotFound("change_request_not_found", "change request not found")
+	ErrInvalidState = errs.Conflict("invalid_state", "", "change request is not in a state allowing this action")
+)
+
+const selectColumns = `
+SELECT
+    id,
+    operation,
+    target_id,
+    feature_id,
+    base_version,
+    payload,
+    status,
+    proposed_by,
+    reviewed_by,
+    comment,
+    created_at,
+    updated_at
+FROM change_requests
+`
+
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+
+	return nil
+}
+
+func (t *Repository) Create(c context.Context, cr *db.ChangeRequest) (uuid.UUID, error) {
+	payload, err := json.Marshal(cr.Payload)
+	if err != nil {
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	id := uuid.New()
+	err = t.db.Exec(c, `
+INSERT INTO change_requests (id, operation, target_id, feature_id, base_version, payload, status, proposed_by, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`, id, cr.Operation, cr.TargetId, cr.FeatureId, cr.BaseVersion, payload, db.ChangeStatusPending, cr.ProposedBy, cr.Comment)
+	if err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	return id, nil
+}
+
+func (t *Repository) Get(c context.Context, id uuid.UUID) (*db.ChangeRequest, error) {
+	row, err := t.db.QueryRow(c, selectColumns+`WHERE id = $1`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	var item db.ChangeRequest
+	var payload []byte
+	if err := row.Scan(&item.Id, &item.Operation, &item.TargetId, &item.FeatureId, &item.BaseVersion, &payload, &item.Status, &item.ProposedBy, &item.ReviewedBy, &item.Comment, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		return nil, ErrNotFound
+	}
+
+	if err := json.Unmarshal(payload, &item.Payload); err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (t *Repository) List(c context.Context, projectId uuid.UUID, status string) ([]*db.ChangeRequest, error) {
+	rows, err := t.db.Query(c, selectColumns+`
+WHERE ($1 = '' OR status = $1)
+  AND feature_id IN (SELECT id FROM features WHERE project_id = $2)
+ORDER BY created_at DESC
+`, status, projectId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	res := make([]*db.ChangeRequest, 0)
+
+	for rows.Next() {
+		var item db.ChangeRequest
+		var payload []byte
+		if err := rows.Scan(&item.Id, &item.Operation, &item.TargetId, &item.FeatureId, &item.BaseVersion, &payload, &item.Status, &item.ProposedBy, &item.ReviewedBy, &item.Comment, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			t.logger.Error(c, err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal(payload, &item.Payload); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+
+		res = append(res, &item)
+	}
+
+	return res, nil
+}
+
+func (t *Repository) Transition(c context.Context, id uuid.UUID, from []string, to string, reviewedBy *string, comment *string) error {
+	row, err := t.db.QueryRow(c, `
+UPDATE change_requests
+SET status = $3,
+    reviewed_by = COALESCE($4, reviewed_by),
+    comment = COALESCE($5, comment),
+    updated_at = NOW()
+WHERE id = $1 AND status = ANY($2)
+RETURNING id
+`, id, from, to, reviewedBy, comment)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	var updatedId uuid.UUID
+	if err := row.Scan(&updatedId); err != nil {
+		// Distinguish a missing request from one in the wrong state
+		if _, getErr := t.Get(c, id); getErr != nil {
+			return getErr
+		}
+		return ErrInvalidState
+	}
+
+	return nil
+}
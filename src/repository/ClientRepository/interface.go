@@ -0,0 +1,18 @@
+package ClientRepository
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+// Interface is the client registry shared by every server instance
+type Interface interface {
+	// Save upserts the instances, VersionAt is kept while the stored version does not change
+	Save(c context.Context, clients []*db.Client) error
+	// ListByService returns the instances of the service seen after since, newest first
+	ListByService(c context.Context, serviceName string, since time.Time) ([]*db.Client, error)
+	// Prune removes instances not seen since before
+	Prune(c context.Context, before time.Time) error
+}
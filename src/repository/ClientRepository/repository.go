@@ -0,0 +1,138 @@
+package ClientRepository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+const columns = 12
+
+// Rows per upsert, keeps a single statement well below the postgres bind limit
+const saveBatch = 1000
+
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+
+	return nil
+}
+
+func (t *Repository) Save(c context.Context, clients []*db.Client) error {
+	for len(clients) > 0 {
+		n := min(len(clients), saveBatch)
+		if err := t.save(c, clients[:n]); err != nil {
+			return err
+		}
+		clients = clients[n:]
+	}
+
+	return nil
+}
+
+func (t *Repository) save(c context.Context, clients []*db.Client) error {
+	placeholders := make([]string, 0, len(clients))
+	args := make([]any, 0, len(clients)*columns)
+	for i, cl := range clients {
+		labels, err := json.Marshal(cl.Labels)
+		if err != nil || cl.Labels == nil {
+			labels = []byte("{}")
+		}
+
+		p := make([]string, columns)
+		for j := range p {
+			p[j] = "$" + strconv.Itoa(i*columns+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(p, ",")+")")
+		args = append(args, cl.ServiceName, cl.InstanceId, cl.Hostname, cl.SdkName, cl.SdkVersion, labels, cl.Transport,
+			cl.Version, cl.VersionAt, cl.Streaming, cl.ConnectedAt, cl.SeenAt)
+	}
+
+	err := t.db.Exec(c, `
+INSERT INTO clients (service_name, instance_id, hostname, sdk_name, sdk_version, labels, transport, version, version_at, streaming, connected_at, seen_at)
+VALUES `+strings.Join(placeholders, ",")+`
+ON CONFLICT (service_name, instance_id) DO UPDATE SET
+    hostname = EXCLUDED.hostname,
+    sdk_name = EXCLUDED.sdk_name,
+    sdk_version = EXCLUDED.sdk_version,
+    labels = EXCLUDED.labels,
+    transport = EXCLUDED.transport,
+    version = EXCLUDED.version,
+    version_at = CASE WHEN clients.version = EXCLUDED.version THEN clients.version_at ELSE EXCLUDED.version_at END,
+    streaming = EXCLUDED.streaming,
+    connected_at = EXCLUDED.connected_at,
+    seen_at = GREATEST(clients.seen_at, EXCLUDED.seen_at)
+`, args...)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	return nil
+}
+
+func (t *Repository) ListByService(c context.Context, serviceName string, since time.Time) ([]*db.Client, error) {
+	rows, err := t.db.Query(c, `
+SELECT service_name, instance_id, hostname, sdk_name, sdk_version, labels, transport, version, version_at, streaming, connected_at, seen_at
+FROM clients
+WHERE service_name = $1 AND seen_at > $2
+ORDER BY seen_at DESC
+`, serviceName, since)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	res := make([]*db.Client, 0)
+
+	for rows.Next() {
+		var item db.Client
+		var labels []byte
+		if err := rows.Scan(&item.ServiceName, &item.InstanceId, &item.Hostname, &item.SdkName, &item.SdkVersion, &labels, &item.Transport,
+			&item.Version, &item.VersionAt, &item.Streaming, &item.ConnectedAt, &item.SeenAt); err != nil {
+			t.logger.Error(c, err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal(labels, &item.Labels); err != nil {
+			t.logger.Error(c, err)
+		}
+
+		res = append(res, &item)
+	}
+
+	return res, nil
+}
+
+func (t *Repository) Prune(c context.Context, before time.Time) error {
+	err := t.db.Exec(c, `DELETE FROM clients WHERE seen_at < $1`, before)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	return nil
+}
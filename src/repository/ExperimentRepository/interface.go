@@ -0,0 +1,15 @@
+package ExperimentRepository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	InsertExposures(c context.Context, events []*db.ExperimentExposure) error
+	InsertMetrics(c context.Context, events []*db.ExperimentMetric) error
+
+	GetVariantStats(c context.Context, featureId uuid.UUID, metric string) ([]*db.ExperimentVariantStats, error)
+}
@@ -0,0 +1,131 @@
+package ExperimentRepository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+
+	return nil
+}
+
+// InsertExposures stores the events against the feature their project and name resolve to,
+// events of unknown features are dropped since no experiment could ever read them
+func (t *Repository) InsertExposures(c context.Context, events []*db.ExperimentExposure) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*6)
+	for i, e := range events {
+		n := i * 6
+		placeholders = append(placeholders, "($"+strconv.Itoa(n+1)+"::uuid,$"+strconv.Itoa(n+2)+"::text,$"+strconv.Itoa(n+3)+"::text,$"+strconv.Itoa(n+4)+"::text,$"+strconv.Itoa(n+5)+"::text,$"+strconv.Itoa(n+6)+"::timestamp)")
+		args = append(args, uuid.New(), e.Project, e.FeatureName, e.Variant, e.UnitId, e.ExposedAt)
+	}
+
+	err := t.db.Exec(c, `
+INSERT INTO experiment_exposures (id, feature_id, feature_name, variant, unit_id, exposed_at)
+SELECT v.id, f.id, v.feature_name, v.variant, v.unit_id, v.exposed_at
+FROM (VALUES `+strings.Join(placeholders, ",")+`) AS v(id, project, feature_name, variant, unit_id, exposed_at)
+JOIN projects p ON p.name = v.project
+JOIN features f ON f.project_id = p.id AND f.name = v.feature_name AND f.deleted_at IS NULL`, args...)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	return nil
+}
+
+func (t *Repository) InsertMetrics(c context.Context, events []*db.ExperimentMetric) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(events))
+	args := make([]any, 0, len(events)*5)
+	for i, e := range events {
+		n := i * 5
+		placeholders = append(placeholders, "($"+strconv.Itoa(n+1)+",$"+strconv.Itoa(n+2)+",$"+strconv.Itoa(n+3)+",$"+strconv.Itoa(n+4)+",$"+strconv.Itoa(n+5)+")")
+		args = append(args, uuid.New(), e.UnitId, e.Metric, e.Value, e.CreatedAt)
+	}
+
+	err := t.db.Exec(c, `INSERT INTO experiment_metrics (id, unit_id, metric, value, created_at) VALUES `+strings.Join(placeholders, ","), args...)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	return nil
+}
+
+// GetVariantStats joins every unit's first exposure with the metric events it produced afterwards.
+// A unit converts when it has at least one event, its value is the sum of its event values.
+func (t *Repository) GetVariantStats(c context.Context, featureId uuid.UUID, metric string) ([]*db.ExperimentVariantStats, error) {
+	rows, err := t.db.Query(c, `
+WITH exposed AS (
+    SELECT DISTINCT ON (unit_id) unit_id, variant, exposed_at
+    FROM experiment_exposures
+    WHERE feature_id = $1
+    ORDER BY unit_id, exposed_at
+), per_unit AS (
+    SELECT e.variant, e.unit_id, COALESCE(SUM(m.value), 0) AS value, COUNT(m.id) > 0 AS converted
+    FROM exposed e
+    LEFT JOIN experiment_metrics m ON m.unit_id = e.unit_id
+        AND m.metric = $2
+        AND m.created_at >= e.exposed_at
+    GROUP BY e.variant, e.unit_id
+)
+SELECT variant,
+       COUNT(*),
+       COUNT(*) FILTER (WHERE converted),
+       COALESCE(SUM(value), 0),
+       COALESCE(SUM(value * value), 0)
+FROM per_unit
+GROUP BY variant
+ORDER BY variant
+`, featureId, metric)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*db.ExperimentVariantStats, 0)
+	for rows.Next() {
+		s := &db.ExperimentVariantStats{}
+		if err := rows.Scan(&s.Variant, &s.Units, &s.Conversions, &s.Sum, &s.SumSquares); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		out = append(out, s)
+	}
+
+	return out, nil
+}
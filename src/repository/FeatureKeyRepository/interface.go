@@ -12,8 +12,9 @@ type Interface interface {
 	ListAllKeys(c context.Context) map[uuid.UUID][]*db.FeatureKey
 	ListKeys(c context.Context, featureId uuid.UUID) []*db.FeatureKey
 	CreateKey(c context.Context, featureId uuid.UUID, key string, description string, value int) (uuid.UUID, error)
-	UpdateKey(c context.Context, featureId uuid.UUID, keyId uuid.UUID, key string, description string, value int) error
-	DeleteKey(c context.Context, keyId uuid.UUID) error
+	UpdateKey(c context.Context, keyId uuid.UUID, key string, description string, value int, revision int64) error
+	SetValue(c context.Context, keyId uuid.UUID, value int, revision int64) (int64, error)
+	DeleteKey(c context.Context, keyId uuid.UUID, revision int64) error
 
 	DeleteAllByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error
 }
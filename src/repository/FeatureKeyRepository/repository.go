@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
@@ -13,6 +14,8 @@ import (
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
+var ErrKeyNotFound = errs.NotFound("key_not_found", "key not found")
+
 type Repository struct {
 	repository.Mock
 	logger                     interfaces.ILogger
@@ -109,7 +112,7 @@ func (t *Repository) CreateKey(c context.Context, featureId uuid.UUID, key strin
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
@@ -124,7 +127,7 @@ RETURNING id
 
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	var id uuid.UUID
@@ -138,79 +141,164 @@ RETURNING id
 `, newId, featureId, key, description)
 		if err != nil {
 			t.logger.Error(c, err)
-			return uuid.Nil, err
+			return uuid.Nil, errs.FromDB(err)
 		}
 
 		if scanErr2 := row.Scan(&id); scanErr2 != nil {
-			return uuid.Nil, scanErr2
+			return uuid.Nil, errs.FromDB(scanErr2)
 		}
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &id, nil, value); err != nil {
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &id, nil, value)
+	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return id, nil
 }
 
-func (t *Repository) UpdateKey(c context.Context, featureId uuid.UUID, keyId uuid.UUID, key string, description string, value int) error {
+// UpdateKey takes the feature from the locked key row, a caller cannot move the key's value to another feature
+func (t *Repository) UpdateKey(c context.Context, keyId uuid.UUID, key string, description string, value int, revision int64) error {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
+	// Clients know the key by name within its feature, remember the old one to tombstone it
+	var featureId uuid.UUID
+	var featureName, oldKey string
+	row, err := tx.QueryRow(c, `
+SELECT k.feature_id, f.name, k.key
+FROM activation_keys k
+JOIN features f ON f.id = k.feature_id
+WHERE k.id = $1 AND k.deleted_at IS NULL
+FOR UPDATE OF k
+`, keyId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	if err := row.Scan(&featureId, &featureName, &oldKey); err != nil {
+		return ErrKeyNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, keyId, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
 	err = tx.Exec(c, `UPDATE activation_keys SET key = $2, description = CASE WHEN $3 = '' THEN description ELSE $3 END WHERE id = $1 AND deleted_at IS NULL`, keyId, key, description)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, nil, value)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if oldKey != key {
+		rename := db.Rename{Kind: db.RenameKey, FeatureId: featureId, KeyId: &keyId, FeatureName: featureName, KeyName: oldKey}
+		if err := t.activationValuesRepository.RecordRename(c, tx, v, rename); err != nil {
+			t.logger.Error(c, err)
+			return errs.FromDB(err)
+		}
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, nil, value); err != nil {
+	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
-	return tx.Commit(c)
+	return nil
 }
 
-func (t *Repository) DeleteKey(c context.Context, keyId uuid.UUID) error {
+// SetValue changes only the key value and returns the version subscribers will see it in
+func (t *Repository) SetValue(c context.Context, keyId uuid.UUID, value int, revision int64) (int64, error) {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return 0, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
+	var featureId uuid.UUID
+	row, err := tx.QueryRow(c, `SELECT feature_id FROM activation_keys WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, keyId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	if err := row.Scan(&featureId); err != nil {
+		return 0, ErrKeyNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, keyId, revision); err != nil {
+		return 0, errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, nil, value)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return v, nil
+}
+
+func (t *Repository) DeleteKey(c context.Context, keyId uuid.UUID, revision int64) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, keyId, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
 	err = tx.Exec(c, `UPDATE activation_keys SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, keyId)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
-	if err := t.activationValuesRepository.DeleteByKeyId(c, tx, keyId); err != nil {
+	v, err := t.activationValuesRepository.DeleteByKeyId(c, tx, keyId)
+	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
 	if err := t.featureParamRepository.DeleteAllByKeyId(c, tx, keyId); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return nil
 }
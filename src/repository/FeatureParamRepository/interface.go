@@ -12,8 +12,9 @@ type Interface interface {
 	ListAllParams(c context.Context) map[uuid.UUID][]*db.FeatureParam
 	ListParams(c context.Context, keyId uuid.UUID) []*db.FeatureParam
 	CreateParam(c context.Context, featureId uuid.UUID, keyId uuid.UUID, name string, value int) (uuid.UUID, error)
-	UpdateParam(c context.Context, featureId uuid.UUID, keyId uuid.UUID, paramId uuid.UUID, name string, value int) error
-	DeleteParam(c context.Context, paramId uuid.UUID) error
+	UpdateParam(c context.Context, paramId uuid.UUID, name string, value int, revision int64) error
+	SetValue(c context.Context, paramId uuid.UUID, value int, revision int64) (int64, error)
+	DeleteParam(c context.Context, paramId uuid.UUID, revision int64) error
 
 	DeleteAllByKeyId(c context.Context, tx postgres.SQLTx, keyId uuid.UUID) error
 	DeleteAllByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error
@@ -6,12 +6,15 @@ import (
 	"github.com/google/uuid"
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
+var ErrParamNotFound = errs.NotFound("param_not_found", "param not found")
+
 type Repository struct {
 	repository.Mock
 	logger                     interfaces.ILogger
@@ -97,7 +100,7 @@ func (t *Repository) CreateParam(c context.Context, featureId uuid.UUID, keyId u
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
@@ -111,7 +114,7 @@ RETURNING id
 `, keyId, name)
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	var id uuid.UUID
@@ -125,72 +128,158 @@ RETURNING id
 `, newId, featureId, keyId, name)
 		if err != nil {
 			t.logger.Error(c, err)
-			return uuid.Nil, err
+			return uuid.Nil, errs.FromDB(err)
 		}
 		if scanErr2 := row.Scan(&id); scanErr2 != nil {
-			return uuid.Nil, scanErr2
+			return uuid.Nil, errs.FromDB(scanErr2)
 		}
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, &id, value); err != nil {
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, &id, value)
+	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return id, nil
 }
 
-func (t *Repository) UpdateParam(c context.Context, featureId uuid.UUID, keyId uuid.UUID, paramId uuid.UUID, name string, value int) error {
+// UpdateParam takes the feature and key from the locked param row, a caller cannot move the param's value elsewhere
+func (t *Repository) UpdateParam(c context.Context, paramId uuid.UUID, name string, value int, revision int64) error {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
+	// Clients know the param by name within its key, remember the old one to tombstone it
+	var featureId, keyId uuid.UUID
+	var featureName, keyName, oldName string
+	row, err := tx.QueryRow(c, `
+SELECT p.feature_id, p.activation_id, f.name, k.key, p.name
+FROM activation_params p
+JOIN activation_keys k ON k.id = p.activation_id
+JOIN features f ON f.id = p.feature_id
+WHERE p.id = $1 AND p.deleted_at IS NULL
+FOR UPDATE OF p
+`, paramId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	if err := row.Scan(&featureId, &keyId, &featureName, &keyName, &oldName); err != nil {
+		return ErrParamNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, paramId, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
 	err = tx.Exec(c, `UPDATE activation_params SET name = $2 WHERE id = $1 AND deleted_at IS NULL`, paramId, name)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, &paramId, value)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if oldName != name {
+		rename := db.Rename{Kind: db.RenameParam, FeatureId: featureId, KeyId: &keyId, ParamId: &paramId, FeatureName: featureName, KeyName: keyName, ParamName: oldName}
+		if err := t.activationValuesRepository.RecordRename(c, tx, v, rename); err != nil {
+			t.logger.Error(c, err)
+			return errs.FromDB(err)
+		}
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, &paramId, value); err != nil {
+	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
-	return tx.Commit(c)
+	return nil
 }
 
-func (t *Repository) DeleteParam(c context.Context, paramId uuid.UUID) error {
+// SetValue changes only the param value and returns the version subscribers will see it in
+func (t *Repository) SetValue(c context.Context, paramId uuid.UUID, value int, revision int64) (int64, error) {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return 0, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
+	var featureId, keyId uuid.UUID
+	row, err := tx.QueryRow(c, `SELECT feature_id, activation_id FROM activation_params WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, paramId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	if err := row.Scan(&featureId, &keyId); err != nil {
+		return 0, ErrParamNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, paramId, revision); err != nil {
+		return 0, errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, featureId, &keyId, &paramId, value)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return v, nil
+}
+
+func (t *Repository) DeleteParam(c context.Context, paramId uuid.UUID, revision int64) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, paramId, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
 	err = tx.Exec(c, `UPDATE activation_params SET deleted_at = NOW() WHERE id = $1`, paramId)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
-	if err := t.activationValuesRepository.DeleteByParamId(c, tx, paramId); err != nil {
+	v, err := t.activationValuesRepository.DeleteByParamId(c, tx, paramId)
+	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return nil
 }
@@ -11,7 +11,8 @@ type Interface interface {
 	GetFeatureName(c context.Context, id uuid.UUID) (string, error)
 	ListFeatures(c context.Context) []*db.Feature
 
-	CreateFeature(c context.Context, name string, description string, value int) (uuid.UUID, error)
-	UpdateFeature(c context.Context, id uuid.UUID, name string, description string, value int) error
-	DeleteFeature(c context.Context, id uuid.UUID) error
+	CreateFeature(c context.Context, projectId uuid.UUID, name string, description string, salt string, bucketBy string, value int) (uuid.UUID, error)
+	UpdateFeature(c context.Context, id uuid.UUID, name string, description string, salt *string, bucketBy *string, value int, revision int64) error
+	SetValue(c context.Context, id uuid.UUID, value int, revision int64) (int64, error)
+	DeleteFeature(c context.Context, id uuid.UUID, revision int64) error
 }
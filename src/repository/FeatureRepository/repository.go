@@ -6,14 +6,18 @@ import (
 	"github.com/google/uuid"
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureKeyRepository"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
+var ErrFeatureNotFound = errs.NotFound("feature_not_found", "feature not found")
+
 type Repository struct {
 	repository.Mock
 	db                         postgres.IPostgres
@@ -21,6 +25,7 @@ type Repository struct {
 	activationValuesRepository ActivationValuesRepository.Interface
 	featureParamRepository     FeatureParamRepository.Interface
 	featureKeyRepository       FeatureKeyRepository.Interface
+	layerRepository            LayerRepository.Interface
 }
 
 func New(name string) *Repository {
@@ -31,20 +36,35 @@ func New(name string) *Repository {
 	}
 }
 
+func NewForTest(db postgres.IPostgres, activationValuesRepository ActivationValuesRepository.Interface, featureParamRepository FeatureParamRepository.Interface, featureKeyRepository FeatureKeyRepository.Interface, layerRepository LayerRepository.Interface, logger interfaces.ILogger) *Repository {
+	return &Repository{
+		db:                         db,
+		logger:                     logger,
+		activationValuesRepository: activationValuesRepository,
+		featureParamRepository:     featureParamRepository,
+		featureKeyRepository:       featureKeyRepository,
+		layerRepository:            layerRepository,
+	}
+}
+
 func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
 	t.logger = app.GetLogger()
 	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
 	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
 	t.featureParamRepository = app.GetModule(interfaces.ModuleRepository, names.FeatureParamRepository).(FeatureParamRepository.Interface)
 	t.featureKeyRepository = app.GetModule(interfaces.ModuleRepository, names.FeatureKeyRepository).(FeatureKeyRepository.Interface)
+	t.layerRepository = app.GetModule(interfaces.ModuleRepository, names.LayerRepository).(LayerRepository.Interface)
 
 	return nil
 }
 
+// GetFeatureName returns ErrFeatureNotFound only for a missing feature, the aggregate always yields a row so a
+// failing read stays an error of its own
 func (t *Repository) GetFeatureName(c context.Context, id uuid.UUID) (string, error) {
 	row, err := t.db.QueryRow(c, `
 SELECT
-    f.name
+    COUNT(*),
+    COALESCE(MAX(f.name), '')
 FROM features AS f
 WHERE f.id = $1
   AND f.deleted_at IS NULL
@@ -52,13 +72,17 @@ WHERE f.id = $1
 
 	if err != nil {
 		t.logger.Error(c, err)
-		return "", err
+		return "", errs.FromDB(err)
 	}
 
+	var found int
 	var name string
-	if err := row.Scan(&name); err != nil {
+	if err := row.Scan(&found, &name); err != nil {
 		t.logger.Error(c, err)
-		return "", err
+		return "", errs.FromDB(err)
+	}
+	if found == 0 {
+		return "", ErrFeatureNotFound
 	}
 
 	return name, nil
@@ -70,6 +94,8 @@ SELECT
     f.id,
     f.name,
     f.description,
+    f.salt,
+    f.bucket_by,
     av.value AS value,
     av.v     AS v
 FROM features AS f
@@ -87,7 +113,7 @@ WHERE av.activation_key_id IS NULL
 
 	for rows.Next() {
 		var item db.Feature
-		if err := rows.Scan(&item.Id, &item.Name, &item.Description, &item.Value, &item.Version); err != nil {
+		if err := rows.Scan(&item.Id, &item.Name, &item.Description, &item.Salt, &item.BucketBy, &item.Value, &item.Version); err != nil {
 			t.logger.Error(c, err)
 			continue
 		}
@@ -97,126 +123,256 @@ WHERE av.activation_key_id IS NULL
 	return res
 }
 
-func (t *Repository) CreateFeature(c context.Context, name string, description string, value int) (uuid.UUID, error) {
+func (t *Repository) CreateFeature(c context.Context, projectId uuid.UUID, name string, description string, salt string, bucketBy string, value int) (uuid.UUID, error) {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
-	// Try to restore an existing soft-deleted feature first
+	// Try to restore an existing soft-deleted feature first, its salt survives unless overridden
 	row, err := tx.QueryRow(c, `
 UPDATE features
-SET description = $2, deleted_at = NULL, created_at = NOW()
-WHERE name = $1 AND deleted_at IS NOT NULL
+SET description = $2,
+    salt = CASE WHEN $3 = '' THEN salt ELSE $3 END,
+    bucket_by = $4,
+    deleted_at = NULL,
+    created_at = NOW()
+WHERE project_id = $5 AND name = $1 AND deleted_at IS NOT NULL
 RETURNING id
-`, name, description)
+`, name, description, salt, bucketBy, projectId)
 
 	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	var id uuid.UUID
 	if scanErr := row.Scan(&id); scanErr != nil {
 		// No soft-deleted row restored; insert a new one
 		newId := uuid.New()
+		if salt == "" {
+			salt = name
+		}
 		row, err = tx.QueryRow(c, `
-INSERT INTO features (id, name, description)
-VALUES ($1, $2, $3)
+INSERT INTO features (id, project_id, name, description, salt, bucket_by)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING id
-`, newId, name, description)
+`, newId, projectId, name, description, salt, bucketBy)
 		if err != nil {
 			t.logger.Error(c, err)
-			return uuid.Nil, err
+			return uuid.Nil, errs.FromDB(err)
 		}
 		if scanErr2 := row.Scan(&id); scanErr2 != nil {
-			return uuid.Nil, scanErr2
+			return uuid.Nil, errs.FromDB(scanErr2)
 		}
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, id, nil, nil, value); err != nil {
+	v, err := t.activationValuesRepository.InsertValue(c, tx, id, nil, nil, value)
+	if err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return id, nil
 }
 
-func (t *Repository) UpdateFeature(c context.Context, id uuid.UUID, name string, description string, value int) error {
+// UpdateFeature keeps the salt across renames so cohorts stay stable; nil salt or bucketBy leave the stored value
+func (t *Repository) UpdateFeature(c context.Context, id uuid.UUID, name string, description string, salt *string, bucketBy *string, value int, revision int64) error {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
-	err = tx.Exec(c, `UPDATE features SET name = $2, description = $3 WHERE id = $1 AND deleted_at IS NULL`, id, name, description)
+	// Clients know the feature by name, remember the old one to tombstone it
+	var oldName string
+	row, err := tx.QueryRow(c, `SELECT name FROM features WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+	if err := row.Scan(&oldName); err != nil {
+		return ErrFeatureNotFound
 	}
 
-	if _, err := t.activationValuesRepository.InsertValue(c, tx, id, nil, nil, value); err != nil {
+	if err := t.activationValuesRepository.CheckRevision(c, tx, id, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
+	err = tx.Exec(c, `
+UPDATE features
+SET name = $2,
+    description = $3,
+    salt = COALESCE(NULLIF($4, ''), salt),
+    bucket_by = COALESCE($5, bucket_by)
+WHERE id = $1 AND deleted_at IS NULL
+`, id, name, description, salt, bucketBy)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, id, nil, nil, value)
+	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+
+	if oldName != name {
+		if err := t.activationValuesRepository.RecordRename(c, tx, v, db.Rename{Kind: db.RenameFeature, FeatureId: id, FeatureName: oldName}); err != nil {
+			t.logger.Error(c, err)
+			return errs.FromDB(err)
+		}
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
 
 	return nil
 }
 
-func (t *Repository) DeleteFeature(c context.Context, id uuid.UUID) error {
+// SetValue changes only the feature-level value and returns the version subscribers will see it in
+func (t *Repository) SetValue(c context.Context, id uuid.UUID, value int, revision int64) (int64, error) {
 	tx, err := t.db.BeginTx(c)
 	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return 0, errs.FromDB(err)
 	}
 
 	defer tx.Rollback(c)
 
+	row, err := tx.QueryRow(c, `SELECT id FROM features WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	if err := row.Scan(&id); err != nil {
+		return 0, ErrFeatureNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, id, revision); err != nil {
+		return 0, errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.InsertValue(c, tx, id, nil, nil, value)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return 0, errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return v, nil
+}
+
+func (t *Repository) DeleteFeature(c context.Context, id uuid.UUID, revision int64) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	// A feature deleted before must not take new versions for its tombstones
+	row, err := tx.QueryRow(c, `SELECT id FROM features WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	if err := row.Scan(&id); err != nil {
+		return ErrFeatureNotFound
+	}
+
+	if err := t.activationValuesRepository.CheckRevision(c, tx, id, revision); err != nil {
+		return errs.FromDB(err)
+	}
+
 	err = tx.Exec(c, `UPDATE features SET deleted_at = NOW() WHERE id = $1`, id)
 	if err != nil {
 		t.logger.Error(c, err)
+		return errs.FromDB(err)
 	}
 
-	// Remove all service bindings for the feature
-	if err := tx.Exec(c, `DELETE FROM service_access WHERE feature_id = $1`, id); err != nil {
+	// Tombstone values before dropping bindings so the change event still knows the feature's services
+	valuesVersion, err := t.activationValuesRepository.DeleteByFeatureId(c, tx, id)
+	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
 
-	if err := t.activationValuesRepository.DeleteByFeatureId(c, tx, id); err != nil {
+	// Unbind all services in their own version, connected clients receive the feature tombstone from it
+	v, err := t.activationValuesRepository.AllocateVersion(c, tx)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	row, err = tx.QueryRow(c, `
+WITH unbound AS (
+    UPDATE service_access SET deleted_at = NOW(), v = $2 WHERE feature_id = $1 AND deleted_at IS NULL RETURNING 1
+)
+SELECT COUNT(*) FROM unbound
+`, id, v)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	var unbound int
+	if err := row.Scan(&unbound); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+	if unbound == 0 {
+		// No row carries the binding version, subscribers only need the value tombstones
+		v = valuesVersion
 	}
 
 	if err := t.featureParamRepository.DeleteAllByFeatureId(c, tx, id); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+
+	// Renames reference the keys dropped below; the feature is unbound, its old names reach no subscriber anymore
+	if err := tx.Exec(c, `DELETE FROM renames WHERE feature_id = $1`, id); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
 	}
 
 	if err := t.featureKeyRepository.DeleteAllByFeatureId(c, tx, id); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+
+	// Free the bucket range the experiment owned in its layer
+	if err := t.layerRepository.DeleteAllByFeatureId(c, tx, id); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
 	}
 
 	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	// The binding version is the newer one, publishing it covers the value tombstones as well
+	t.activationValuesRepository.Publish(c, v)
 
 	return nil
 }
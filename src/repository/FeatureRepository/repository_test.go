@@ -0,0 +1,169 @@
+package FeatureRepository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureKeyRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+// renamedKeys drops keys like Postgres does: a rename still pointing at one violates its foreign key
+type renamedKeys struct {
+	FeatureKeyRepository.Interface
+	renames *int
+}
+
+func (t *renamedKeys) DeleteAllByFeatureId(context.Context, postgres.SQLTx, uuid.UUID) error {
+	if *t.renames > 0 {
+		return errors.New(`update or delete on table "activation_keys" violates foreign key constraint "renames_key_id_fkey"`)
+	}
+	return nil
+}
+
+type noParams struct {
+	FeatureParamRepository.Interface
+}
+
+func (t *noParams) DeleteAllByFeatureId(context.Context, postgres.SQLTx, uuid.UUID) error { return nil }
+
+type noLayers struct {
+	LayerRepository.Interface
+}
+
+func (t *noLayers) DeleteAllByFeatureId(context.Context, postgres.SQLTx, uuid.UUID) error { return nil }
+
+func TestRepository_DeleteRenamedFeature(t *testing.T) {
+	featureId, keyId := uuid.New(), uuid.New()
+
+	renames := 0
+	pg := &postgres.Mock{
+		QueryRowFunc: func(_ context.Context, query string, _ ...any) (postgres.SQLRow, error) {
+			if strings.Contains(query, "FROM features") {
+				return &postgres.MockRow{Values: []any{featureId.String()}}, nil
+			}
+			return &postgres.MockRow{Values: []any{int64(7)}}, nil
+		},
+		ExecFunc: func(_ context.Context, query string, _ ...any) error {
+			switch {
+			case strings.Contains(query, "INSERT INTO renames"):
+				renames++
+			case strings.Contains(query, "DELETE FROM renames"):
+				renames = 0
+			}
+			return nil
+		},
+	}
+	versions := VersionRepository.NewForTest(pg, &redis.Mock{Data: map[string]string{}}, mock_log.New(true))
+	values := ActivationValuesRepository.NewForTest(pg, nil, nil, versions, mock_log.New(true))
+	r := NewForTest(pg, values, &noParams{}, &renamedKeys{renames: &renames}, &noLayers{}, mock_log.New(true))
+
+	tx, _ := pg.BeginTx(context.Background())
+	rename := db.Rename{Kind: db.RenameKey, FeatureId: featureId, KeyId: &keyId, FeatureName: "checkout", KeyName: "country"}
+	if err := values.RecordRename(context.Background(), tx, 6, rename); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.DeleteFeature(context.Background(), featureId, 0); err != nil {
+		t.Fatalf("a feature with a renamed key must be deletable, got %v", err)
+	}
+}
+
+func TestRepository_DeleteFeatureOnce(t *testing.T) {
+	failed := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		deleted   bool
+		updateErr error
+		err       error
+	}{
+		{name: "live"},
+		{name: "already deleted", deleted: true, err: ErrFeatureNotFound},
+		{name: "update failed", updateErr: failed, err: failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			featureId := uuid.New()
+			allocated := 0
+			pg := &postgres.Mock{
+				QueryRowFunc: func(_ context.Context, query string, _ ...any) (postgres.SQLRow, error) {
+					switch {
+					case strings.Contains(query, "FROM features"):
+						if tt.deleted {
+							return &postgres.MockRow{}, nil
+						}
+						return &postgres.MockRow{Values: []any{featureId.String()}}, nil
+					case strings.Contains(query, "nextval"):
+						allocated++
+					}
+					return &postgres.MockRow{Values: []any{int64(7)}}, nil
+				},
+				ExecFunc: func(_ context.Context, query string, _ ...any) error {
+					if strings.Contains(query, "UPDATE features SET deleted_at") {
+						return tt.updateErr
+					}
+					return nil
+				},
+			}
+			versions := VersionRepository.NewForTest(pg, &redis.Mock{Data: map[string]string{}}, mock_log.New(true))
+			values := ActivationValuesRepository.NewForTest(pg, nil, nil, versions, mock_log.New(true))
+			renames := 0
+			r := NewForTest(pg, values, &noParams{}, &renamedKeys{renames: &renames}, &noLayers{}, mock_log.New(true))
+
+			err := r.DeleteFeature(context.Background(), featureId, 0)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if tt.err != nil && allocated > 0 {
+				t.Errorf("a failed deletion must not take new versions, took %d", allocated)
+			}
+		})
+	}
+}
+
+func TestRepository_GetFeatureName(t *testing.T) {
+	failed := errors.New("connection reset")
+
+	tests := []struct {
+		name   string
+		values []any
+		failed error
+		want   string
+		err    error
+	}{
+		{name: "live", values: []any{1, "checkout"}, want: "checkout"},
+		{name: "missing", values: []any{0, ""}, err: ErrFeatureNotFound},
+		{name: "read failed", failed: failed, err: failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := &postgres.Mock{
+				QueryRowFunc: func(context.Context, string, ...any) (postgres.SQLRow, error) {
+					if tt.failed != nil {
+						return nil, tt.failed
+					}
+					return &postgres.MockRow{Values: tt.values}, nil
+				},
+			}
+			r := NewForTest(pg, nil, nil, nil, nil, mock_log.New(true))
+
+			name, err := r.GetFeatureName(context.Background(), uuid.New())
+			if name != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("expected %q %v, got %q %v", tt.want, tt.err, name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package HealthRepository
+
+import "context"
+
+// Interface probes the storage the server depends on
+type Interface interface {
+	// Ping checks that Postgres answers queries
+	Ping(c context.Context) error
+	// PingCache checks that Redis accepts writes, nil without Redis
+	PingCache(c context.Context) error
+	// HasCache reports whether Redis is configured
+	HasCache() bool
+	// MigrationVersion returns the newest migration goose has applied
+	MigrationVersion(c context.Context) (int64, error)
+}
@@ -0,0 +1,79 @@
+package HealthRepository
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+const (
+	keyProbe = "health_probe"
+	probeTTL = time.Minute
+)
+
+type Repository struct {
+	repository.Mock
+	db    postgres.IPostgres
+	cache redis.IRedis
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewForTest(db postgres.IPostgres, cache redis.IRedis) *Repository {
+	return &Repository{db: db, cache: cache}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	// Redis is optional, without it the version lives in Postgres
+	t.cache, _ = app.GetPkg(interfaces.PkgCache, names.CacheRedis).(redis.IRedis)
+
+	return nil
+}
+
+func (t *Repository) Ping(c context.Context) error {
+	row, err := t.db.QueryRow(c, `SELECT 1`)
+	if err != nil {
+		return err
+	}
+
+	var one int
+	return row.Scan(&one)
+}
+
+func (t *Repository) PingCache(c context.Context) error {
+	if t.cache == nil {
+		return nil
+	}
+
+	return t.cache.Set(c, keyProbe, time.Now().Unix(), probeTTL)
+}
+
+func (t *Repository) HasCache() bool {
+	return t.cache != nil
+}
+
+func (t *Repository) MigrationVersion(c context.Context) (int64, error) {
+	row, err := t.db.QueryRow(c, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,22 @@
+package LayerRepository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+type Interface interface {
+	ListLayers(c context.Context, projectId uuid.UUID) ([]*db.Layer, error)
+	ListAllocations(c context.Context) (map[uuid.UUID][]*db.LayerAllocation, error)
+	CreateLayer(c context.Context, projectId uuid.UUID, name string, salt string, description string) (uuid.UUID, error)
+	UpdateLayer(c context.Context, id uuid.UUID, name string, salt string, description string) error
+	DeleteLayer(c context.Context, id uuid.UUID) error
+
+	SetAllocation(c context.Context, layerId uuid.UUID, featureId uuid.UUID, from int, to int) error
+	RemoveAllocation(c context.Context, featureId uuid.UUID) error
+
+	DeleteAllByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error
+}
@@ -0,0 +1,375 @@
+package LayerRepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+// Layer bucket space is 0..100, every experiment owns a half-open range [from, to)
+const BucketCount = 100
+
+var (
+	ErrLayerNotFound      = errs.NotFound("layer_not_found", "layer not found")
+	ErrAllocationNotFound = errs.NotFound("allocation_not_found", "feature is not allocated in a layer")
+	ErrInvalidRange       = errs.Validation("invalid_range", "from", "allocation must satisfy 0 <= from < to <= 100")
+	ErrOverlap            = errs.Conflict("allocation_overlap", "from", "allocation overlaps another experiment of the layer")
+)
+
+type Repository struct {
+	repository.Mock
+	logger                     interfaces.ILogger
+	db                         postgres.IPostgres
+	activationValuesRepository ActivationValuesRepository.Interface
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
+
+	return nil
+}
+
+func NewForTest(db postgres.IPostgres, activationValuesRepository ActivationValuesRepository.Interface, logger interfaces.ILogger) *Repository {
+	return &Repository{
+		logger:                     logger,
+		db:                         db,
+		activationValuesRepository: activationValuesRepository,
+	}
+}
+
+// ValidateAllocation checks that [from, to) fits the bucket space and does not intersect
+// the ranges of other experiments already allocated in the same layer
+func ValidateAllocation(allocations []*db.LayerAllocation, featureId uuid.UUID, from int, to int) error {
+	if from < 0 || to > BucketCount || from >= to {
+		return ErrInvalidRange
+	}
+
+	for _, a := range allocations {
+		// Re-allocating the same experiment replaces its previous range
+		if a.FeatureId == featureId {
+			continue
+		}
+
+		if from < a.To && a.From < to {
+			return fmt.Errorf("%w: %s [%d, %d)", ErrOverlap, a.FeatureName, a.From, a.To)
+		}
+	}
+
+	return nil
+}
+
+func (t *Repository) ListLayers(c context.Context, projectId uuid.UUID) ([]*db.Layer, error) {
+	rows, err := t.db.Query(c, `SELECT id, name, salt, COALESCE(description, '') FROM layers WHERE project_id = $1 ORDER BY name`, projectId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*db.Layer, 0)
+	for rows.Next() {
+		l := &db.Layer{}
+		if err := rows.Scan(&l.Id, &l.Name, &l.Salt, &l.Description); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		out = append(out, l)
+	}
+
+	return out, nil
+}
+
+func (t *Repository) ListAllocations(c context.Context) (map[uuid.UUID][]*db.LayerAllocation, error) {
+	rows, err := t.db.Query(c, `
+SELECT la.id, la.layer_id, la.feature_id, f.name, la.bucket_from, la.bucket_to
+FROM layer_allocations la
+JOIN features f ON f.id = la.feature_id
+WHERE f.deleted_at IS NULL
+ORDER BY la.bucket_from
+`)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uuid.UUID][]*db.LayerAllocation)
+	for rows.Next() {
+		a := &db.LayerAllocation{}
+		if err := rows.Scan(&a.Id, &a.LayerId, &a.FeatureId, &a.FeatureName, &a.From, &a.To); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		out[a.LayerId] = append(out[a.LayerId], a)
+	}
+
+	return out, nil
+}
+
+func (t *Repository) CreateLayer(c context.Context, projectId uuid.UUID, name string, salt string, description string) (uuid.UUID, error) {
+	if salt == "" {
+		salt = name
+	}
+
+	id := uuid.New()
+	if err := t.db.Exec(c, `INSERT INTO layers (id, project_id, name, salt, description) VALUES ($1, $2, $3, $4, $5)`, id, projectId, name, salt, description); err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	return id, nil
+}
+
+func (t *Repository) UpdateLayer(c context.Context, id uuid.UUID, name string, salt string, description string) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	row, err := tx.QueryRow(c, `SELECT salt FROM layers WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var oldSalt string
+	if err := row.Scan(&oldSalt); err != nil {
+		return ErrLayerNotFound
+	}
+
+	if salt == "" {
+		salt = oldSalt
+	}
+
+	err = tx.Exec(c, `UPDATE layers SET name = $2, salt = $3, description = $4 WHERE id = $1`, id, name, salt, description)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	// Layer name and salt are part of every experiment payload, resend them to subscribers
+	v, err := t.touchLayerFeatures(c, tx, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return nil
+}
+
+func (t *Repository) DeleteLayer(c context.Context, id uuid.UUID) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	row, err := tx.QueryRow(c, `SELECT id FROM layers WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var lockedId uuid.UUID
+	if err := row.Scan(&lockedId); err != nil {
+		return ErrLayerNotFound
+	}
+
+	v, err := t.touchLayerFeatures(c, tx, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Exec(c, `DELETE FROM layer_allocations WHERE layer_id = $1`, id); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Exec(c, `DELETE FROM layers WHERE id = $1`, id); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return nil
+}
+
+func (t *Repository) SetAllocation(c context.Context, layerId uuid.UUID, featureId uuid.UUID, from int, to int) error {
+	if from < 0 || to > BucketCount || from >= to {
+		return ErrInvalidRange
+	}
+
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	// Lock the layer so concurrent allocations in it are validated one after another
+	row, err := tx.QueryRow(c, `SELECT id FROM layers WHERE id = $1 FOR UPDATE`, layerId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var lockedId uuid.UUID
+	if err := row.Scan(&lockedId); err != nil {
+		return ErrLayerNotFound
+	}
+
+	rows, err := tx.Query(c, `
+SELECT la.id, la.layer_id, la.feature_id, f.name, la.bucket_from, la.bucket_to
+FROM layer_allocations la
+JOIN features f ON f.id = la.feature_id
+WHERE la.layer_id = $1
+`, layerId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	allocations := make([]*db.LayerAllocation, 0)
+	for rows.Next() {
+		a := &db.LayerAllocation{}
+		if err := rows.Scan(&a.Id, &a.LayerId, &a.FeatureId, &a.FeatureName, &a.From, &a.To); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		allocations = append(allocations, a)
+	}
+	rows.Close()
+
+	if err := ValidateAllocation(allocations, featureId, from, to); err != nil {
+		return errs.FromDB(err)
+	}
+
+	err = tx.Exec(c, `
+INSERT INTO layer_allocations (id, layer_id, feature_id, bucket_from, bucket_to)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (feature_id)
+DO UPDATE SET layer_id = EXCLUDED.layer_id, bucket_from = EXCLUDED.bucket_from, bucket_to = EXCLUDED.bucket_to
+`, uuid.New(), layerId, featureId, from, to)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	v, err := t.activationValuesRepository.TouchFeature(c, tx, featureId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return nil
+}
+
+func (t *Repository) RemoveAllocation(c context.Context, featureId uuid.UUID) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	row, err := tx.QueryRow(c, `DELETE FROM layer_allocations WHERE feature_id = $1 RETURNING id`, featureId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var allocationId uuid.UUID
+	if err := row.Scan(&allocationId); err != nil {
+		return ErrAllocationNotFound
+	}
+
+	v, err := t.activationValuesRepository.TouchFeature(c, tx, featureId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+	t.activationValuesRepository.Publish(c, v)
+
+	return nil
+}
+
+func (t *Repository) DeleteAllByFeatureId(c context.Context, tx postgres.SQLTx, featureId uuid.UUID) error {
+	return tx.Exec(c, `DELETE FROM layer_allocations WHERE feature_id = $1`, featureId)
+}
+
+// touchLayerFeatures re-versions every feature allocated in the layer and returns the newest version, 0 when the
+// layer is empty
+func (t *Repository) touchLayerFeatures(c context.Context, tx postgres.SQLTx, layerId uuid.UUID) (int64, error) {
+	rows, err := tx.Query(c, `SELECT feature_id FROM layer_allocations WHERE layer_id = $1`, layerId)
+	if err != nil {
+		return 0, err
+	}
+
+	featureIds := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		featureIds = append(featureIds, id)
+	}
+	rows.Close()
+
+	var v int64
+	for _, id := range featureIds {
+		if v, err = t.activationValuesRepository.TouchFeature(c, tx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,75 @@
+package LayerRepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+func TestValidateAllocation(t *testing.T) {
+	checkout := uuid.New()
+	search := uuid.New()
+	allocations := []*db.LayerAllocation{
+		{FeatureId: checkout, FeatureName: "checkout", From: 0, To: 30},
+		{FeatureId: search, FeatureName: "search", From: 50, To: 70},
+	}
+
+	tests := []struct {
+		name      string
+		featureId uuid.UUID
+		from, to  int
+		err       error
+	}{
+		{name: "free gap", featureId: uuid.New(), from: 30, to: 50},
+		{name: "tail of space", featureId: uuid.New(), from: 70, to: 100},
+		{name: "overlaps head", featureId: uuid.New(), from: 20, to: 40, err: ErrOverlap},
+		{name: "overlaps tail", featureId: uuid.New(), from: 69, to: 80, err: ErrOverlap},
+		{name: "covers other", featureId: uuid.New(), from: 40, to: 90, err: ErrOverlap},
+		{name: "resize own range", featureId: checkout, from: 0, to: 50},
+		{name: "own range into other", featureId: checkout, from: 0, to: 51, err: ErrOverlap},
+		{name: "empty range", featureId: uuid.New(), from: 40, to: 40, err: ErrInvalidRange},
+		{name: "negative from", featureId: uuid.New(), from: -1, to: 10, err: ErrInvalidRange},
+		{name: "beyond space", featureId: uuid.New(), from: 90, to: 101, err: ErrInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAllocation(allocations, tt.featureId, tt.from, tt.to)
+			if tt.err == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestRepository_DeleteMissing(t *testing.T) {
+	execs := 0
+	pg := &postgres.Mock{
+		QueryRowFunc: func(context.Context, string, ...any) (postgres.SQLRow, error) {
+			return &postgres.MockRow{}, nil
+		},
+		ExecFunc: func(context.Context, string, ...any) error {
+			execs++
+			return nil
+		},
+	}
+	r := NewForTest(pg, nil, mock_log.New(true))
+
+	if err := r.DeleteLayer(context.Background(), uuid.New()); !errors.Is(err, ErrLayerNotFound) {
+		t.Errorf("expected %v for a missing layer, got %v", ErrLayerNotFound, err)
+	}
+	if err := r.RemoveAllocation(context.Background(), uuid.New()); !errors.Is(err, ErrAllocationNotFound) {
+		t.Errorf("expected %v for a feature outside layers, got %v", ErrAllocationNotFound, err)
+	}
+	if execs != 0 {
+		t.Errorf("nothing must be written for missing ids, got %d statements", execs)
+	}
+}
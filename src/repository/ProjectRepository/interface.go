@@ -0,0 +1,19 @@
+package ProjectRepository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	ListProjects(c context.Context) ([]*db.Project, error)
+	// FindProject resolves a project by id or by name
+	FindProject(c context.Context, ref string) (*db.Project, error)
+	CreateProject(c context.Context, name string) (uuid.UUID, error)
+	DeleteProject(c context.Context, id uuid.UUID) error
+
+	// Owns reports whether the entity with the given id belongs to the project
+	Owns(c context.Context, projectId uuid.UUID, entity string, id uuid.UUID) (bool, error)
+}
@@ -0,0 +1,28 @@
+package ProjectRepository
+
+import "strings"
+
+// DefaultProject holds the data that existed before projects, bare service names resolve to it
+const DefaultProject = "default"
+
+// DefaultProjectId is fixed by the migration that introduced projects
+const DefaultProjectId = "00000000-0000-0000-0000-000000000001"
+
+// SplitServiceName splits a client supplied "<project>/<service>", a bare name belongs to the default project
+func SplitServiceName(name string) (string, string) {
+	project, service, ok := strings.Cut(name, "/")
+	if !ok {
+		return DefaultProject, name
+	}
+
+	return project, service
+}
+
+// QualifiedName is the inverse of SplitServiceName, names of the default project stay bare
+func QualifiedName(project string, name string) string {
+	if project == "" || project == DefaultProject {
+		return name
+	}
+
+	return project + "/" + name
+}
@@ -0,0 +1,43 @@
+package ProjectRepository
+
+import "testing"
+
+func TestSplitServiceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		service string
+	}{
+		{name: "billing", project: DefaultProject, service: "billing"},
+		{name: "shop/billing", project: "shop", service: "billing"},
+		{name: "default/billing", project: DefaultProject, service: "billing"},
+		{name: "shop/", project: "shop", service: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, service := SplitServiceName(tt.name)
+			if project != tt.project || service != tt.service {
+				t.Fatalf("expected %q %q, got %q %q", tt.project, tt.service, project, service)
+			}
+		})
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	if got := QualifiedName(DefaultProject, "billing"); got != "billing" {
+		t.Fatalf("default project must stay bare, got %q", got)
+	}
+	if got := QualifiedName("", "billing"); got != "billing" {
+		t.Fatalf("unknown project must stay bare, got %q", got)
+	}
+
+	got := QualifiedName("shop", "billing")
+	if got != "shop/billing" {
+		t.Fatalf("expected shop/billing, got %q", got)
+	}
+
+	if project, service := SplitServiceName(got); project != "shop" || service != "billing" {
+		t.Fatalf("round trip failed: %q %q", project, service)
+	}
+}
@@ -0,0 +1,175 @@
+package ProjectRepository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+// Entities a project owns, directly or through its features
+const (
+	EntityFeature       = "feature" // feature, key or param id
+	EntityService       = "service"
+	EntityLayer         = "layer"
+	EntityWebhook       = "webhook"
+	EntityChangeRequest = "change_request"
+)
+
+var (
+	ErrProjectNotFound = errs.NotFound("project_not_found", "project not found")
+	ErrProjectNotEmpty = errs.InUse("project_not_empty", "project still has features, services, layers or webhooks")
+	ErrDefaultProject  = errs.Conflict("default_project", "", "default project cannot be deleted")
+	ErrUnknownEntity   = errors.New("unknown entity")
+)
+
+var ownsQueries = map[string]string{
+	EntityFeature: `
+SELECT EXISTS (
+    SELECT 1
+    FROM features f
+    LEFT JOIN activation_keys ak ON ak.feature_id = f.id
+    LEFT JOIN activation_params ap ON ap.activation_id = ak.id
+    WHERE f.project_id = $1 AND f.deleted_at IS NULL AND (f.id = $2 OR ak.id = $2 OR ap.id = $2)
+)`,
+	EntityService:       `SELECT EXISTS (SELECT 1 FROM services WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL)`,
+	EntityLayer:         `SELECT EXISTS (SELECT 1 FROM layers WHERE project_id = $1 AND id = $2)`,
+	EntityWebhook:       `SELECT EXISTS (SELECT 1 FROM webhooks WHERE project_id = $1 AND id = $2)`,
+	EntityChangeRequest: `SELECT EXISTS (SELECT 1 FROM change_requests cr JOIN features f ON f.id = cr.feature_id WHERE f.project_id = $1 AND cr.id = $2)`,
+}
+
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+
+	return nil
+}
+
+func (t *Repository) ListProjects(c context.Context) ([]*db.Project, error) {
+	rows, err := t.db.Query(c, `SELECT id, name, created_at FROM projects ORDER BY name`)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*db.Project, 0)
+	for rows.Next() {
+		p := &db.Project{}
+		if err := rows.Scan(&p.Id, &p.Name, &p.CreatedAt); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		out = append(out, p)
+	}
+
+	return out, nil
+}
+
+func (t *Repository) FindProject(c context.Context, ref string) (*db.Project, error) {
+	query := `SELECT id, name, created_at FROM projects WHERE name = $1`
+	var arg any = ref
+	if id, err := uuid.Parse(ref); err == nil {
+		query = `SELECT id, name, created_at FROM projects WHERE id = $1`
+		arg = id
+	}
+
+	row, err := t.db.QueryRow(c, query, arg)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	p := &db.Project{}
+	if err := row.Scan(&p.Id, &p.Name, &p.CreatedAt); err != nil {
+		return nil, ErrProjectNotFound
+	}
+
+	return p, nil
+}
+
+func (t *Repository) CreateProject(c context.Context, name string) (uuid.UUID, error) {
+	id := uuid.New()
+	if err := t.db.Exec(c, `INSERT INTO projects (id, name) VALUES ($1, $2)`, id, name); err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	return id, nil
+}
+
+// DeleteProject removes only empty projects, soft-deleted features still count since their history stays
+func (t *Repository) DeleteProject(c context.Context, id uuid.UUID) error {
+	if id.String() == DefaultProjectId {
+		return ErrDefaultProject
+	}
+
+	row, err := t.db.QueryRow(c, `
+SELECT EXISTS (SELECT 1 FROM features WHERE project_id = $1)
+    OR EXISTS (SELECT 1 FROM services WHERE project_id = $1)
+    OR EXISTS (SELECT 1 FROM layers WHERE project_id = $1)
+    OR EXISTS (SELECT 1 FROM webhooks WHERE project_id = $1)
+`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var used bool
+	if err := row.Scan(&used); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if used {
+		return ErrProjectNotEmpty
+	}
+
+	if err := t.db.Exec(c, `DELETE FROM projects WHERE id = $1`, id); err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	return nil
+}
+
+func (t *Repository) Owns(c context.Context, projectId uuid.UUID, entity string, id uuid.UUID) (bool, error) {
+	query, ok := ownsQueries[entity]
+	if !ok {
+		return false, ErrUnknownEntity
+	}
+
+	row, err := t.db.QueryRow(c, query, projectId, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return false, err
+	}
+
+	var owns bool
+	if err := row.Scan(&owns); err != nil {
+		t.logger.Error(c, err)
+		return false, err
+	}
+
+	return owns, nil
+}
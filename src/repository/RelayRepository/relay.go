@@ -0,0 +1,83 @@
+package RelayRepository
+
+import (
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/go_utils/decode"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Relay mode replaces the Postgres and Redis backed repositories: configuration is mirrored
+// from an upstream FeatureChaos over Subscribe and usage stats are forwarded over Stats
+
+var (
+	ErrReadOnly        = errors.New("relay is read-only, change the configuration upstream")
+	ErrTooManyServices = errors.New("relay follows too many services on demand, list the service in the upstream services")
+)
+
+// Config is the "upstream" client entry of cfg.yaml, its presence switches the binary into relay mode
+type Config struct {
+	Addr     string   `yaml:"addr"`
+	TLS      bool     `yaml:"tls"`
+	Services []string `yaml:"services"`
+	// StateFile keeps the mirrored configuration across restarts, so the relay serves even while upstream is unreachable
+	StateFile     string        `yaml:"state_file"`
+	StatsInterval time.Duration `yaml:"stats_interval"`
+	// MaxServices caps the services subscribed on first request, the configured ones do not count
+	MaxServices int `yaml:"max_services"`
+	// IdleTimeout drops a service subscribed on first request once no client asked for it this long
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+
+	// expireInterval is how often services subscribed on demand are checked for idleness
+	expireInterval = time.Minute
+)
+
+func (cfg *Config) setDefaults() {
+	if cfg.StatsInterval == 0 {
+		cfg.StatsInterval = 10 * time.Second
+	}
+	if cfg.MaxServices == 0 {
+		cfg.MaxServices = 100
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = 24 * time.Hour
+	}
+}
+
+func loadConfig(app interfaces.IEngine) (Config, error) {
+	var cfg Config
+
+	item := app.GetConfig().GetConfigItem(interfaces.PkgClient, names.UpstreamClient)
+	if len(item) > 0 {
+		if err := decode.Decode(item, &cfg, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return cfg, err
+		}
+	}
+
+	if cfg.Addr == "" {
+		return cfg, errors.New("relay: upstream addr is required")
+	}
+	cfg.setDefaults()
+
+	return cfg, nil
+}
+
+func dial(cfg Config) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	return grpc.NewClient(cfg.Addr, grpc.WithTransportCredentials(creds))
+}
@@ -0,0 +1,238 @@
+package RelayRepository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+// Every node remembers the upstream version of its last change, deltas for local clients are cut by it
+
+type paramState struct {
+	Value   int   `json:"value"`
+	V       int64 `json:"v"`
+	Deleted bool  `json:"deleted,omitempty"`
+}
+
+type keyState struct {
+	Value   int                    `json:"value"`
+	V       int64                  `json:"v"`
+	Deleted bool                   `json:"deleted,omitempty"`
+	Params  map[string]*paramState `json:"params"`
+}
+
+type layerState struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	From int    `json:"from"`
+	To   int    `json:"to"`
+}
+
+type featureState struct {
+	Value    int                  `json:"value"`
+	V        int64                `json:"v"`
+	Deleted  bool                 `json:"deleted,omitempty"`
+	Salt     string               `json:"salt"`
+	BucketBy string               `json:"bucket_by,omitempty"`
+	Layer    *layerState          `json:"layer,omitempty"`
+	Keys     map[string]*keyState `json:"keys"`
+}
+
+type serviceState struct {
+	Version  int64                    `json:"version"`
+	Features map[string]*featureState `json:"features"`
+	// LastUsed is when a local client last asked for the service, idle ones subscribed on demand are dropped
+	LastUsed time.Time `json:"last_used"`
+}
+
+func newServiceState() *serviceState {
+	return &serviceState{Features: make(map[string]*featureState)}
+}
+
+func (s *serviceState) feature(name string) *featureState {
+	f, ok := s.Features[name]
+	if !ok {
+		f = &featureState{Value: -1, Salt: name, Keys: make(map[string]*keyState)}
+		s.Features[name] = f
+	}
+	return f
+}
+
+func (f *featureState) key(name string) *keyState {
+	k, ok := f.Keys[name]
+	if !ok {
+		k = &keyState{Value: -1, Params: make(map[string]*paramState)}
+		f.Keys[name] = k
+	}
+	return k
+}
+
+// apply merges one upstream delta, -1 in All means the level did not change in this version
+func (s *serviceState) apply(resp *FeatureChaos.GetFeatureResponse) {
+	v := resp.Version
+
+	for _, item := range resp.Features {
+		f := s.feature(item.Name)
+		f.Salt = item.Salt
+		f.BucketBy = item.BucketBy
+		f.Layer = nil
+		if item.Layer != nil && item.Layer.Name != "" {
+			f.Layer = &layerState{Name: item.Layer.Name, Salt: item.Layer.Salt, From: int(item.Layer.From), To: int(item.Layer.To)}
+		}
+
+		if item.All != -1 || f.Deleted {
+			f.Value = int(item.All)
+			f.V = v
+			f.Deleted = false
+		}
+
+		for _, props := range item.Props {
+			k := f.key(props.Name)
+			if props.All != -1 || k.Deleted {
+				k.Value = int(props.All)
+				k.V = v
+				k.Deleted = false
+			}
+
+			for name, value := range props.Item {
+				k.Params[name] = &paramState{Value: int(value), V: v}
+			}
+		}
+	}
+
+	for _, d := range resp.Deleted {
+		switch d.Kind {
+		case FeatureChaos.GetFeatureResponse_DeletedItem_FEATURE:
+			f := s.feature(d.FeatureName)
+			f.Deleted = true
+			f.V = v
+		case FeatureChaos.GetFeatureResponse_DeletedItem_KEY:
+			k := s.feature(d.FeatureName).key(d.KeyName)
+			k.Deleted = true
+			k.V = v
+		case FeatureChaos.GetFeatureResponse_DeletedItem_PARAM:
+			k := s.feature(d.FeatureName).key(d.KeyName)
+			p, ok := k.Params[d.ParamName]
+			if !ok {
+				p = &paramState{Value: -1}
+				k.Params[d.ParamName] = p
+			}
+			p.Deleted = true
+			p.V = v
+		}
+	}
+
+	if v > s.Version {
+		s.Version = v
+	}
+}
+
+// since rebuilds what upstream would answer for lastVersion, in the same shape as GetNewByServiceName
+func (s *serviceState) since(lastVersion int64) []*dto.Feature {
+	out := make([]*dto.Feature, 0)
+
+	for _, name := range sortedKeys(s.Features) {
+		f := s.Features[name]
+
+		if f.Deleted {
+			if f.V > lastVersion {
+				out = append(out, &dto.Feature{Name: name, Salt: f.Salt, IsDeleted: true})
+			}
+			continue
+		}
+
+		feat := &dto.Feature{Name: name, Salt: f.Salt, BucketBy: f.BucketBy, Value: -1}
+		if f.V > lastVersion {
+			feat.Value = f.Value
+		}
+		if f.Layer != nil {
+			feat.Layer = &dto.FeatureLayer{Name: f.Layer.Name, Salt: f.Layer.Salt, From: f.Layer.From, To: f.Layer.To}
+		}
+
+		for _, keyName := range sortedKeys(f.Keys) {
+			k := f.Keys[keyName]
+
+			if k.Deleted {
+				if k.V > lastVersion {
+					feat.Keys = append(feat.Keys, dto.FeatureKey{Key: keyName, Value: -1, IsDeleted: true})
+				}
+				continue
+			}
+
+			key := dto.FeatureKey{Key: keyName, Value: -1, Params: make([]dto.FeatureParam, 0)}
+			if k.V > lastVersion {
+				key.Value = k.Value
+			}
+
+			for _, paramName := range sortedKeys(k.Params) {
+				p := k.Params[paramName]
+				if p.V > lastVersion {
+					key.Params = append(key.Params, dto.FeatureParam{Name: paramName, Value: p.Value, IsDeleted: p.Deleted})
+				}
+			}
+
+			if k.V > lastVersion || len(key.Params) > 0 {
+				feat.Keys = append(feat.Keys, key)
+			}
+		}
+
+		if f.V > lastVersion || len(feat.Keys) > 0 {
+			out = append(out, feat)
+		}
+	}
+
+	return out
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func loadState(path string) (map[string]*serviceState, error) {
+	services := make(map[string]*serviceState)
+	if path == "" {
+		return services, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return services, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// saveState replaces the file atomically, a crash never leaves a half written state behind
+func saveState(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
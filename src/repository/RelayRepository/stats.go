@@ -0,0 +1,169 @@
+package RelayRepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"google.golang.org/grpc"
+)
+
+type statKey struct {
+	service string
+	feature string
+}
+
+// StatsRepository collapses local usage reports and forwards each pair upstream once per interval
+type StatsRepository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	config Config
+	conn   *grpc.ClientConn
+	client FeatureChaos.FeatureServiceClient
+
+	mu      sync.Mutex
+	pending map[statKey]struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewStats(name string) *StatsRepository {
+	return &StatsRepository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewStatsForTest(client FeatureChaos.FeatureServiceClient, logger interfaces.ILogger, config Config) *StatsRepository {
+	t := &StatsRepository{logger: logger, config: config, client: client}
+	t.start()
+	return t
+}
+
+func (t *StatsRepository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+
+	cfg, err := loadConfig(app)
+	if err != nil {
+		return err
+	}
+	t.config = cfg
+
+	conn, err := dial(cfg)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.client = FeatureChaos.NewFeatureServiceClient(conn)
+
+	t.start()
+	return nil
+}
+
+func (t *StatsRepository) start() {
+	t.pending = make(map[statKey]struct{})
+
+	c, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.config.StatsInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				t.flush(c)
+			}
+		}
+	}()
+}
+
+func (t *StatsRepository) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+
+	// Last chance to deliver what was collected since the previous tick
+	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	t.flush(c)
+
+	if t.conn != nil {
+		return t.conn.Close()
+	}
+	return nil
+}
+
+func (t *StatsRepository) SetStat(_ context.Context, serviceName string, featureName string) {
+	t.mu.Lock()
+	t.pending[statKey{service: serviceName, feature: featureName}] = struct{}{}
+	t.mu.Unlock()
+}
+
+// Usage is judged upstream where all relays report
+
+func (t *StatsRepository) IsUsed(context.Context, string) bool {
+	return false
+}
+
+func (t *StatsRepository) IsServiceUsed(context.Context, string) bool {
+	return false
+}
+
+func (t *StatsRepository) FeatureSeenAt(context.Context, string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (t *StatsRepository) ServiceSeenAt(context.Context, string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (t *StatsRepository) flush(c context.Context) {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[statKey]struct{})
+	t.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := t.send(c, batch); err != nil {
+		t.logger.Error(c, err)
+
+		// Keep the batch for the next interval
+		t.mu.Lock()
+		for k := range batch {
+			t.pending[k] = struct{}{}
+		}
+		t.mu.Unlock()
+	}
+}
+
+func (t *StatsRepository) send(c context.Context, batch map[statKey]struct{}) error {
+	st, err := t.client.Stats(c)
+	if err != nil {
+		return err
+	}
+
+	for k := range batch {
+		if err := st.Send(&FeatureChaos.SendStatsRequest{ServiceName: k.service, FeatureName: k.feature}); err != nil {
+			return err
+		}
+	}
+
+	_, err = st.CloseAndRecv()
+	return err
+}
@@ -0,0 +1,395 @@
+package RelayRepository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"google.golang.org/grpc"
+)
+
+// relaySdkName tells relays apart from SDK instances in the upstream client registry
+const relaySdkName = "featurechaos-relay"
+
+// ValuesRepository serves ActivationValuesRepository.Interface from memory, one upstream subscription per service
+type ValuesRepository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	config Config
+	conn   *grpc.ClientConn
+	client FeatureChaos.FeatureServiceClient
+
+	// instanceId and hostname identify the relay in the upstream client registry
+	instanceId string
+	hostname   string
+
+	mu         sync.Mutex
+	services   map[string]*serviceState
+	modifiedAt time.Time
+	// cancels ends the upstream subscription of each followed service
+	cancels map[string]context.CancelFunc
+
+	c      context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewValues(name string) *ValuesRepository {
+	return &ValuesRepository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewValuesForTest(client FeatureChaos.FeatureServiceClient, logger interfaces.ILogger, config Config) (*ValuesRepository, error) {
+	config.setDefaults()
+	t := &ValuesRepository{logger: logger, config: config, client: client}
+	return t, t.start()
+}
+
+func (t *ValuesRepository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+
+	cfg, err := loadConfig(app)
+	if err != nil {
+		return err
+	}
+	t.config = cfg
+
+	conn, err := dial(cfg)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.client = FeatureChaos.NewFeatureServiceClient(conn)
+
+	return t.start()
+}
+
+func (t *ValuesRepository) start() error {
+	services, err := loadState(t.config.StateFile)
+	if err != nil {
+		return err
+	}
+	t.services = services
+	t.cancels = make(map[string]context.CancelFunc)
+	t.instanceId = uuid.NewString()
+	t.hostname, _ = os.Hostname()
+
+	t.c, t.cancel = context.WithCancel(context.Background())
+
+	// Known services resume from the persisted version, configured ones are subscribed eagerly.
+	// A state written before idleness was tracked counts as used now
+	now := time.Now()
+	for name, svc := range services {
+		if svc.LastUsed.IsZero() {
+			svc.LastUsed = now
+		}
+		t.subscribe(name)
+	}
+	for _, name := range t.config.Services {
+		if _, err := t.ensure(name); err != nil {
+			return err
+		}
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(expireInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.c.Done():
+				return
+			case now := <-ticker.C:
+				t.expire(now)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (t *ValuesRepository) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+
+	if t.conn != nil {
+		return t.conn.Close()
+	}
+	return nil
+}
+
+// ensure subscribes to the service on first use, so local clients need no relay config change.
+// Services outside the config are capped by MaxServices, any client could otherwise make the relay follow
+// arbitrary names upstream
+func (t *ValuesRepository) ensure(serviceName string) (*serviceState, error) {
+	t.mu.Lock()
+	svc, ok := t.services[serviceName]
+	if !ok {
+		if !t.configured(serviceName) && t.onDemand() >= t.config.MaxServices {
+			t.mu.Unlock()
+			return nil, ErrTooManyServices
+		}
+		svc = newServiceState()
+		t.services[serviceName] = svc
+	}
+	svc.LastUsed = time.Now()
+	t.mu.Unlock()
+
+	if !ok {
+		t.subscribe(serviceName)
+	}
+	return svc, nil
+}
+
+func (t *ValuesRepository) configured(serviceName string) bool {
+	return slices.Contains(t.config.Services, serviceName)
+}
+
+// onDemand counts the followed services that are not in the config, callers hold mu
+func (t *ValuesRepository) onDemand() int {
+	n := 0
+	for name := range t.services {
+		if !t.configured(name) {
+			n++
+		}
+	}
+	return n
+}
+
+// expire forgets services subscribed on demand that no client asked for within IdleTimeout and ends their
+// upstream subscriptions
+func (t *ValuesRepository) expire(now time.Time) {
+	t.mu.Lock()
+	expired := 0
+	for name, svc := range t.services {
+		if t.configured(name) || now.Sub(svc.LastUsed) < t.config.IdleTimeout {
+			continue
+		}
+		delete(t.services, name)
+		if cancel, ok := t.cancels[name]; ok {
+			cancel()
+			delete(t.cancels, name)
+		}
+		expired++
+	}
+	t.mu.Unlock()
+
+	if expired > 0 {
+		t.persist()
+	}
+}
+
+func (t *ValuesRepository) subscribe(serviceName string) {
+	c, cancel := context.WithCancel(t.c)
+	t.mu.Lock()
+	t.cancels[serviceName] = cancel
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer cancel()
+
+		backoff := minBackoff
+		for {
+			received, err := t.stream(c, serviceName)
+			if c.Err() != nil {
+				return
+			}
+			if received {
+				backoff = minBackoff
+			}
+			if err != nil {
+				t.logger.Error(c, err)
+			}
+
+			select {
+			case <-c.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+}
+
+// stream follows one upstream subscription until it breaks or sc ends, resuming from the last applied version
+func (t *ValuesRepository) stream(sc context.Context, serviceName string) (bool, error) {
+	t.mu.Lock()
+	svc, ok := t.services[serviceName]
+	var lastVersion int64
+	if ok {
+		lastVersion = svc.Version
+	}
+	t.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	c, cancel := context.WithCancel(sc)
+	defer cancel()
+
+	st, err := t.client.Subscribe(c, &FeatureChaos.GetAllFeatureRequest{
+		ServiceName: serviceName,
+		LastVersion: lastVersion,
+		InstanceId:  t.instanceId,
+		Hostname:    t.hostname,
+		SdkName:     relaySdkName,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for {
+		resp, err := st.Recv()
+		if err != nil {
+			if c.Err() != nil && sc.Err() == nil {
+				// Left a draining upstream as asked
+				return received, nil
+			}
+			return received, err
+		}
+		received = true
+		if resp.Reconnect {
+			// Upstream drains, leaving after the hinted delay spreads relays over the remaining instances
+			time.AfterFunc(time.Duration(resp.ReconnectAfter)*time.Millisecond, cancel)
+			continue
+		}
+		if resp.Heartbeat {
+			// The mirror is already at this version, nothing to apply or persist
+			continue
+		}
+
+		t.mu.Lock()
+		svc.apply(resp)
+		t.modifiedAt = time.Now()
+		t.mu.Unlock()
+
+		t.persist()
+	}
+}
+
+func (t *ValuesRepository) persist() {
+	if t.config.StateFile == "" {
+		return
+	}
+
+	t.mu.Lock()
+	b, err := json.Marshal(t.services)
+	t.mu.Unlock()
+
+	if err == nil {
+		err = saveState(t.config.StateFile, b)
+	}
+	if err != nil {
+		t.logger.Error(t.c, err)
+	}
+}
+
+func (t *ValuesRepository) GetNewByServiceName(_ context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature, error) {
+	svc, err := t.ensure(serviceName)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if svc.Version <= lastVersion {
+		return svc.Version, nil, nil
+	}
+
+	return svc.Version, svc.since(lastVersion), nil
+}
+
+func (t *ValuesRepository) GetGlobalVersion(_ context.Context) (int64, time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var version int64 = -1
+	for _, svc := range t.services {
+		if svc.Version > version {
+			version = svc.Version
+		}
+	}
+
+	return version, t.modifiedAt.Truncate(time.Second), nil
+}
+
+// Writes and admin reads need the upstream database
+
+func (t *ValuesRepository) InsertValue(context.Context, postgres.SQLTx, uuid.UUID, *uuid.UUID, *uuid.UUID, int) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) TouchFeature(context.Context, postgres.SQLTx, uuid.UUID) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) AllocateVersion(context.Context, postgres.SQLTx) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) RecordRename(context.Context, postgres.SQLTx, int64, db.Rename) error {
+	return ErrReadOnly
+}
+
+func (t *ValuesRepository) RecordBinding(context.Context, postgres.SQLTx, int64, uuid.UUID, uuid.UUID, bool) error {
+	return ErrReadOnly
+}
+
+func (t *ValuesRepository) Publish(context.Context, int64) {}
+
+func (t *ValuesRepository) GetVersion(context.Context, uuid.UUID) (uuid.UUID, int64, error) {
+	return uuid.Nil, 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) CheckRevision(context.Context, postgres.SQLTx, uuid.UUID, int64) error {
+	return ErrReadOnly
+}
+
+func (t *ValuesRepository) GetState(context.Context, uuid.UUID) (*dto.EntityState, error) {
+	return nil, ErrReadOnly
+}
+
+func (t *ValuesRepository) GetClientSideByServiceName(context.Context, string) (int64, []*dto.Feature, error) {
+	return 0, nil, ErrReadOnly
+}
+
+func (t *ValuesRepository) GetFeatures(context.Context, uuid.UUID, string, int, int, string, bool, time.Duration) ([]*dto.Feature, int, error) {
+	return nil, 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) DeleteByFeatureId(context.Context, postgres.SQLTx, uuid.UUID) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) DeleteByKeyId(context.Context, postgres.SQLTx, uuid.UUID) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (t *ValuesRepository) DeleteByParamId(context.Context, postgres.SQLTx, uuid.UUID) (int64, error) {
+	return 0, ErrReadOnly
+}
@@ -0,0 +1,312 @@
+package RelayRepository
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// upstream is a fake central FeatureChaos, it replays deltas newer than the requested version
+type upstream struct {
+	FeatureChaos.UnimplementedFeatureServiceServer
+
+	mu          sync.Mutex
+	deltas      []*FeatureChaos.GetFeatureResponse
+	requests    []*FeatureChaos.GetAllFeatureRequest
+	stats       []string
+	subscribers chan struct{}
+}
+
+func (u *upstream) Subscribe(req *FeatureChaos.GetAllFeatureRequest, st grpc.ServerStreamingServer[FeatureChaos.GetFeatureResponse]) error {
+	u.mu.Lock()
+	u.requests = append(u.requests, req)
+	deltas := append([]*FeatureChaos.GetFeatureResponse(nil), u.deltas...)
+	u.mu.Unlock()
+
+	for _, d := range deltas {
+		if d.Version > req.LastVersion {
+			if err := st.Send(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	u.subscribers <- struct{}{}
+	<-st.Context().Done()
+	return nil
+}
+
+func (u *upstream) Stats(st grpc.ClientStreamingServer[FeatureChaos.SendStatsRequest, emptypb.Empty]) error {
+	for {
+		req, err := st.Recv()
+		if err != nil {
+			return st.SendAndClose(&emptypb.Empty{})
+		}
+		u.mu.Lock()
+		u.stats = append(u.stats, req.ServiceName+"/"+req.FeatureName)
+		u.mu.Unlock()
+	}
+}
+
+func startUpstream(t *testing.T, u *upstream) FeatureChaos.FeatureServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	FeatureChaos.RegisterFeatureServiceServer(srv, u)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return FeatureChaos.NewFeatureServiceClient(conn)
+}
+
+func waitSubscribed(t *testing.T, u *upstream) {
+	t.Helper()
+
+	select {
+	case <-u.subscribers:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not subscribe upstream")
+	}
+}
+
+func deltas() []*FeatureChaos.GetFeatureResponse {
+	return []*FeatureChaos.GetFeatureResponse{
+		{
+			Version: 1,
+			Features: []*FeatureChaos.FeatureItem{
+				{All: 50, Name: "checkout", Salt: "checkout", Props: []*FeatureChaos.PropsItem{{All: -1, Name: "country", Item: map[string]int32{"US": 100, "RU": 0}}}},
+				{All: 10, Name: "search", Salt: "search_v1", BucketBy: "company_id", Layer: &FeatureChaos.LayerItem{Name: "l", Salt: "ls", From: 0, To: 50}},
+			},
+		},
+		{
+			Version: 2,
+			Features: []*FeatureChaos.FeatureItem{
+				{All: -1, Name: "checkout", Salt: "checkout", Props: []*FeatureChaos.PropsItem{{All: -1, Name: "country", Item: map[string]int32{"US": 75}}}},
+			},
+			Deleted: []*FeatureChaos.GetFeatureResponse_DeletedItem{
+				{Kind: FeatureChaos.GetFeatureResponse_DeletedItem_PARAM, FeatureName: "checkout", KeyName: "country", ParamName: "RU"},
+				{Kind: FeatureChaos.GetFeatureResponse_DeletedItem_FEATURE, FeatureName: "search"},
+			},
+		},
+	}
+}
+
+func TestServiceState_ApplyAndSince(t *testing.T) {
+	s := newServiceState()
+	for _, d := range deltas() {
+		s.apply(d)
+	}
+
+	if s.Version != 2 {
+		t.Fatalf("unexpected version %d", s.Version)
+	}
+
+	// A fresh client gets the full picture including deletions, like from upstream
+	all := s.since(0)
+	if len(all) != 2 || all[0].Name != "checkout" || all[1].Name != "search" || !all[1].IsDeleted {
+		t.Fatalf("unexpected full state %+v", all)
+	}
+	checkout := all[0]
+	if checkout.Value != 50 || len(checkout.Keys) != 1 || len(checkout.Keys[0].Params) != 2 {
+		t.Fatalf("unexpected checkout %+v", checkout)
+	}
+	if p := checkout.Keys[0].Params[0]; p.Name != "RU" || !p.IsDeleted {
+		t.Errorf("expected RU deletion, got %+v", p)
+	}
+	if p := checkout.Keys[0].Params[1]; p.Name != "US" || p.Value != 75 {
+		t.Errorf("expected US at 75, got %+v", p)
+	}
+
+	// A client at version 1 only gets the second delta, the unchanged feature value stays -1
+	delta := s.since(1)
+	if len(delta) != 2 || delta[0].Value != -1 || len(delta[0].Keys[0].Params) != 2 || !delta[1].IsDeleted {
+		t.Fatalf("unexpected delta %+v", delta)
+	}
+
+	if len(s.since(2)) != 0 {
+		t.Errorf("nothing is newer than the latest version")
+	}
+}
+
+func TestServiceState_Layer(t *testing.T) {
+	s := newServiceState()
+	s.apply(deltas()[0])
+
+	f := s.since(0)[1]
+	if f.Salt != "search_v1" || f.BucketBy != "company_id" || f.Layer == nil || *f.Layer != (dto.FeatureLayer{Name: "l", Salt: "ls", From: 0, To: 50}) {
+		t.Fatalf("unexpected feature %+v", f)
+	}
+
+	// Leaving the layer arrives as a feature touch without layer
+	s.apply(&FeatureChaos.GetFeatureResponse{Version: 3, Features: []*FeatureChaos.FeatureItem{{All: 10, Name: "search", Salt: "search_v1"}}})
+	if f := s.since(2)[0]; f.Layer != nil {
+		t.Errorf("layer must be cleared, got %+v", f.Layer)
+	}
+}
+
+func TestValuesRepository_MirrorsUpstream(t *testing.T) {
+	u := &upstream{deltas: deltas(), subscribers: make(chan struct{}, 10)}
+	client := startUpstream(t, u)
+	stateFile := filepath.Join(t.TempDir(), "relay.json")
+
+	repo, err := NewValuesForTest(client, mock_log.New(true), Config{StateFile: stateFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown services are subscribed on first use
+	if v, features, _ := repo.GetNewByServiceName(context.Background(), "web", 0); v != 0 || features != nil {
+		t.Fatalf("expected empty state before sync, got %d %v", v, features)
+	}
+	waitSubscribed(t, u)
+
+	// The subscriber signal comes after the upstream send, the relay may still be applying it
+	var (
+		v        int64
+		features []*dto.Feature
+	)
+	deadline := time.Now().Add(5 * time.Second)
+	for v != 2 && time.Now().Before(deadline) {
+		v, features, err = repo.GetNewByServiceName(context.Background(), "web", 0)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || v != 2 || len(features) != 2 {
+		t.Fatalf("unexpected mirror %d %+v %v", v, features, err)
+	}
+	if gv, _, _ := repo.GetGlobalVersion(context.Background()); gv != 2 {
+		t.Errorf("unexpected global version %d", gv)
+	}
+
+	if _, err := repo.InsertValue(context.Background(), nil, features[0].Id, nil, nil, 1); err != ErrReadOnly {
+		t.Errorf("writes must be rejected, got %v", err)
+	}
+
+	if err := repo.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a restart the persisted state is served at once and upstream is asked only for newer versions
+	restarted, err := NewValuesForTest(client, mock_log.New(true), Config{StateFile: stateFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Stop()
+
+	if v, features, _ := restarted.GetNewByServiceName(context.Background(), "web", 0); v != 2 || len(features) != 2 {
+		t.Fatalf("state was not restored: %d %+v", v, features)
+	}
+	waitSubscribed(t, u)
+
+	u.mu.Lock()
+	last := u.requests[len(u.requests)-1]
+	u.mu.Unlock()
+	if last.ServiceName != "web" || last.LastVersion != 2 {
+		t.Errorf("expected resume from version 2, got %+v", last)
+	}
+}
+
+func TestStatsRepository_Forwards(t *testing.T) {
+	u := &upstream{subscribers: make(chan struct{}, 10)}
+	client := startUpstream(t, u)
+
+	repo := NewStatsForTest(client, mock_log.New(true), Config{StatsInterval: time.Hour})
+	for i := 0; i < 3; i++ {
+		repo.SetStat(context.Background(), "web", "checkout")
+	}
+	repo.SetStat(context.Background(), "web", "search")
+
+	// Stop flushes what was collected, duplicates within an interval are sent once
+	if err := repo.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.stats) != 2 {
+		t.Fatalf("expected 2 aggregated reports, got %v", u.stats)
+	}
+}
+
+func TestValuesRepository_LeavesDrainingUpstream(t *testing.T) {
+	drain := append(deltas(), &FeatureChaos.GetFeatureResponse{Version: 2, Reconnect: true, ReconnectAfter: 10})
+	u := &upstream{deltas: drain, subscribers: make(chan struct{}, 10)}
+	client := startUpstream(t, u)
+
+	repo, err := NewValuesForTest(client, mock_log.New(true), Config{Services: []string{"web"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Stop()
+
+	waitSubscribed(t, u)
+	// Resubscribes on its own although upstream keeps the stream open
+	waitSubscribed(t, u)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.requests) < 2 || u.requests[1].LastVersion != 2 {
+		t.Fatalf("expected a resubscription from version 2, got %v", u.requests)
+	}
+	if v, _, _ := repo.GetNewByServiceName(context.Background(), "web", 0); v != 2 {
+		t.Errorf("reconnect must not change the mirror, got version %d", v)
+	}
+}
+
+func TestValuesRepository_CapsAndExpiresOnDemand(t *testing.T) {
+	u := &upstream{subscribers: make(chan struct{}, 10)}
+	client := startUpstream(t, u)
+
+	repo, err := NewValuesForTest(client, mock_log.New(true), Config{Services: []string{"web"}, MaxServices: 1, IdleTimeout: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Stop()
+	waitSubscribed(t, u)
+
+	// Configured services do not count against the cap
+	if _, _, err := repo.GetNewByServiceName(context.Background(), "api", 0); err != nil {
+		t.Fatalf("first service on demand must be followed, got %v", err)
+	}
+	waitSubscribed(t, u)
+	if _, _, err := repo.GetNewByServiceName(context.Background(), "typo", 0); err != ErrTooManyServices {
+		t.Fatalf("expected the cap to reject, got %v", err)
+	}
+
+	// Nobody asked for api within the idle timeout, web stays as configured
+	repo.expire(time.Now().Add(2 * time.Hour))
+
+	repo.mu.Lock()
+	_, api := repo.services["api"]
+	_, web := repo.services["web"]
+	_, cancel := repo.cancels["api"]
+	repo.mu.Unlock()
+	if api || cancel || !web {
+		t.Fatalf("expected only api to be dropped, api %v cancel %v web %v", api, cancel, web)
+	}
+
+	// The freed slot is available again
+	if _, _, err := repo.GetNewByServiceName(context.Background(), "typo", 0); err != nil {
+		t.Errorf("expected a free slot after expiry, got %v", err)
+	}
+}
@@ -8,12 +8,15 @@ import (
 )
 
 type Interface interface {
-	ListServices(c context.Context) []db.Service
-	CreateService(c context.Context, name string) (uuid.UUID, error)
+	ListServices(c context.Context, projectId uuid.UUID) []db.Service
+	CreateService(c context.Context, projectId uuid.UUID, name string) (uuid.UUID, error)
 	DeleteService(c context.Context, id uuid.UUID) error
+	GetServiceName(c context.Context, serviceId uuid.UUID) (string, error)
 
 	GetAccess(c context.Context) ([]*db.ServiceAccess, error)
 	GetAccessByFeatures(c context.Context, featureIds []uuid.UUID) (map[uuid.UUID][]*db.ServiceAccess, error)
+	GetFeaturesByService(c context.Context, serviceId uuid.UUID) ([]uuid.UUID, error)
 	AddAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error
 	RemoveAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error
+	SetClientSide(c context.Context, featureId uuid.UUID, serviceId uuid.UUID, enabled bool) error
 }
@@ -8,17 +8,24 @@ import (
 	"github.com/google/uuid"
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
-	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
 	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
 )
 
+var (
+	ErrAccessNotFound  = errs.NotFound("binding_not_found", "feature is not bound to the service")
+	ErrServiceNotFound = errs.NotFound("service_not_found", "service not found")
+)
+
 type Repository struct {
 	repository.Mock
-	logger interfaces.ILogger
-	cache  redis.IRedis
-	db     postgres.IPostgres
+	logger                     interfaces.ILogger
+	db                         postgres.IPostgres
+	activationValuesRepository ActivationValuesRepository.Interface
 }
 
 func New(name string) *Repository {
@@ -29,17 +36,25 @@ func New(name string) *Repository {
 	}
 }
 
+func NewForTest(db postgres.IPostgres, activationValuesRepository ActivationValuesRepository.Interface, logger interfaces.ILogger) *Repository {
+	return &Repository{
+		db:                         db,
+		activationValuesRepository: activationValuesRepository,
+		logger:                     logger,
+	}
+}
+
 func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
 	t.logger = app.GetLogger()
-	t.cache = app.GetPkg(interfaces.PkgCache, names.CacheRedis).(redis.IRedis)
 	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
 
 	return nil
 }
 
 // Services CRUD
-func (t *Repository) ListServices(c context.Context) []db.Service {
-	rows, err := t.db.Query(c, `SELECT id, name FROM services ORDER BY name`)
+func (t *Repository) ListServices(c context.Context, projectId uuid.UUID) []db.Service {
+	rows, err := t.db.Query(c, `SELECT id, name FROM services WHERE project_id = $1 AND deleted_at IS NULL ORDER BY name`, projectId)
 	if err != nil {
 		t.logger.Error(c, err)
 		return nil
@@ -57,41 +72,207 @@ func (t *Repository) ListServices(c context.Context) []db.Service {
 	return out
 }
 
-func (t *Repository) CreateService(c context.Context, name string) (uuid.UUID, error) {
-	id := uuid.New()
-	if err := t.db.Exec(c, `INSERT INTO services(id, name) VALUES($1,$2)`, id, name); err != nil {
+func (t *Repository) CreateService(c context.Context, projectId uuid.UUID, name string) (uuid.UUID, error) {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	// A deleted service of the same name comes back under its id. Its tombstoned bindings stay, so clients that had
+	// not synced past the deletion still receive the tombstones, and binding a feature again revives its row
+	row, err := tx.QueryRow(c, `
+UPDATE services SET deleted_at = NULL
+WHERE id = (SELECT id FROM services WHERE project_id = $1 AND name = $2 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT 1)
+RETURNING id
+`, projectId, name)
+	if err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	var id uuid.UUID
+	if err := row.Scan(&id); err == nil {
+		if err := tx.Commit(c); err != nil {
+			t.logger.Error(c, err)
+			return uuid.Nil, errs.FromDB(err)
+		}
+		return id, nil
+	}
+
+	id = uuid.New()
+	if err := tx.Exec(c, `INSERT INTO services(id, project_id, name) VALUES($1,$2,$3)`, id, projectId, name); err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return uuid.Nil, err
+		return uuid.Nil, errs.FromDB(err)
 	}
+
 	return id, nil
 }
 
+// DeleteService unbinds every feature of the service in one version and keeps the service as a tombstone,
+// so connected clients receive deletions of all its features
 func (t *Repository) DeleteService(c context.Context, id uuid.UUID) error {
-	if err := t.db.Exec(c, `DELETE FROM services WHERE id=$1`, id); err != nil {
+	return t.versioned(c, func(tx postgres.SQLTx, v int64) (bool, error) {
+		rows, err := tx.Query(c, `UPDATE service_access SET deleted_at = NOW(), v = $2 WHERE service_id = $1 AND deleted_at IS NULL RETURNING feature_id`, id, v)
+		if err != nil {
+			return false, err
+		}
+		unbound := make([]uuid.UUID, 0)
+		for rows.Next() {
+			var featureId uuid.UUID
+			if err := rows.Scan(&featureId); err != nil {
+				rows.Close()
+				return false, err
+			}
+			unbound = append(unbound, featureId)
+		}
+		rows.Close()
+
+		for _, featureId := range unbound {
+			if err := t.activationValuesRepository.RecordBinding(c, tx, v, featureId, id, false); err != nil {
+				return false, err
+			}
+		}
+
+		return len(unbound) > 0, tx.Exec(c, `UPDATE services SET deleted_at = NOW() WHERE id = $1`, id)
+	})
+}
+
+// AddAccess binds the feature to the service, the service receives the feature's whole live state
+func (t *Repository) AddAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error {
+	return t.versioned(c, func(tx postgres.SQLTx, v int64) (bool, error) {
+		written, err := changed(tx.QueryRow(c, `
+INSERT INTO service_access(id, feature_id, service_id, v) VALUES($1,$2,$3,$4)
+ON CONFLICT (feature_id, service_id) DO UPDATE SET deleted_at = NULL, v = EXCLUDED.v
+WHERE service_access.deleted_at IS NOT NULL
+RETURNING id
+`, uuid.New(), featureId, serviceId, v))
+		if err != nil || !written {
+			return written, err
+		}
+
+		return true, t.activationValuesRepository.RecordBinding(c, tx, v, featureId, serviceId, true)
+	})
+}
+
+// RemoveAccess unbinds the feature, the service receives its tombstone
+func (t *Repository) RemoveAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error {
+	return t.versioned(c, func(tx postgres.SQLTx, v int64) (bool, error) {
+		written, err := changed(tx.QueryRow(c, `UPDATE service_access SET deleted_at = NOW(), v = $3 WHERE feature_id = $1 AND service_id = $2 AND deleted_at IS NULL RETURNING id`, featureId, serviceId, v))
+		if err != nil || !written {
+			return written, err
+		}
+
+		return true, t.activationValuesRepository.RecordBinding(c, tx, v, featureId, serviceId, false)
+	})
+}
+
+// changed reports whether a binding statement returned the row it wrote, an already live or missing binding is
+// left as is
+func changed(row postgres.SQLRow, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	var id uuid.UUID
+	return row.Scan(&id) == nil, nil
+}
+
+// versioned runs a binding change in a transaction under a new global version, the version is published once the
+// change committed and only if it wrote a binding
+func (t *Repository) versioned(c context.Context, change func(tx postgres.SQLTx, v int64) (bool, error)) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	v, err := t.activationValuesRepository.AllocateVersion(c, tx)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	written, err := change(tx, v)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
+	}
+	if written {
+		t.activationValuesRepository.Publish(c, v)
 	}
+
 	return nil
 }
 
-func (t *Repository) AddAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error {
-	if err := t.db.Exec(c, `INSERT INTO service_access(id, feature_id, service_id) VALUES($1,$2,$3) ON CONFLICT (feature_id, service_id) DO NOTHING`, uuid.New(), featureId, serviceId); err != nil {
+func (t *Repository) SetClientSide(c context.Context, featureId uuid.UUID, serviceId uuid.UUID, enabled bool) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	defer tx.Rollback(c)
+
+	row, err := tx.QueryRow(c, `UPDATE service_access SET client_side = $3 WHERE feature_id = $1 AND service_id = $2 AND deleted_at IS NULL RETURNING id`, featureId, serviceId, enabled)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var id uuid.UUID
+	if err := row.Scan(&id); err != nil {
+		return ErrAccessNotFound
+	}
+
+	// Client-side evaluation caches per version, publish a new one so the flag takes effect
+	v, err := t.activationValuesRepository.TouchFeature(c, tx, featureId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	if err := tx.Commit(c); err != nil {
 		t.logger.Error(c, err)
-		return err
+		return errs.FromDB(err)
 	}
+	t.activationValuesRepository.Publish(c, v)
+
 	return nil
 }
 
-func (t *Repository) RemoveAccess(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) error {
-	if err := t.db.Exec(c, `DELETE FROM service_access WHERE feature_id=$1 AND service_id=$2`, featureId, serviceId); err != nil {
+// GetServiceName returns the name clients address the service by, "<project>/<service>" outside the default project
+func (t *Repository) GetServiceName(c context.Context, serviceId uuid.UUID) (string, error) {
+	row, err := t.db.QueryRow(c, `SELECT services.name, projects.name FROM services JOIN projects ON projects.id = services.project_id WHERE services.id = $1 AND services.deleted_at IS NULL`, serviceId)
+	if err != nil {
 		t.logger.Error(c, err)
-		return err
+		return "", errs.FromDB(err)
 	}
-	return nil
+
+	var name, projectName string
+	if err := row.Scan(&name, &projectName); err != nil {
+		return "", ErrServiceNotFound
+	}
+
+	return ProjectRepository.QualifiedName(projectName, name), nil
 }
 
 func (t *Repository) GetAccess(c context.Context) ([]*db.ServiceAccess, error) {
-	rows, err := t.db.Query(c, `SELECT service_access.id, feature_id, service_id, name FROM service_access JOIN services ON service_access.service_id = services.id`)
+	rows, err := t.db.Query(c, `SELECT service_access.id, feature_id, service_id, services.name, projects.name, client_side FROM service_access JOIN services ON service_access.service_id = services.id JOIN projects ON projects.id = services.project_id WHERE service_access.deleted_at IS NULL`)
 	if err != nil {
 		t.logger.Error(c, err)
 		return nil, err
@@ -100,7 +281,7 @@ func (t *Repository) GetAccess(c context.Context) ([]*db.ServiceAccess, error) {
 	out := make([]*db.ServiceAccess, 0)
 	for rows.Next() {
 		s := &db.ServiceAccess{}
-		if err := rows.Scan(&s.ID, &s.FeatureId, &s.ServiceId, &s.Name); err != nil {
+		if err := rows.Scan(&s.ID, &s.FeatureId, &s.ServiceId, &s.Name, &s.ProjectName, &s.ClientSide); err != nil {
 			t.logger.Error(c, err)
 			continue
 		}
@@ -109,6 +290,26 @@ func (t *Repository) GetAccess(c context.Context) ([]*db.ServiceAccess, error) {
 	return out, nil
 }
 
+// GetFeaturesByService lists the features currently bound to the service
+func (t *Repository) GetFeaturesByService(c context.Context, serviceId uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := t.db.Query(c, `SELECT feature_id FROM service_access WHERE service_id = $1 AND deleted_at IS NULL`, serviceId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			t.logger.Error(c, err)
+			continue
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
 func (t *Repository) GetAccessByFeatures(c context.Context, featureIds []uuid.UUID) (map[uuid.UUID][]*db.ServiceAccess, error) {
 	// Short-circuit to avoid building an invalid IN () clause
 	if len(featureIds) == 0 {
@@ -124,10 +325,11 @@ func (t *Repository) GetAccessByFeatures(c context.Context, featureIds []uuid.UU
 	}
 
 	query := `
-    SELECT service_access.id, feature_id, service_id, name
+    SELECT service_access.id, feature_id, service_id, services.name, projects.name, client_side
     FROM service_access
     JOIN services ON service_access.service_id = services.id
-    WHERE feature_id IN (` + strings.Join(placeholders, ",") + `)
+    JOIN projects ON projects.id = services.project_id
+    WHERE feature_id IN (` + strings.Join(placeholders, ",") + `) AND service_access.deleted_at IS NULL
     `
 
 	rows, err := t.db.Query(c, query, args...)
@@ -141,7 +343,7 @@ func (t *Repository) GetAccessByFeatures(c context.Context, featureIds []uuid.UU
 
 	for rows.Next() {
 		s := &db.ServiceAccess{}
-		if err := rows.Scan(&s.ID, &s.FeatureId, &s.ServiceId, &s.Name); err != nil {
+		if err := rows.Scan(&s.ID, &s.FeatureId, &s.ServiceId, &s.Name, &s.ProjectName, &s.ClientSide); err != nil {
 			t.logger.Error(c, err)
 			continue
 		}
@@ -0,0 +1,249 @@
+package ServiceAccessRepository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+type exec struct {
+	query string
+	args  []any
+}
+
+// newTestRepository runs on a database whose version sequence hands out 7, statements after it are recorded,
+// binding statements answer with written and service deletions unbind the unbound features
+func newTestRepository(execs *[]exec, cache *redis.Mock, written []any, unbound [][]any) *Repository {
+	pg := &postgres.Mock{
+		QueryFunc: func(_ context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			*execs = append(*execs, exec{query: query, args: args})
+			return &postgres.MockRows{Values: unbound}, nil
+		},
+		QueryRowFunc: func(_ context.Context, query string, args ...any) (postgres.SQLRow, error) {
+			if strings.Contains(query, "nextval") {
+				return &postgres.MockRow{Values: []any{int64(7)}}, nil
+			}
+			*execs = append(*execs, exec{query: query, args: args})
+			return &postgres.MockRow{Values: written}, nil
+		},
+		ExecFunc: func(_ context.Context, query string, args ...any) error {
+			*execs = append(*execs, exec{query: query, args: args})
+			return nil
+		},
+	}
+	// Publishing takes its own lock, only the binding statements are recorded
+	values := ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(&postgres.Mock{}, cache, mock_log.New(true)), mock_log.New(true))
+
+	return NewForTest(pg, values, mock_log.New(true))
+}
+
+func TestRepository_bindingTransitions(t *testing.T) {
+	featureId, serviceId := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		change  func(r *Repository) error
+		written []any
+		unbound [][]any
+		want    []string
+		// event is the webhook event every outbox statement must carry for the service
+		event string
+		// version is the position of the version among the binding statement's args
+		version int
+		publish bool
+	}{
+		{
+			name:    "bind",
+			change:  func(r *Repository) error { return r.AddAccess(context.Background(), featureId, serviceId) },
+			written: []any{uuid.NewString()},
+			want:    []string{"ON CONFLICT (feature_id, service_id) DO UPDATE SET deleted_at = NULL, v = EXCLUDED.v", "INSERT INTO webhook_outbox"},
+			event:   "bound",
+			version: 3,
+			publish: true,
+		},
+		{
+			name:    "bind already bound",
+			change:  func(r *Repository) error { return r.AddAccess(context.Background(), featureId, serviceId) },
+			want:    []string{"ON CONFLICT (feature_id, service_id) DO UPDATE SET deleted_at = NULL, v = EXCLUDED.v"},
+			version: 3,
+		},
+		{
+			name:    "unbind",
+			change:  func(r *Repository) error { return r.RemoveAccess(context.Background(), featureId, serviceId) },
+			written: []any{uuid.NewString()},
+			want:    []string{"UPDATE service_access SET deleted_at = NOW(), v = $3", "INSERT INTO webhook_outbox"},
+			event:   "unbound",
+			version: 2,
+			publish: true,
+		},
+		{
+			name:    "unbind missing",
+			change:  func(r *Repository) error { return r.RemoveAccess(context.Background(), featureId, serviceId) },
+			want:    []string{"UPDATE service_access SET deleted_at = NOW(), v = $3"},
+			version: 2,
+		},
+		{
+			name:    "delete service",
+			change:  func(r *Repository) error { return r.DeleteService(context.Background(), serviceId) },
+			unbound: [][]any{{uuid.NewString()}, {uuid.NewString()}},
+			want:    []string{"UPDATE service_access SET deleted_at = NOW(), v = $2 WHERE service_id = $1", "INSERT INTO webhook_outbox", "INSERT INTO webhook_outbox", "UPDATE services SET deleted_at = NOW()"},
+			event:   "unbound",
+			version: 1,
+			publish: true,
+		},
+		{
+			name:    "delete unbound service",
+			change:  func(r *Repository) error { return r.DeleteService(context.Background(), serviceId) },
+			want:    []string{"UPDATE service_access SET deleted_at = NOW(), v = $2 WHERE service_id = $1", "UPDATE services SET deleted_at = NOW()"},
+			version: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var execs []exec
+			cache := &redis.Mock{Data: map[string]string{}}
+			r := newTestRepository(&execs, cache, tt.written, tt.unbound)
+
+			if err := tt.change(r); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(execs) != len(tt.want) {
+				t.Fatalf("expected %d statements, got %v", len(tt.want), execs)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(execs[i].query, want) {
+					t.Errorf("statement %d must contain %q, got %s", i, want, execs[i].query)
+				}
+				// Webhook subscribers of the service hear about the binding change in the binding's version
+				if strings.Contains(want, "webhook_outbox") && (execs[i].args[0] != tt.event || execs[i].args[6] != int64(7) || execs[i].args[7] != serviceId) {
+					t.Errorf("statement %d must queue a %s event of the service, got %v", i, tt.event, execs[i].args)
+				}
+			}
+
+			// The binding rows carry the version from the sequence, subscribers learn about it only if a row did
+			if v := execs[0].args[tt.version]; v != int64(7) {
+				t.Errorf("binding must be versioned 7, got %v", v)
+			}
+			if published := cache.Data["feature_version"] == "7"; published != tt.publish {
+				t.Errorf("expected published %v, got version %q", tt.publish, cache.Data["feature_version"])
+			}
+		})
+	}
+}
+
+// catalog models the services and bindings tables closely enough to follow a service through deletion and
+// recreation, and answers delta polls with the tombstones of removed bindings
+type catalog struct {
+	version  int64
+	services map[uuid.UUID]*serviceRow
+	bindings []*bindingRow
+}
+
+type serviceRow struct {
+	name    string
+	deleted bool
+}
+
+type bindingRow struct {
+	featureId uuid.UUID
+	serviceId uuid.UUID
+	v         int64
+	deleted   bool
+}
+
+func (t *catalog) db() *postgres.Mock {
+	return &postgres.Mock{
+		QueryRowFunc: func(_ context.Context, query string, args ...any) (postgres.SQLRow, error) {
+			switch {
+			case strings.Contains(query, "nextval"):
+				t.version++
+				return &postgres.MockRow{Values: []any{t.version}}, nil
+			case strings.Contains(query, "GREATEST"):
+				return &postgres.MockRow{Values: []any{t.version}}, nil
+			case strings.Contains(query, "UPDATE services SET deleted_at = NULL"):
+				for id, s := range t.services {
+					if s.deleted && s.name == args[1] {
+						s.deleted = false
+						return &postgres.MockRow{Values: []any{id.String()}}, nil
+					}
+				}
+			}
+			return &postgres.MockRow{}, nil
+		},
+		QueryFunc: func(_ context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			rows := make([][]any, 0)
+			switch {
+			case strings.Contains(query, "UPDATE service_access SET deleted_at"):
+				for _, b := range t.bindings {
+					if b.serviceId == args[0] && !b.deleted {
+						b.deleted, b.v = true, args[1].(int64)
+						rows = append(rows, []any{b.featureId.String()})
+					}
+				}
+			case strings.Contains(query, "FROM activation_values av"):
+				for _, b := range t.bindings {
+					if s := t.services[b.serviceId]; s != nil && s.name == args[1] && b.deleted && b.v > args[2].(int64) && b.v <= args[3].(int64) {
+						rows = append(rows, []any{b.featureId.String(), "checkout", nil, nil, nil, nil, 50, int64(1), time.Now(), nil, nil, nil, nil, nil, nil})
+					}
+				}
+			}
+			return &postgres.MockRows{Values: rows}, nil
+		},
+		ExecFunc: func(_ context.Context, query string, args ...any) error {
+			switch {
+			case strings.Contains(query, "UPDATE services SET deleted_at = NOW()"):
+				t.services[args[0].(uuid.UUID)].deleted = true
+			case strings.Contains(query, "INSERT INTO services"):
+				t.services[args[0].(uuid.UUID)] = &serviceRow{name: args[2].(string)}
+			case strings.Contains(query, "DELETE FROM service_access"), strings.Contains(query, "DELETE FROM services"):
+				return fmt.Errorf("tombstones must be kept: %s", query)
+			}
+			return nil
+		},
+	}
+}
+
+func TestRepository_RecreatedServiceKeepsTombstones(t *testing.T) {
+	c := context.Background()
+	featureId, serviceId := uuid.New(), uuid.New()
+	state := &catalog{
+		version:  1,
+		services: map[uuid.UUID]*serviceRow{serviceId: {name: "web"}},
+		bindings: []*bindingRow{{featureId: featureId, serviceId: serviceId, v: 1}},
+	}
+	pg := state.db()
+	versions := VersionRepository.NewForTest(&postgres.Mock{}, &redis.Mock{Data: map[string]string{"feature_version": "1"}}, mock_log.New(true))
+	values := ActivationValuesRepository.NewForTest(pg, nil, nil, versions, mock_log.New(true))
+	r := NewForTest(pg, values, mock_log.New(true))
+
+	if err := r.DeleteService(c, serviceId); err != nil {
+		t.Fatal(err)
+	}
+	id, err := r.CreateService(c, uuid.New(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != serviceId {
+		t.Errorf("the deleted service must come back under its id, got %s", id)
+	}
+
+	// A client that synced before the deletion still learns the feature is gone
+	_, features, err := values.GetNewByServiceName(c, "web", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 1 || features[0].Name != "checkout" || !features[0].IsDeleted {
+		t.Fatalf("expected the tombstone of checkout, got %+v", features)
+	}
+}
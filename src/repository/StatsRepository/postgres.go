@@ -0,0 +1,124 @@
+package StatsRepository
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/memory"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+const (
+	kindFeature = "feature"
+	kindService = "service"
+)
+
+// writeEvery limits writes of one usage mark per instance, clients report on every evaluation
+const writeEvery = time.Minute
+
+// PostgresRepository keeps usage marks in Postgres for deployments without Redis
+type PostgresRepository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+	cache  memory.IMemory
+}
+
+func NewPostgres(name string) *PostgresRepository {
+	return &PostgresRepository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewPostgresForTest(db postgres.IPostgres, cache memory.IMemory, logger interfaces.ILogger) *PostgresRepository {
+	return &PostgresRepository{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+func (t *PostgresRepository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	t.cache = app.GetPkg(interfaces.PkgCache, names.CacheMemory).(memory.IMemory)
+
+	return nil
+}
+
+func (t *PostgresRepository) SetStat(c context.Context, serviceName string, featureName string) {
+	t.mark(c, kindFeature, featureKey(serviceName, featureName))
+	t.mark(c, kindService, serviceName)
+}
+
+func (t *PostgresRepository) IsUsed(c context.Context, featureName string) bool {
+	return t.isUsed(c, kindFeature, featureName)
+}
+
+func (t *PostgresRepository) IsServiceUsed(c context.Context, serviceName string) bool {
+	return t.isUsed(c, kindService, serviceName)
+}
+
+// mark skips the write while the memory cache remembers a recent one
+func (t *PostgresRepository) mark(c context.Context, kind string, name string) {
+	key := "stat_" + kind + ":" + name
+	if t.cache.Has(c, key) {
+		return
+	}
+
+	err := t.db.Exec(c, `
+INSERT INTO usage_stats (kind, name, seen_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (kind, name) DO UPDATE SET seen_at = EXCLUDED.seen_at
+`, kind, name)
+	if err != nil {
+		t.logger.Error(c, err)
+		return
+	}
+
+	_ = t.cache.Set(c, key, 1, writeEvery)
+}
+
+func (t *PostgresRepository) FeatureSeenAt(c context.Context, featureName string) (time.Time, bool) {
+	return t.seenAt(c, kindFeature, featureName)
+}
+
+func (t *PostgresRepository) ServiceSeenAt(c context.Context, serviceName string) (time.Time, bool) {
+	return t.seenAt(c, kindService, serviceName)
+}
+
+func (t *PostgresRepository) seenAt(c context.Context, kind string, name string) (time.Time, bool) {
+	row, err := t.db.QueryRow(c, `SELECT seen_at FROM usage_stats WHERE kind = $1 AND name = $2 AND seen_at > NOW() - $3::interval`, kind, name, usedFor.String())
+	if err != nil {
+		t.logger.Error(c, err)
+		return time.Time{}, false
+	}
+
+	var seenAt time.Time
+	if err := row.Scan(&seenAt); err != nil {
+		return time.Time{}, false
+	}
+
+	return seenAt, true
+}
+
+func (t *PostgresRepository) isUsed(c context.Context, kind string, name string) bool {
+	row, err := t.db.QueryRow(c, `SELECT EXISTS (SELECT 1 FROM usage_stats WHERE kind = $1 AND name = $2 AND seen_at > NOW() - $3::interval)`, kind, name, usedFor.String())
+	if err != nil {
+		t.logger.Error(c, err)
+		return false
+	}
+
+	var used bool
+	if err := row.Scan(&used); err != nil {
+		t.logger.Error(c, err)
+		return false
+	}
+
+	return used
+}
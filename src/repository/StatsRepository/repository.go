@@ -2,14 +2,20 @@ package StatsRepository
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
 	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
 )
 
+// usedFor is how long a feature or service counts as used after the last report
+const usedFor = 30 * time.Minute
+
+// Repository keeps usage marks in Redis with usedFor as expiry
 type Repository struct {
 	repository.Mock
 	cache redis.IRedis
@@ -29,9 +35,12 @@ func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) erro
 	return nil
 }
 
+// SetStat keys features by the project of the reporting service, feature names are unique only within a project.
+// The marks hold the report time so deletion checks can tell when the entity was last seen
 func (t *Repository) SetStat(c context.Context, serviceName string, featureName string) {
-	_ = t.cache.Set(c, "stat_used:"+featureName, 1, 30*time.Minute)
-	_ = t.cache.Set(c, "stat_service_used:"+serviceName, 1, 30*time.Minute)
+	now := time.Now().Unix()
+	_ = t.cache.Set(c, "stat_used:"+featureKey(serviceName, featureName), now, usedFor)
+	_ = t.cache.Set(c, "stat_service_used:"+serviceName, now, usedFor)
 }
 
 func (t *Repository) IsUsed(c context.Context, featureName string) bool {
@@ -41,3 +50,31 @@ func (t *Repository) IsUsed(c context.Context, featureName string) bool {
 func (t *Repository) IsServiceUsed(c context.Context, serviceName string) bool {
 	return t.cache.Has(c, "stat_service_used:"+serviceName)
 }
+
+func (t *Repository) FeatureSeenAt(c context.Context, featureName string) (time.Time, bool) {
+	return t.seenAt(c, "stat_used:"+featureName)
+}
+
+func (t *Repository) ServiceSeenAt(c context.Context, serviceName string) (time.Time, bool) {
+	return t.seenAt(c, "stat_service_used:"+serviceName)
+}
+
+func (t *Repository) seenAt(c context.Context, key string) (time.Time, bool) {
+	value, err := t.cache.Get(c, key)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Marks written before they carried the time read as used at an unknown moment
+	at, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || at <= 1 {
+		return time.Time{}, true
+	}
+
+	return time.Unix(at, 0), true
+}
+
+func featureKey(serviceName string, featureName string) string {
+	project, _ := ProjectRepository.SplitServiceName(serviceName)
+	return ProjectRepository.QualifiedName(project, featureName)
+}
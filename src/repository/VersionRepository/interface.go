@@ -0,0 +1,16 @@
+package VersionRepository
+
+import (
+	"context"
+	"time"
+)
+
+// Interface holds the global configuration version subscribers compare against
+type Interface interface {
+	// Bump publishes v as the latest version
+	Bump(c context.Context, v int64) error
+	// Get returns the latest version and when it was published, rebuilding both from Postgres when unknown
+	Get(c context.Context) (int64, time.Time, error)
+	// Verify fails when the published version is behind Postgres, subscribers would miss committed changes
+	Verify(c context.Context) error
+}
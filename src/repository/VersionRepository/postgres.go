@@ -0,0 +1,109 @@
+package VersionRepository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/memory"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+// localTTL bounds how long an instance serves its local copy before it sees writes made through other instances
+const localTTL = time.Second
+
+type published struct {
+	version int64
+	at      time.Time
+}
+
+// PostgresRepository keeps the version without Redis: Postgres is the source, the memory cache holds it per instance
+type PostgresRepository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+	cache  memory.IMemory
+
+	// mu serializes rebuilds so a burst of pollers costs one query
+	mu sync.Mutex
+}
+
+func NewPostgres(name string) *PostgresRepository {
+	return &PostgresRepository{Mock: repository.Mock{NamePkg: name}}
+}
+
+func NewPostgresForTest(db postgres.IPostgres, cache memory.IMemory, logger interfaces.ILogger) *PostgresRepository {
+	return &PostgresRepository{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+func (t *PostgresRepository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	t.cache = app.GetPkg(interfaces.PkgCache, names.CacheMemory).(memory.IMemory)
+
+	return nil
+}
+
+// Bump makes the version visible to subscribers of this instance at once, others catch up within localTTL
+func (t *PostgresRepository) Bump(c context.Context, v int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Transactions publish after commit in any order, a late one must not take the version back
+	if p, ok := t.cached(c); ok && p.version >= v {
+		return nil
+	}
+
+	return t.cache.Set(c, keyVersion, published{version: v, at: time.Now()}, localTTL)
+}
+
+func (t *PostgresRepository) Get(c context.Context) (int64, time.Time, error) {
+	if p, ok := t.cached(c); ok {
+		return p.version, p.at, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.cached(c); ok {
+		return p.version, p.at, nil
+	}
+
+	version, at, err := latest(c, t.db)
+	if err != nil {
+		t.logger.Error(c, err)
+		return -1, time.Time{}, err
+	}
+
+	if err := t.cache.Set(c, keyVersion, published{version: version, at: at}, localTTL); err != nil {
+		t.logger.Error(c, err)
+	}
+
+	return version, at, nil
+}
+
+func (t *PostgresRepository) Verify(c context.Context) error {
+	p, ok := t.cached(c)
+	if !ok {
+		return nil
+	}
+
+	return compare(c, t.db, p.version)
+}
+
+func (t *PostgresRepository) cached(c context.Context) (published, bool) {
+	v, err := t.cache.Get(c, keyVersion)
+	if err != nil {
+		return published{}, false
+	}
+
+	p, ok := v.(published)
+	return p, ok
+}
@@ -0,0 +1,181 @@
+package VersionRepository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+const (
+	keyVersion   = "feature_version"
+	keyVersionAt = "feature_version_at"
+	versionTTL   = 365 * 24 * time.Hour
+
+	// publishLock serializes the compare-and-set of the shared version between instances. The redis package has no
+	// scripting or transactions, the lock is taken in Postgres which every instance shares
+	publishLock = 4651320746193876
+)
+
+// Repository shares the version between instances through Redis
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+	cache  redis.IRedis
+}
+
+func New(name string) *Repository {
+	return &Repository{Mock: repository.Mock{NamePkg: name}}
+}
+
+func NewForTest(db postgres.IPostgres, cache redis.IRedis, logger interfaces.ILogger) *Repository {
+	return &Repository{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+	t.cache = app.GetPkg(interfaces.PkgCache, names.CacheRedis).(redis.IRedis)
+
+	return nil
+}
+
+func (t *Repository) Bump(c context.Context, v int64) error {
+	// The bump time backs Last-Modified of the cacheable updates endpoint
+	return t.publish(c, v, time.Now())
+}
+
+// publish sets the shared version unless a newer one is already there. Transactions commit one after another but
+// publish in any order, a late one must not take the version back
+func (t *Repository) publish(c context.Context, v int64, at time.Time) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(c)
+
+	if err := tx.Exec(c, `SELECT pg_advisory_xact_lock($1)`, publishLock); err != nil {
+		return err
+	}
+
+	if current, err := t.cache.Get(c, keyVersion); err == nil {
+		if published, err := strconv.ParseInt(current, 10, 64); err == nil && published >= v {
+			return nil
+		}
+	}
+
+	if err := t.cache.Set(c, keyVersion, v, versionTTL); err != nil {
+		return err
+	}
+	if !at.IsZero() {
+		if err := t.cache.Set(c, keyVersionAt, at.Unix(), versionTTL); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(c)
+}
+
+func (t *Repository) Get(c context.Context) (int64, time.Time, error) {
+	versionStr, err := t.cache.Get(c, keyVersion)
+	if err != nil {
+		// Redis was flushed or is fresh, subscribers would stall on -1 until the next write
+		return t.rebuild(c)
+	}
+
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		t.logger.Error(c, err)
+		return t.rebuild(c)
+	}
+
+	atStr, err := t.cache.Get(c, keyVersionAt)
+	if err != nil {
+		return version, time.Time{}, nil
+	}
+
+	at, err := strconv.ParseInt(atStr, 10, 64)
+	if err != nil {
+		return version, time.Time{}, nil
+	}
+
+	return version, time.Unix(at, 0), nil
+}
+
+func (t *Repository) Verify(c context.Context) error {
+	versionStr, err := t.cache.Get(c, keyVersion)
+	if err != nil {
+		// Not published yet, the next Get rebuilds it from Postgres
+		return nil
+	}
+
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cached version %q is not a number", versionStr)
+	}
+
+	return compare(c, t.db, version)
+}
+
+func (t *Repository) rebuild(c context.Context) (int64, time.Time, error) {
+	version, at, err := latest(c, t.db)
+	if err != nil {
+		t.logger.Error(c, err)
+		return -1, time.Time{}, err
+	}
+
+	// A bump racing the rebuild may already have published a newer version
+	if err := t.publish(c, version, at); err != nil {
+		t.logger.Error(c, err)
+	}
+
+	return version, at, nil
+}
+
+// compare checks a published version against the one in Postgres. Ahead is fine: versions come from a sequence and
+// one taken by a change that wrote no row leaves a gap
+func compare(c context.Context, db postgres.IPostgres, version int64) error {
+	stored, _, err := latest(c, db)
+	if err != nil {
+		return err
+	}
+	if version < stored {
+		return fmt.Errorf("cached version %d is behind %d in postgres", version, stored)
+	}
+
+	return nil
+}
+
+// latest reads the version straight from values and service bindings, the time is zero while there are no values
+func latest(c context.Context, db postgres.IPostgres) (int64, time.Time, error) {
+	row, err := db.QueryRow(c, `
+SELECT GREATEST(COALESCE(MAX(v), 0), (SELECT COALESCE(MAX(v), 0) FROM service_access)), MAX(updated_at)
+FROM activation_values`)
+	if err != nil {
+		return -1, time.Time{}, err
+	}
+
+	var version int64
+	var at *time.Time
+	if err := row.Scan(&version, &at); err != nil {
+		return -1, time.Time{}, err
+	}
+
+	if at == nil {
+		return version, time.Time{}, nil
+	}
+
+	return version, *at, nil
+}
@@ -0,0 +1,232 @@
+package VersionRepository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+// memoryCache is an in-process memory.IMemory honouring expiry
+type memoryCache struct {
+	mu      sync.Mutex
+	values  map[string]any
+	expires map[string]time.Time
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string]any{}, expires: map[string]time.Time{}}
+}
+
+func (m *memoryCache) Init(map[string]interface{}) error { return nil }
+func (m *memoryCache) Stop() error                       { return nil }
+func (m *memoryCache) Name() string                      { return "memory" }
+func (m *memoryCache) Type() interfaces.PkgType          { return interfaces.PkgCache }
+
+func (m *memoryCache) Has(c context.Context, key string) bool {
+	_, err := m.Get(c, key)
+	return err == nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, v any, timeout time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = v
+	m.expires[key] = time.Now().Add(timeout)
+	return nil
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Now().After(m.expires[key]) {
+		return nil, redis.ErrKeyNotFound
+	}
+	return m.values[key], nil
+}
+
+func (m *memoryCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// maxV answers the MAX(v) query and counts how often it ran
+func maxV(v int64, at time.Time, queries *int) *postgres.Mock {
+	return &postgres.Mock{
+		QueryRowFunc: func(context.Context, string, ...any) (postgres.SQLRow, error) {
+			*queries++
+			return &postgres.MockRow{Values: []any{v, at}}, nil
+		},
+	}
+}
+
+func TestRepository_Get(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+
+	t.Run("cached", func(t *testing.T) {
+		queries := 0
+		r := NewForTest(maxV(7, at, &queries), &redis.Mock{Data: map[string]string{keyVersion: "9", keyVersionAt: "1760000100"}}, mock_log.New(true))
+
+		v, modified, err := r.Get(context.Background())
+		if err != nil || v != 9 || modified.Unix() != 1760000100 {
+			t.Fatalf("unexpected %d %v %v", v, modified, err)
+		}
+		if queries != 0 {
+			t.Errorf("cached version must not query postgres")
+		}
+	})
+
+	t.Run("rebuilds after flush", func(t *testing.T) {
+		queries := 0
+		cache := &redis.Mock{Data: map[string]string{}}
+		r := NewForTest(maxV(7, at, &queries), cache, mock_log.New(true))
+
+		v, modified, err := r.Get(context.Background())
+		if err != nil || v != 7 || !modified.Equal(at) {
+			t.Fatalf("unexpected %d %v %v", v, modified, err)
+		}
+		if cache.Data[keyVersion] != "7" {
+			t.Errorf("rebuilt version must be written back, got %q", cache.Data[keyVersion])
+		}
+
+		if v, _, _ := r.Get(context.Background()); v != 7 || queries != 1 {
+			t.Errorf("second read must come from redis, version %d queries %d", v, queries)
+		}
+	})
+
+	t.Run("bump", func(t *testing.T) {
+		queries := 0
+		r := NewForTest(maxV(7, at, &queries), &redis.Mock{}, mock_log.New(true))
+
+		if err := r.Bump(context.Background(), 8); err != nil {
+			t.Fatal(err)
+		}
+		if v, _, _ := r.Get(context.Background()); v != 8 || queries != 0 {
+			t.Errorf("expected bumped version 8 without queries, got %d after %d queries", v, queries)
+		}
+	})
+}
+
+func TestPostgresRepository_Get(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+	queries := 0
+	r := NewPostgresForTest(maxV(7, at, &queries), newMemoryCache(), mock_log.New(true))
+
+	v, modified, err := r.Get(context.Background())
+	if err != nil || v != 7 || !modified.Equal(at) {
+		t.Fatalf("startup must rebuild from postgres, got %d %v %v", v, modified, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Get(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if queries != 1 {
+		t.Errorf("hot reads must come from memory, %d queries", queries)
+	}
+
+	if err := r.Bump(context.Background(), 8); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, _ := r.Get(context.Background()); v != 8 {
+		t.Errorf("bump must be visible at once, got %d", v)
+	}
+}
+
+func TestRepository_BumpKeepsNewest(t *testing.T) {
+	queries := 0
+	r := NewForTest(maxV(7, time.Time{}, &queries), &redis.Mock{Data: map[string]string{keyVersion: "9"}}, mock_log.New(true))
+	p := NewPostgresForTest(maxV(7, time.Time{}, &queries), newMemoryCache(), mock_log.New(true))
+
+	for _, version := range []Interface{r, p} {
+		// A transaction that committed earlier publishes after a newer one
+		if err := version.Bump(context.Background(), 10); err != nil {
+			t.Fatal(err)
+		}
+		if err := version.Bump(context.Background(), 8); err != nil {
+			t.Fatal(err)
+		}
+		if v, _, _ := version.Get(context.Background()); v != 10 {
+			t.Errorf("%T: late bump must not take the version back, got %d", version, v)
+		}
+	}
+}
+
+func TestRepository_BumpUnderLock(t *testing.T) {
+	cache := &redis.Mock{Data: map[string]string{keyVersion: "9"}}
+	locked := 0
+	pg := &postgres.Mock{
+		ExecFunc: func(_ context.Context, query string, args ...any) error {
+			if strings.Contains(query, "pg_advisory_xact_lock") && len(args) == 1 && args[0] == publishLock {
+				locked++
+			}
+			return nil
+		},
+	}
+	r := NewForTest(pg, cache, mock_log.New(true))
+
+	if err := r.Bump(context.Background(), 11); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Bump(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if locked != 2 || cache.Data[keyVersion] != "11" {
+		t.Errorf("expected both publishes under the lock keeping 11, got %d locks and %q", locked, cache.Data[keyVersion])
+	}
+}
+
+func TestPostgresRepository_GetEmpty(t *testing.T) {
+	pg := &postgres.Mock{
+		QueryRowFunc: func(context.Context, string, ...any) (postgres.SQLRow, error) {
+			return &postgres.MockRow{Values: []any{int64(0), nil}}, nil
+		},
+	}
+	r := NewPostgresForTest(pg, newMemoryCache(), mock_log.New(true))
+
+	v, modified, err := r.Get(context.Background())
+	if err != nil || v != 0 || !modified.IsZero() {
+		t.Fatalf("empty table must be version 0 without time, got %d %v %v", v, modified, err)
+	}
+}
+
+func TestRepository_Verify(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+	queries := 0
+
+	tests := []struct {
+		name   string
+		cached map[string]string
+		ok     bool
+	}{
+		{name: "in sync", cached: map[string]string{keyVersion: "7"}, ok: true},
+		{name: "ahead", cached: map[string]string{keyVersion: "8"}, ok: true},
+		{name: "not published", cached: map[string]string{}, ok: true},
+		{name: "behind", cached: map[string]string{keyVersion: "6"}},
+		{name: "garbage", cached: map[string]string{keyVersion: "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewForTest(maxV(7, at, &queries), &redis.Mock{Data: tt.cached}, mock_log.New(true))
+			if err := r.Verify(context.Background()); (err == nil) != tt.ok {
+				t.Errorf("expected ok %v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package WebhookRepository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	ListWebhooks(c context.Context, projectId uuid.UUID) ([]*db.Webhook, error)
+	CreateWebhook(c context.Context, hook *db.Webhook) (uuid.UUID, error)
+	UpdateWebhook(c context.Context, hook *db.Webhook) error
+	DeleteWebhook(c context.Context, id uuid.UUID) error
+
+	// Dispatch fans undispatched outbox events out into deliveries of matching subscriptions
+	Dispatch(c context.Context, limit int) (int, error)
+	// ClaimDue leases due deliveries until leaseUntil so concurrent workers do not send them twice
+	ClaimDue(c context.Context, limit int, leaseUntil time.Time) ([]*db.WebhookDelivery, error)
+	// RecordAttempt logs an attempt and schedules the next one, nil nextAttemptAt with a failure gives up
+	RecordAttempt(c context.Context, delivery *db.WebhookDelivery, statusCode int, deliveryErr error, duration time.Duration, nextAttemptAt *time.Time) error
+
+	ListDeliveryLog(c context.Context, webhookId uuid.UUID, limit int) ([]*db.WebhookDeliveryLog, error)
+}
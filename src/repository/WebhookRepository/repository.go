@@ -0,0 +1,309 @@
+package WebhookRepository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/repository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+)
+
+var ErrWebhookNotFound = errs.NotFound("webhook_not_found", "webhook not found")
+
+type Repository struct {
+	repository.Mock
+	logger interfaces.ILogger
+	db     postgres.IPostgres
+}
+
+func New(name string) *Repository {
+	return &Repository{
+		Mock: repository.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Repository) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.db = app.GetPkg(interfaces.PkgDatabase, names.DatabasePrimary).(postgres.IPostgres)
+
+	return nil
+}
+
+func (t *Repository) ListWebhooks(c context.Context, projectId uuid.UUID) ([]*db.Webhook, error) {
+	rows, err := t.db.Query(c, `
+SELECT id, project_id, url, secret, feature_names, service_names, event_types, is_active, created_at
+FROM webhooks
+WHERE project_id = $1
+ORDER BY created_at
+`, projectId)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	res := make([]*db.Webhook, 0)
+
+	for rows.Next() {
+		var item db.Webhook
+		if err := rows.Scan(&item.Id, &item.ProjectId, &item.Url, &item.Secret, &item.FeatureNames, &item.ServiceNames, &item.EventTypes, &item.IsActive, &item.CreatedAt); err != nil {
+			t.logger.Error(c, err)
+			return nil, err
+		}
+		res = append(res, &item)
+	}
+
+	return res, nil
+}
+
+func (t *Repository) CreateWebhook(c context.Context, hook *db.Webhook) (uuid.UUID, error) {
+	id := uuid.New()
+	err := t.db.Exec(c, `
+INSERT INTO webhooks (id, project_id, url, secret, feature_names, service_names, event_types, is_active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`, id, hook.ProjectId, hook.Url, hook.Secret, nonNil(hook.FeatureNames), nonNil(hook.ServiceNames), nonNil(hook.EventTypes), hook.IsActive)
+	if err != nil {
+		t.logger.Error(c, err)
+		return uuid.Nil, errs.FromDB(err)
+	}
+
+	return id, nil
+}
+
+// UpdateWebhook keeps the stored secret when hook.Secret is empty
+func (t *Repository) UpdateWebhook(c context.Context, hook *db.Webhook) error {
+	row, err := t.db.QueryRow(c, `
+UPDATE webhooks
+SET url = $2,
+    secret = COALESCE(NULLIF($3, ''), secret),
+    feature_names = $4,
+    service_names = $5,
+    event_types = $6,
+    is_active = $7
+WHERE id = $1
+RETURNING id
+`, hook.Id, hook.Url, hook.Secret, nonNil(hook.FeatureNames), nonNil(hook.ServiceNames), nonNil(hook.EventTypes), hook.IsActive)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	var id uuid.UUID
+	if err := row.Scan(&id); err != nil {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (t *Repository) DeleteWebhook(c context.Context, id uuid.UUID) error {
+	err := t.db.Exec(c, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		t.logger.Error(c, err)
+		return errs.FromDB(err)
+	}
+
+	return nil
+}
+
+func (t *Repository) Dispatch(c context.Context, limit int) (int, error) {
+	// Single statement: the batch is locked, fanned out and marked dispatched atomically
+	row, err := t.db.QueryRow(c, `
+WITH batch AS (
+    SELECT id
+    FROM webhook_outbox
+    WHERE dispatched_at IS NULL
+    ORDER BY id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+), fanout AS (
+    INSERT INTO webhook_deliveries (webhook_id, outbox_id)
+    SELECT w.id, o.id
+    FROM webhook_outbox o
+    JOIN batch b ON b.id = o.id
+    JOIN webhooks w ON w.is_active AND w.project_id = o.project_id
+    WHERE (cardinality(w.event_types) = 0 OR o.event_type = ANY(w.event_types))
+      AND (cardinality(w.feature_names) = 0 OR o.feature_name = ANY(w.feature_names))
+      AND (cardinality(w.service_names) = 0 OR o.service_names && w.service_names)
+    ON CONFLICT (webhook_id, outbox_id) DO NOTHING
+), marked AS (
+    UPDATE webhook_outbox
+    SET dispatched_at = NOW()
+    WHERE id IN (SELECT id FROM batch)
+    RETURNING id
+)
+SELECT COUNT(*) FROM marked
+`, limit)
+	if err != nil {
+		t.logger.Error(c, err)
+		return 0, err
+	}
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		t.logger.Error(c, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (t *Repository) ClaimDue(c context.Context, limit int, leaseUntil time.Time) ([]*db.WebhookDelivery, error) {
+	rows, err := t.db.Query(c, `
+WITH due AS (
+    SELECT id
+    FROM webhook_deliveries
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = $2,
+    attempts = d.attempts + 1,
+    updated_at = NOW()
+FROM due, webhook_outbox o, webhooks w
+WHERE d.id = due.id
+  AND o.id = d.outbox_id
+  AND w.id = d.webhook_id
+RETURNING
+    d.id,
+    d.attempts,
+    w.url,
+    w.secret,
+    o.id,
+    o.event_type,
+    o.feature_name,
+    o.key_name,
+    o.param_name,
+    o.value_before,
+    o.value_after,
+    o.service_name,
+    o.previous_name,
+    o.version,
+    o.created_at
+`, limit, leaseUntil)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	res := make([]*db.WebhookDelivery, 0)
+
+	for rows.Next() {
+		var item db.WebhookDelivery
+		e := &item.Event
+		if err := rows.Scan(&item.Id, &item.Attempt, &item.Url, &item.Secret, &e.Id, &e.EventType, &e.FeatureName, &e.KeyName, &e.ParamName, &e.Before, &e.After, &e.ServiceName, &e.PreviousName, &e.Version, &e.CreatedAt); err != nil {
+			t.logger.Error(c, err)
+			return nil, err
+		}
+		res = append(res, &item)
+	}
+
+	return res, nil
+}
+
+func (t *Repository) RecordAttempt(c context.Context, delivery *db.WebhookDelivery, statusCode int, deliveryErr error, duration time.Duration, nextAttemptAt *time.Time) error {
+	tx, err := t.db.BeginTx(c)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	defer tx.Rollback(c)
+
+	errText := ""
+	if deliveryErr != nil {
+		errText = deliveryErr.Error()
+	}
+
+	err = tx.Exec(c, `
+INSERT INTO webhook_delivery_log (delivery_id, attempt, status_code, error, duration_ms)
+VALUES ($1, $2, $3, $4, $5)
+`, delivery.Id, delivery.Attempt, statusCode, errText, duration.Milliseconds())
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	status := db.WebhookDeliveryDelivered
+	if deliveryErr != nil {
+		status = db.WebhookDeliveryPending
+		if nextAttemptAt == nil {
+			status = db.WebhookDeliveryFailed
+		}
+	}
+
+	err = tx.Exec(c, `
+UPDATE webhook_deliveries
+SET status = $2,
+    last_error = $3,
+    next_attempt_at = COALESCE($4, next_attempt_at),
+    updated_at = NOW()
+WHERE id = $1
+`, delivery.Id, status, errText, nextAttemptAt)
+	if err != nil {
+		t.logger.Error(c, err)
+		return err
+	}
+
+	return tx.Commit(c)
+}
+
+func (t *Repository) ListDeliveryLog(c context.Context, webhookId uuid.UUID, limit int) ([]*db.WebhookDeliveryLog, error) {
+	rows, err := t.db.Query(c, `
+SELECT
+    l.id,
+    d.id,
+    o.id,
+    o.event_type,
+    o.feature_name,
+    d.status,
+    l.attempt,
+    l.status_code,
+    l.error,
+    l.duration_ms,
+    l.created_at
+FROM webhook_delivery_log l
+JOIN webhook_deliveries d ON d.id = l.delivery_id
+JOIN webhook_outbox o ON o.id = d.outbox_id
+WHERE d.webhook_id = $1
+ORDER BY l.id DESC
+LIMIT $2
+`, webhookId, limit)
+	if err != nil {
+		t.logger.Error(c, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	res := make([]*db.WebhookDeliveryLog, 0)
+
+	for rows.Next() {
+		var item db.WebhookDeliveryLog
+		if err := rows.Scan(&item.Id, &item.DeliveryId, &item.EventId, &item.EventType, &item.FeatureName, &item.Status, &item.Attempt, &item.StatusCode, &item.Error, &item.DurationMs, &item.CreatedAt); err != nil {
+			t.logger.Error(c, err)
+			return nil, err
+		}
+		res = append(res, &item)
+	}
+
+	return res, nil
+}
+
+// nonNil keeps empty filters as '{}' instead of NULL
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -0,0 +1,48 @@
+package BootstrapService
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Format identifies the bootstrap file layout, SDKs reject files of an unknown format
+const Format = "featurechaos-bootstrap/v1"
+
+var ErrWrongService = errors.New("bootstrap file belongs to another service")
+
+// Bootstrap is the JWS payload of a bootstrap file, Snapshot is a GetFeatureResponse in proto JSON
+type Bootstrap struct {
+	Format      string          `json:"format"`
+	ServiceName string          `json:"service_name"`
+	Version     int64           `json:"version"`
+	IssuedAt    int64           `json:"iat"`
+	Snapshot    json.RawMessage `json:"snapshot"`
+}
+
+// Parse verifies the file and decodes its snapshot, the same steps an SDK performs before using a file from disk
+func Parse(key ed25519.PublicKey, file []byte, serviceName string) (*Bootstrap, *FeatureChaos.GetFeatureResponse, error) {
+	var b Bootstrap
+	if err := SignService.VerifyToken(key, strings.TrimSpace(string(file)), &b); err != nil {
+		return nil, nil, err
+	}
+
+	if b.Format != Format {
+		return nil, nil, SignService.ErrMalformedToken
+	}
+	if b.ServiceName != serviceName {
+		return nil, nil, ErrWrongService
+	}
+
+	snapshot := &FeatureChaos.GetFeatureResponse{}
+	if err := protojson.Unmarshal(b.Snapshot, snapshot); err != nil {
+		return nil, nil, err
+	}
+
+	return &b, snapshot, nil
+}
@@ -0,0 +1,8 @@
+package BootstrapService
+
+import "context"
+
+type Interface interface {
+	// Build returns the signed bootstrap file of the service and the version it captures
+	Build(c context.Context, serviceName string) ([]byte, int64, error)
+}
@@ -0,0 +1,68 @@
+package BootstrapService
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/controller/FeatureChaos"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type Service struct {
+	service.Mock
+	featureService FeatureService.Interface
+	signService    SignService.Interface
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewForTest(featureService FeatureService.Interface, signService SignService.Interface) *Service {
+	return &Service{
+		featureService: featureService,
+		signService:    signService,
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.featureService = app.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface)
+	t.signService = app.GetModule(interfaces.ModuleService, names.SignService).(SignService.Interface)
+
+	return nil
+}
+
+func (t *Service) Build(c context.Context, serviceName string) ([]byte, int64, error) {
+	// An empty file signed over a failed read would pass verification and start clients with nothing enabled
+	version, features, err := t.featureService.Snapshot(c, serviceName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snapshot, err := protojson.Marshal(FeatureChaos.ToFeatureResponse(version, features))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	token, err := t.signService.Sign(Bootstrap{
+		Format:      Format,
+		ServiceName: serviceName,
+		Version:     version,
+		IssuedAt:    time.Now().Unix(),
+		Snapshot:    snapshot,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []byte(token), version, nil
+}
@@ -0,0 +1,118 @@
+package BootstrapService
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+func newTestService() (*Service, ed25519.PublicKey) {
+	featureId := uuid.New()
+	keyId := uuid.New()
+	deletedId := uuid.New()
+
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			return &postgres.MockRows{
+				Values: [][]any{
+					{featureId.String(), "checkout", nil, nil, nil, nil, 50, int64(3), nil, nil, nil, nil, nil, nil, nil},
+					{featureId.String(), "checkout", keyId.String(), "country", nil, nil, -1, int64(3), nil, nil, nil, nil, nil, nil, nil},
+					{featureId.String(), "checkout", keyId.String(), "country", uuid.New().String(), "US", 100, int64(3), nil, nil, nil, nil, nil, nil, nil},
+					{featureId.String(), "checkout", keyId.String(), "country", uuid.New().String(), "RU", 0, int64(2), "2025-01-01", nil, nil, nil, nil, nil, nil},
+					{deletedId.String(), "legacy", nil, nil, nil, nil, 0, int64(1), "2025-01-01", nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "3"}}
+
+	features := FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)))
+	signer := SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	_, pub := signer.PublicKey()
+
+	return NewForTest(features, signer), pub
+}
+
+func TestBuildAndParse(t *testing.T) {
+	s, pub := newTestService()
+
+	file, version, err := s.Build(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 3 {
+		t.Errorf("unexpected version %d", version)
+	}
+
+	b, snapshot, err := Parse(pub, file, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != 3 || snapshot.Version != 3 {
+		t.Errorf("unexpected versions %d %d", b.Version, snapshot.Version)
+	}
+
+	// A snapshot is the live state only: no deletion markers, deleted entities left out
+	if len(snapshot.Deleted) != 0 || len(snapshot.Features) != 1 {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+	f := snapshot.Features[0]
+	if f.Name != "checkout" || f.All != 50 || len(f.Props) != 1 || len(f.Props[0].Item) != 1 || f.Props[0].Item["US"] != 100 {
+		t.Errorf("unexpected feature %v", f)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	s, pub := newTestService()
+	file, _, _ := s.Build(context.Background(), "web")
+
+	if _, _, err := Parse(pub, file, "billing"); !errors.Is(err, ErrWrongService) {
+		t.Errorf("expected wrong service, got %v", err)
+	}
+
+	parts := strings.Split(string(file), ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, _, err := Parse(pub, []byte(tampered), "web"); err == nil {
+		t.Errorf("tampered file must be rejected")
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if _, _, err := Parse(otherPub, file, "web"); !errors.Is(err, SignService.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestBuildRefusesFailedRead(t *testing.T) {
+	pg := &postgres.Mock{
+		QueryFunc: func(context.Context, string, ...any) (postgres.SQLRows, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "3"}}
+	features := FeatureService.NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)))
+	s := NewForTest(features, SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))))
+
+	if file, _, err := s.Build(context.Background(), "web"); err == nil || file != nil {
+		t.Errorf("an unreadable configuration must not be signed, got %d bytes and %v", len(file), err)
+	}
+
+	// Nor a configuration whose version is unknown
+	empty := &postgres.Mock{}
+	versions := VersionRepository.NewForTest(empty, &redis.Mock{Data: map[string]string{}}, mock_log.New(true))
+	features = FeatureService.NewForTest(ActivationValuesRepository.NewForTest(empty, nil, nil, versions, mock_log.New(true)))
+	s = NewForTest(features, SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))))
+	if _, _, err := s.Build(context.Background(), "web"); err == nil {
+		t.Errorf("a snapshot without a version must not be signed")
+	}
+}
@@ -0,0 +1,22 @@
+package ChangeRequestService
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	// ProposeIfRequired returns a pending change request when the target feature falls under the approval policy, nil otherwise.
+	// A non-zero revision must match the target's current one
+	ProposeIfRequired(c context.Context, operation string, targetId uuid.UUID, payload db.ChangeRequestPayload, user string, revision int64) (*db.ChangeRequest, error)
+	Propose(c context.Context, operation string, targetId uuid.UUID, payload db.ChangeRequestPayload, user string, comment string) (*db.ChangeRequest, error)
+
+	Get(c context.Context, id uuid.UUID) (*db.ChangeRequest, error)
+	List(c context.Context, projectId uuid.UUID, status string) ([]*db.ChangeRequest, error)
+
+	Approve(c context.Context, id uuid.UUID, user string, role string, comment string) (*db.ChangeRequest, error)
+	Reject(c context.Context, id uuid.UUID, user string, role string, comment string) (*db.ChangeRequest, error)
+	Apply(c context.Context, id uuid.UUID, user string) (*db.ChangeRequest, error)
+}
@@ -0,0 +1,455 @@
+package ChangeRequestService
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/errs"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ChangeRequestRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureKeyRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureParamRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ServiceAccessRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
+)
+
+var (
+	ErrUnauthenticated  = errs.Unauthenticated("user_required", "user is required")
+	ErrForbidden        = errs.Forbidden("role_not_allowed", "role is not allowed to review change requests")
+	ErrSelfReview       = errs.Forbidden("self_review", "change request must be reviewed by another person")
+	ErrConflict         = errs.Conflict("stale_change_request", "", "target was changed after the change request was proposed")
+	ErrUnknownOperation = errs.Validation("unknown_operation", "operation", "unknown change request operation")
+	ErrTargetNotFound   = errs.NotFound("target_not_found", "change request target not found")
+	ErrInvalidPayload   = errs.Validation("invalid_payload", "payload", "payload does not fit the operation")
+)
+
+// Config is the approval policy: features bound to any of Services need a second person with one of ApproverRoles,
+// services of projects other than the default one are listed as "<project>/<service>"
+type Config struct {
+	Services      []string `yaml:"services"`
+	ApproverRoles []string `yaml:"approver_roles"`
+}
+
+type Service struct {
+	service.Mock
+	changeRequests   ChangeRequestRepository.Interface
+	activationValues ActivationValuesRepository.Interface
+	access           ServiceAccessRepository.Interface
+	features         FeatureRepository.Interface
+	keys             FeatureKeyRepository.Interface
+	params           FeatureParamRepository.Interface
+	layers           LayerRepository.Interface
+
+	config Config
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	t.changeRequests = app.GetModule(interfaces.ModuleRepository, names.ChangeRequestRepository).(ChangeRequestRepository.Interface)
+	t.activationValues = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
+	t.access = app.GetModule(interfaces.ModuleRepository, names.ServiceAccessRepository).(ServiceAccessRepository.Interface)
+	t.features = app.GetModule(interfaces.ModuleRepository, names.FeatureRepository).(FeatureRepository.Interface)
+	t.keys = app.GetModule(interfaces.ModuleRepository, names.FeatureKeyRepository).(FeatureKeyRepository.Interface)
+	t.params = app.GetModule(interfaces.ModuleRepository, names.FeatureParamRepository).(FeatureParamRepository.Interface)
+	t.layers = app.GetModule(interfaces.ModuleRepository, names.LayerRepository).(LayerRepository.Interface)
+
+	// Without a policy section every change is applied immediately
+	if len(cfg) == 0 {
+		return nil
+	}
+
+	return decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst)
+}
+
+func (t *Service) ProposeIfRequired(c context.Context, operation string, targetId uuid.UUID, payload db.ChangeRequestPayload, user string, revision int64) (*db.ChangeRequest, error) {
+	if len(t.config.Services) == 0 {
+		return nil, nil
+	}
+
+	// A service or layer has no revision of its own, it is held when any feature it reaches is protected
+	if isGroupOperation(operation) {
+		_, protected, err := t.reachedFeature(c, operation, targetId)
+		if err != nil || !protected {
+			return nil, err
+		}
+		return t.Propose(c, operation, targetId, payload, user, "")
+	}
+
+	featureId, version, err := t.activationValues.GetVersion(c, targetId)
+	if errors.Is(err, ActivationValuesRepository.ErrValueNotFound) {
+		// Nothing live to protect, let the direct path report the missing target
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := t.requiresApproval(c, featureId, payload.ServiceId)
+	if err != nil || !required {
+		return nil, err
+	}
+
+	// A proposal made from a stale form would be reviewed against a diff nobody meant
+	if revision != 0 && revision != version {
+		return nil, ActivationValuesRepository.ErrRevisionMismatch
+	}
+
+	return t.Propose(c, operation, targetId, payload, user, "")
+}
+
+func (t *Service) Propose(c context.Context, operation string, targetId uuid.UUID, payload db.ChangeRequestPayload, user string, comment string) (*db.ChangeRequest, error) {
+	if user == "" {
+		return nil, ErrUnauthenticated
+	}
+	if !isKnownOperation(operation) {
+		return nil, ErrUnknownOperation
+	}
+	if !fitsOperation(operation, payload) {
+		return nil, ErrInvalidPayload
+	}
+
+	baseId := targetId
+	if isGroupOperation(operation) {
+		featureId, _, err := t.reachedFeature(c, operation, targetId)
+		if err != nil {
+			return nil, err
+		}
+		if featureId == uuid.Nil {
+			return nil, ErrTargetNotFound
+		}
+		baseId = featureId
+	}
+
+	featureId, version, err := t.activationValues.GetVersion(c, baseId)
+	if errors.Is(err, ActivationValuesRepository.ErrValueNotFound) {
+		return nil, ErrTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := t.changeRequests.Create(c, &db.ChangeRequest{
+		Operation:   operation,
+		TargetId:    targetId,
+		FeatureId:   featureId,
+		BaseVersion: version,
+		Payload:     payload,
+		ProposedBy:  user,
+		Comment:     comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.changeRequests.Get(c, id)
+}
+
+func (t *Service) Get(c context.Context, id uuid.UUID) (*db.ChangeRequest, error) {
+	return t.changeRequests.Get(c, id)
+}
+
+func (t *Service) List(c context.Context, projectId uuid.UUID, status string) ([]*db.ChangeRequest, error) {
+	return t.changeRequests.List(c, projectId, status)
+}
+
+func (t *Service) Approve(c context.Context, id uuid.UUID, user string, role string, comment string) (*db.ChangeRequest, error) {
+	cr, err := t.review(c, id, user, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not let a reviewer sign off a diff computed against a stale value
+	if err := t.checkBase(c, cr); err != nil {
+		return nil, err
+	}
+
+	if err := t.changeRequests.Transition(c, id, []string{db.ChangeStatusPending}, db.ChangeStatusApproved, &user, optional(comment)); err != nil {
+		return nil, err
+	}
+
+	return t.changeRequests.Get(c, id)
+}
+
+func (t *Service) Reject(c context.Context, id uuid.UUID, user string, role string, comment string) (*db.ChangeRequest, error) {
+	if _, err := t.review(c, id, user, role); err != nil {
+		return nil, err
+	}
+
+	if err := t.changeRequests.Transition(c, id, []string{db.ChangeStatusPending, db.ChangeStatusApproved}, db.ChangeStatusRejected, &user, optional(comment)); err != nil {
+		return nil, err
+	}
+
+	return t.changeRequests.Get(c, id)
+}
+
+func (t *Service) Apply(c context.Context, id uuid.UUID, user string) (*db.ChangeRequest, error) {
+	if user == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	cr, err := t.changeRequests.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Status != db.ChangeStatusApproved {
+		return nil, ChangeRequestRepository.ErrInvalidState
+	}
+
+	if err := t.checkBase(c, cr); err != nil {
+		return nil, err
+	}
+
+	// Claim the request first so two concurrent applies cannot both run it
+	if err := t.changeRequests.Transition(c, id, []string{db.ChangeStatusApproved}, db.ChangeStatusApplied, nil, nil); err != nil {
+		return nil, err
+	}
+
+	if err := t.apply(c, cr); err != nil {
+		// The target moved on between checkBase and the write
+		if errors.Is(err, ActivationValuesRepository.ErrRevisionMismatch) {
+			if err := t.changeRequests.Transition(c, id, []string{db.ChangeStatusApplied}, db.ChangeStatusConflict, nil, nil); err != nil {
+				return nil, err
+			}
+			return nil, ErrConflict
+		}
+		if revertErr := t.changeRequests.Transition(c, id, []string{db.ChangeStatusApplied}, db.ChangeStatusApproved, nil, nil); revertErr != nil {
+			return nil, errors.Join(err, revertErr)
+		}
+		return nil, err
+	}
+
+	return t.changeRequests.Get(c, id)
+}
+
+// review loads a pending request and checks that user may review it
+func (t *Service) review(c context.Context, id uuid.UUID, user string, role string) (*db.ChangeRequest, error) {
+	if user == "" {
+		return nil, ErrUnauthenticated
+	}
+	if len(t.config.ApproverRoles) > 0 && !slices.Contains(t.config.ApproverRoles, role) {
+		return nil, ErrForbidden
+	}
+
+	cr, err := t.changeRequests.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.ProposedBy == user {
+		return nil, ErrSelfReview
+	}
+
+	return cr, nil
+}
+
+// checkBase marks the request as conflicting when its target moved on since the proposal
+func (t *Service) checkBase(c context.Context, cr *db.ChangeRequest) error {
+	baseId := cr.TargetId
+	if isGroupOperation(cr.Operation) {
+		baseId = cr.FeatureId
+	}
+
+	_, version, err := t.activationValues.GetVersion(c, baseId)
+	if err != nil && !errors.Is(err, ActivationValuesRepository.ErrValueNotFound) {
+		return err
+	}
+
+	if err == nil && version == cr.BaseVersion {
+		return nil
+	}
+
+	if err := t.changeRequests.Transition(c, cr.Id, []string{db.ChangeStatusPending, db.ChangeStatusApproved}, db.ChangeStatusConflict, nil, nil); err != nil {
+		return err
+	}
+
+	return ErrConflict
+}
+
+// apply writes the change only while the target still has the version the request was proposed against
+func (t *Service) apply(c context.Context, cr *db.ChangeRequest) error {
+	p := cr.Payload
+
+	switch cr.Operation {
+	case db.ChangeOperationUpdateFeature:
+		return t.features.UpdateFeature(c, cr.TargetId, p.Name, p.Description, p.Salt, p.BucketBy, p.Value, cr.BaseVersion)
+	case db.ChangeOperationDeleteFeature:
+		return t.features.DeleteFeature(c, cr.TargetId, cr.BaseVersion)
+	case db.ChangeOperationUpdateKey:
+		return t.keys.UpdateKey(c, cr.TargetId, p.Name, p.Description, p.Value, cr.BaseVersion)
+	case db.ChangeOperationDeleteKey:
+		return t.keys.DeleteKey(c, cr.TargetId, cr.BaseVersion)
+	case db.ChangeOperationUpdateParam:
+		return t.params.UpdateParam(c, cr.TargetId, p.Name, p.Value, cr.BaseVersion)
+	case db.ChangeOperationDeleteParam:
+		return t.params.DeleteParam(c, cr.TargetId, cr.BaseVersion)
+	case db.ChangeOperationSetFeatureValue:
+		_, err := t.features.SetValue(c, cr.TargetId, p.Value, cr.BaseVersion)
+		return err
+	case db.ChangeOperationSetKeyValue:
+		_, err := t.keys.SetValue(c, cr.TargetId, p.Value, cr.BaseVersion)
+		return err
+	case db.ChangeOperationSetParamValue:
+		_, err := t.params.SetValue(c, cr.TargetId, p.Value, cr.BaseVersion)
+		return err
+	case db.ChangeOperationCreateKey:
+		_, err := t.keys.CreateKey(c, cr.TargetId, p.Name, p.Description, p.Value)
+		return err
+	case db.ChangeOperationCreateParam:
+		_, err := t.params.CreateParam(c, cr.FeatureId, cr.TargetId, p.Name, p.Value)
+		return err
+	case db.ChangeOperationAddService:
+		return t.access.AddAccess(c, cr.TargetId, p.ServiceId)
+	case db.ChangeOperationRemoveService:
+		return t.access.RemoveAccess(c, cr.TargetId, p.ServiceId)
+	case db.ChangeOperationSetClientSide:
+		if p.ClientSide == nil {
+			return ErrInvalidPayload
+		}
+		return t.access.SetClientSide(c, cr.TargetId, p.ServiceId, *p.ClientSide)
+	case db.ChangeOperationSetLayer:
+		return t.layers.SetAllocation(c, p.LayerId, cr.TargetId, p.From, p.To)
+	case db.ChangeOperationRemoveLayer:
+		return t.layers.RemoveAllocation(c, cr.TargetId)
+	case db.ChangeOperationDeleteService:
+		return t.access.DeleteService(c, cr.TargetId)
+	case db.ChangeOperationUpdateLayer:
+		salt := ""
+		if p.Salt != nil {
+			salt = *p.Salt
+		}
+		return t.layers.UpdateLayer(c, cr.TargetId, p.Name, salt, p.Description)
+	case db.ChangeOperationDeleteLayer:
+		return t.layers.DeleteLayer(c, cr.TargetId)
+	}
+
+	return ErrUnknownOperation
+}
+
+// requiresApproval reports whether the feature is bound to a protected service, or is about to be bound to serviceId
+// when that one is protected
+func (t *Service) requiresApproval(c context.Context, featureId uuid.UUID, serviceId uuid.UUID) (bool, error) {
+	access, err := t.access.GetAccessByFeatures(c, []uuid.UUID{featureId})
+	if err != nil {
+		return false, err
+	}
+
+	for _, svc := range access[featureId] {
+		if slices.Contains(t.config.Services, ProjectRepository.QualifiedName(svc.ProjectName, svc.Name)) {
+			return true, nil
+		}
+	}
+
+	if serviceId == uuid.Nil {
+		return false, nil
+	}
+
+	name, err := t.access.GetServiceName(c, serviceId)
+	if errors.Is(err, ServiceAccessRepository.ErrServiceNotFound) {
+		// Nothing to protect, let the direct path report the missing service
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(t.config.Services, name), nil
+}
+
+// reachedFeature returns the feature a service or layer change is checked against: the first protected one it reaches,
+// otherwise the first one, uuid.Nil when it reaches none
+func (t *Service) reachedFeature(c context.Context, operation string, targetId uuid.UUID) (uuid.UUID, bool, error) {
+	var featureIds []uuid.UUID
+	if operation == db.ChangeOperationDeleteService {
+		ids, err := t.access.GetFeaturesByService(c, targetId)
+		if err != nil {
+			return uuid.Nil, false, err
+		}
+		featureIds = ids
+	} else {
+		allocations, err := t.layers.ListAllocations(c)
+		if err != nil {
+			return uuid.Nil, false, err
+		}
+		for _, a := range allocations[targetId] {
+			featureIds = append(featureIds, a.FeatureId)
+		}
+	}
+
+	for _, id := range featureIds {
+		required, err := t.requiresApproval(c, id, uuid.Nil)
+		if err != nil {
+			return uuid.Nil, false, err
+		}
+		if required {
+			return id, true, nil
+		}
+	}
+
+	if len(featureIds) == 0 {
+		return uuid.Nil, false, nil
+	}
+	return featureIds[0], false, nil
+}
+
+func isGroupOperation(operation string) bool {
+	switch operation {
+	case db.ChangeOperationDeleteService, db.ChangeOperationUpdateLayer, db.ChangeOperationDeleteLayer:
+		return true
+	}
+
+	return false
+}
+
+func isKnownOperation(operation string) bool {
+	switch operation {
+	case db.ChangeOperationUpdateFeature, db.ChangeOperationDeleteFeature,
+		db.ChangeOperationUpdateKey, db.ChangeOperationDeleteKey,
+		db.ChangeOperationUpdateParam, db.ChangeOperationDeleteParam,
+		db.ChangeOperationSetFeatureValue, db.ChangeOperationSetKeyValue, db.ChangeOperationSetParamValue,
+		db.ChangeOperationCreateKey, db.ChangeOperationCreateParam,
+		db.ChangeOperationAddService, db.ChangeOperationRemoveService, db.ChangeOperationSetClientSide,
+		db.ChangeOperationSetLayer, db.ChangeOperationRemoveLayer,
+		db.ChangeOperationDeleteService, db.ChangeOperationUpdateLayer, db.ChangeOperationDeleteLayer:
+		return true
+	}
+
+	return false
+}
+
+// fitsOperation checks the payload carries the ids a structural change cannot do without
+func fitsOperation(operation string, payload db.ChangeRequestPayload) bool {
+	switch operation {
+	case db.ChangeOperationAddService, db.ChangeOperationRemoveService:
+		return payload.ServiceId != uuid.Nil
+	case db.ChangeOperationSetClientSide:
+		return payload.ServiceId != uuid.Nil && payload.ClientSide != nil
+	case db.ChangeOperationSetLayer:
+		return payload.LayerId != uuid.Nil
+	case db.ChangeOperationUpdateLayer:
+		return payload.Name != ""
+	}
+
+	return true
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
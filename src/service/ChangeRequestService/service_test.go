@@ -0,0 +1,390 @@
+package ChangeRequestService
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ChangeRequestRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/FeatureRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/LayerRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ServiceAccessRepository"
+)
+
+type fakeChangeRequests struct {
+	items map[uuid.UUID]*db.ChangeRequest
+}
+
+func (f *fakeChangeRequests) Create(_ context.Context, cr *db.ChangeRequest) (uuid.UUID, error) {
+	item := *cr
+	item.Id = uuid.New()
+	item.Status = db.ChangeStatusPending
+	f.items[item.Id] = &item
+	return item.Id, nil
+}
+
+func (f *fakeChangeRequests) Get(_ context.Context, id uuid.UUID) (*db.ChangeRequest, error) {
+	item, ok := f.items[id]
+	if !ok {
+		return nil, ChangeRequestRepository.ErrNotFound
+	}
+	cp := *item
+	return &cp, nil
+}
+
+func (f *fakeChangeRequests) List(_ context.Context, _ uuid.UUID, _ string) ([]*db.ChangeRequest, error) {
+	return nil, nil
+}
+
+func (f *fakeChangeRequests) Transition(_ context.Context, id uuid.UUID, from []string, to string, reviewedBy *string, _ *string) error {
+	item, ok := f.items[id]
+	if !ok {
+		return ChangeRequestRepository.ErrNotFound
+	}
+	if !slices.Contains(from, item.Status) {
+		return ChangeRequestRepository.ErrInvalidState
+	}
+	item.Status = to
+	if reviewedBy != nil {
+		item.ReviewedBy = reviewedBy
+	}
+	return nil
+}
+
+type fakeActivationValues struct {
+	ActivationValuesRepository.Interface
+	featureId uuid.UUID
+	versions  map[uuid.UUID]int64
+}
+
+func (f *fakeActivationValues) GetVersion(_ context.Context, targetId uuid.UUID) (uuid.UUID, int64, error) {
+	v, ok := f.versions[targetId]
+	if !ok {
+		return uuid.Nil, 0, ActivationValuesRepository.ErrValueNotFound
+	}
+	return f.featureId, v, nil
+}
+
+type fakeAccess struct {
+	ServiceAccessRepository.Interface
+	services []string
+	names    map[uuid.UUID]string
+	added    []uuid.UUID
+	bound    map[uuid.UUID][]uuid.UUID
+	deleted  []uuid.UUID
+}
+
+func (f *fakeAccess) GetServiceName(_ context.Context, serviceId uuid.UUID) (string, error) {
+	name, ok := f.names[serviceId]
+	if !ok {
+		return "", ServiceAccessRepository.ErrServiceNotFound
+	}
+	return name, nil
+}
+
+func (f *fakeAccess) AddAccess(_ context.Context, _ uuid.UUID, serviceId uuid.UUID) error {
+	f.added = append(f.added, serviceId)
+	return nil
+}
+
+func (f *fakeAccess) GetAccessByFeatures(_ context.Context, featureIds []uuid.UUID) (map[uuid.UUID][]*db.ServiceAccess, error) {
+	res := make(map[uuid.UUID][]*db.ServiceAccess)
+	for _, name := range f.services {
+		res[featureIds[0]] = append(res[featureIds[0]], &db.ServiceAccess{Name: name})
+	}
+	return res, nil
+}
+
+func (f *fakeAccess) GetFeaturesByService(_ context.Context, serviceId uuid.UUID) ([]uuid.UUID, error) {
+	return f.bound[serviceId], nil
+}
+
+func (f *fakeAccess) DeleteService(_ context.Context, serviceId uuid.UUID) error {
+	f.deleted = append(f.deleted, serviceId)
+	return nil
+}
+
+type fakeLayers struct {
+	LayerRepository.Interface
+	allocations map[uuid.UUID][]*db.LayerAllocation
+	salts       []string
+}
+
+func (f *fakeLayers) ListAllocations(context.Context) (map[uuid.UUID][]*db.LayerAllocation, error) {
+	return f.allocations, nil
+}
+
+func (f *fakeLayers) UpdateLayer(_ context.Context, _ uuid.UUID, _ string, salt string, _ string) error {
+	f.salts = append(f.salts, salt)
+	return nil
+}
+
+type fakeFeatures struct {
+	FeatureRepository.Interface
+	updated   []int
+	revisions []int64
+	// stale makes the write see a revision other than the expected one
+	stale bool
+}
+
+func (f *fakeFeatures) UpdateFeature(_ context.Context, _ uuid.UUID, _ string, _ string, _ *string, _ *string, value int, revision int64) error {
+	f.revisions = append(f.revisions, revision)
+	if f.stale {
+		return ActivationValuesRepository.ErrRevisionMismatch
+	}
+	f.updated = append(f.updated, value)
+	return nil
+}
+
+func (f *fakeFeatures) SetValue(_ context.Context, _ uuid.UUID, value int, revision int64) (int64, error) {
+	f.revisions = append(f.revisions, revision)
+	f.updated = append(f.updated, value)
+	return 11, nil
+}
+
+func newTestService(services ...string) (*Service, *fakeActivationValues, *fakeFeatures, uuid.UUID) {
+	featureId := uuid.New()
+	values := &fakeActivationValues{featureId: featureId, versions: map[uuid.UUID]int64{featureId: 10}}
+	features := &fakeFeatures{}
+
+	svc := &Service{
+		changeRequests:   &fakeChangeRequests{items: make(map[uuid.UUID]*db.ChangeRequest)},
+		activationValues: values,
+		access:           &fakeAccess{services: services},
+		features:         features,
+		config:           Config{Services: []string{"payments"}, ApproverRoles: []string{"admin"}},
+	}
+
+	return svc, values, features, featureId
+}
+
+func TestProposeIfRequiredPolicy(t *testing.T) {
+	c := context.Background()
+
+	svc, _, _, featureId := newTestService("catalog")
+	cr, err := svc.ProposeIfRequired(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 50}, "alice", 0)
+	if err != nil || cr != nil {
+		t.Fatalf("unprotected feature must be applied directly, got %v %v", cr, err)
+	}
+
+	svc, _, _, featureId = newTestService("catalog", "payments")
+	cr, err = svc.ProposeIfRequired(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 50}, "alice", 0)
+	if err != nil || cr == nil {
+		t.Fatalf("protected feature must be held for review, got %v %v", cr, err)
+	}
+	if cr.Status != db.ChangeStatusPending || cr.BaseVersion != 10 || cr.FeatureId != featureId {
+		t.Errorf("unexpected change request %+v", cr)
+	}
+
+	if _, err := svc.ProposeIfRequired(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{}, "", 0); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected ErrUnauthenticated, got %v", err)
+	}
+
+	if _, err := svc.ProposeIfRequired(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 50}, "alice", 9); !errors.Is(err, ActivationValuesRepository.ErrRevisionMismatch) {
+		t.Errorf("proposal from a stale form must be rejected, got %v", err)
+	}
+	if _, err := svc.ProposeIfRequired(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 50}, "alice", 10); err != nil {
+		t.Errorf("proposal at the current revision must be accepted, got %v", err)
+	}
+}
+
+func TestProposeBindingToProtectedService(t *testing.T) {
+	c := context.Background()
+	svc, _, _, featureId := newTestService("catalog")
+	access := svc.access.(*fakeAccess)
+	payments, search := uuid.New(), uuid.New()
+	access.names = map[uuid.UUID]string{payments: "payments", search: "search"}
+
+	cr, err := svc.ProposeIfRequired(c, db.ChangeOperationAddService, featureId, db.ChangeRequestPayload{ServiceId: search}, "alice", 0)
+	if err != nil || cr != nil {
+		t.Fatalf("binding to an unprotected service must be applied directly, got %v %v", cr, err)
+	}
+
+	cr, err = svc.ProposeIfRequired(c, db.ChangeOperationAddService, featureId, db.ChangeRequestPayload{ServiceId: payments}, "alice", 0)
+	if err != nil || cr == nil {
+		t.Fatalf("binding to a protected service must be held for review, got %v %v", cr, err)
+	}
+
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Apply(c, cr.Id, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(access.added, []uuid.UUID{payments}) {
+		t.Errorf("expected the protected service to be bound on apply, got %v", access.added)
+	}
+
+	if _, err := svc.Propose(c, db.ChangeOperationSetClientSide, featureId, db.ChangeRequestPayload{ServiceId: payments}, "alice", ""); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload without client_side, got %v", err)
+	}
+}
+
+func TestProposeServiceAndLayerChanges(t *testing.T) {
+	c := context.Background()
+	svc, values, _, featureId := newTestService("payments")
+	access := svc.access.(*fakeAccess)
+	serviceId, layerId, empty := uuid.New(), uuid.New(), uuid.New()
+	access.bound = map[uuid.UUID][]uuid.UUID{serviceId: {featureId}}
+	layers := &fakeLayers{allocations: map[uuid.UUID][]*db.LayerAllocation{layerId: {{LayerId: layerId, FeatureId: featureId}}}}
+	svc.layers = layers
+
+	cr, err := svc.ProposeIfRequired(c, db.ChangeOperationDeleteService, empty, db.ChangeRequestPayload{}, "alice", 0)
+	if err != nil || cr != nil {
+		t.Fatalf("a service without features must be deleted directly, got %v %v", cr, err)
+	}
+
+	cr, err = svc.ProposeIfRequired(c, db.ChangeOperationDeleteService, serviceId, db.ChangeRequestPayload{}, "alice", 0)
+	if err != nil || cr == nil {
+		t.Fatalf("deleting a service bound to a protected feature must be held for review, got %v %v", cr, err)
+	}
+	if cr.TargetId != serviceId || cr.FeatureId != featureId || cr.BaseVersion != 10 {
+		t.Errorf("unexpected change request %+v", cr)
+	}
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Apply(c, cr.Id, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(access.deleted, []uuid.UUID{serviceId}) {
+		t.Errorf("expected the service to be deleted on apply, got %v", access.deleted)
+	}
+
+	salt := "v2"
+	cr, err = svc.ProposeIfRequired(c, db.ChangeOperationUpdateLayer, layerId, db.ChangeRequestPayload{Name: "l", Salt: &salt}, "alice", 0)
+	if err != nil || cr == nil {
+		t.Fatalf("re-salting a layer with a protected feature must be held for review, got %v %v", cr, err)
+	}
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The protected feature moved on while the request waited
+	values.versions[featureId] = 11
+	if _, err := svc.Apply(c, cr.Id, "alice"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if len(layers.salts) != 0 {
+		t.Errorf("stale layer change must not be applied, got %v", layers.salts)
+	}
+
+	if _, err := svc.Propose(c, db.ChangeOperationUpdateLayer, layerId, db.ChangeRequestPayload{}, "alice", ""); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload without a name, got %v", err)
+	}
+	if _, err := svc.Propose(c, db.ChangeOperationDeleteLayer, empty, db.ChangeRequestPayload{}, "alice", ""); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected ErrTargetNotFound for a layer without features, got %v", err)
+	}
+}
+
+func TestApproveAndApply(t *testing.T) {
+	c := context.Background()
+	svc, _, features, featureId := newTestService("payments")
+
+	cr, err := svc.Propose(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 75}, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Approve(c, cr.Id, "alice", "admin", ""); !errors.Is(err, ErrSelfReview) {
+		t.Errorf("expected ErrSelfReview, got %v", err)
+	}
+	if _, err := svc.Approve(c, cr.Id, "bob", "viewer", ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.Apply(c, cr.Id, "alice"); !errors.Is(err, ChangeRequestRepository.ErrInvalidState) {
+		t.Errorf("pending request must not be applied, got %v", err)
+	}
+
+	cr, err = svc.Approve(c, cr.Id, "bob", "admin", "")
+	if err != nil || cr.Status != db.ChangeStatusApproved {
+		t.Fatalf("approve failed: %v %+v", err, cr)
+	}
+
+	cr, err = svc.Apply(c, cr.Id, "alice")
+	if err != nil || cr.Status != db.ChangeStatusApplied {
+		t.Fatalf("apply failed: %v %+v", err, cr)
+	}
+	if !slices.Equal(features.updated, []int{75}) {
+		t.Errorf("expected one update with value 75, got %v", features.updated)
+	}
+	if !slices.Equal(features.revisions, []int64{10}) {
+		t.Errorf("write must be conditional on the base version, got %v", features.revisions)
+	}
+
+	if _, err := svc.Apply(c, cr.Id, "alice"); !errors.Is(err, ChangeRequestRepository.ErrInvalidState) {
+		t.Errorf("applied request must not be applied twice, got %v", err)
+	}
+}
+
+func TestApplySetValue(t *testing.T) {
+	c := context.Background()
+	svc, _, features, featureId := newTestService("payments")
+
+	cr, err := svc.Propose(c, db.ChangeOperationSetFeatureValue, featureId, db.ChangeRequestPayload{Value: 30}, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	cr, err = svc.Apply(c, cr.Id, "alice")
+	if err != nil || cr.Status != db.ChangeStatusApplied {
+		t.Fatalf("apply failed: %v %+v", err, cr)
+	}
+	if !slices.Equal(features.updated, []int{30}) {
+		t.Errorf("expected the value alone to be set to 30, got %v", features.updated)
+	}
+}
+
+func TestApplyDetectsConflict(t *testing.T) {
+	c := context.Background()
+	svc, values, features, featureId := newTestService("payments")
+
+	cr, _ := svc.Propose(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 75}, "alice", "")
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Someone changed the flag while the request waited
+	values.versions[featureId] = 11
+
+	if _, err := svc.Apply(c, cr.Id, "alice"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if len(features.updated) != 0 {
+		t.Errorf("stale diff must not be applied, got %v", features.updated)
+	}
+
+	got, _ := svc.Get(c, cr.Id)
+	if got.Status != db.ChangeStatusConflict {
+		t.Errorf("expected conflict status, got %s", got.Status)
+	}
+}
+
+func TestApplyDetectsConcurrentWrite(t *testing.T) {
+	c := context.Background()
+	svc, _, features, featureId := newTestService("payments")
+
+	cr, _ := svc.Propose(c, db.ChangeOperationUpdateFeature, featureId, db.ChangeRequestPayload{Value: 75}, "alice", "")
+	if _, err := svc.Approve(c, cr.Id, "bob", "admin", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flag changes between the base check and the write
+	features.stale = true
+
+	if _, err := svc.Apply(c, cr.Id, "alice"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	got, _ := svc.Get(c, cr.Id)
+	if got.Status != db.ChangeStatusConflict {
+		t.Errorf("expected conflict status, got %s", got.Status)
+	}
+}
@@ -0,0 +1,26 @@
+package ClientService
+
+import (
+	"context"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+// Interface tracks which SDK instances talk to the server and which configuration version they hold.
+// Clients without an instance id and hostname are ignored
+type Interface interface {
+	// Seen records a polling request of the client, client.Version is the version it sent
+	Seen(c context.Context, client *db.Client)
+
+	// Connect registers an open update stream, the same client is passed to Advance and Disconnect
+	Connect(c context.Context, client *db.Client)
+	// Advance records that the stream delivered, or the client acknowledged, version
+	Advance(client *db.Client, version int64)
+	Disconnect(c context.Context, client *db.Client)
+
+	// List returns the instances of the service seen recently with their lag
+	List(c context.Context, serviceName string) ([]*dto.Client, error)
+	// Connected counts the instances of the service streaming updates from any server instance
+	Connected(c context.Context, serviceName string) (int, error)
+}
@@ -0,0 +1,358 @@
+package ClientService
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ClientRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ProjectRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+)
+
+const (
+	// flushEvery batches registry writes, open streams are refreshed at the same pace
+	flushEvery = 10 * time.Second
+	// A streaming row not refreshed for this long belongs to a server instance that is gone
+	streamTimeout = 3 * flushEvery
+	// listFor is how long an instance stays listed after it was last seen
+	listFor = 10 * time.Minute
+	// stuckAfter is how long an instance may miss changes of its service before it is flagged
+	stuckAfter = 5 * time.Minute
+
+	keepFor    = 24 * time.Hour
+	pruneEvery = 10 * time.Minute
+)
+
+// Limits of client supplied fields, they match the clients table
+const (
+	maxService = 511
+	maxName    = 255
+	maxSdk     = 64
+	maxLabels  = 32
+)
+
+type clientKey struct {
+	service  string
+	instance string
+}
+
+type Service struct {
+	service.Mock
+	logger                     interfaces.ILogger
+	clientRepository           ClientRepository.Interface
+	activationValuesRepository ActivationValuesRepository.Interface
+
+	mu      sync.Mutex
+	live    map[*db.Client]struct{}
+	pending map[clientKey]db.Client
+	pruned  time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+// NewForTest does not start the flush loop, tests call flush themselves
+func NewForTest(clientRepository ClientRepository.Interface, activationValuesRepository ActivationValuesRepository.Interface, logger interfaces.ILogger) *Service {
+	return &Service{
+		logger:                     logger,
+		clientRepository:           clientRepository,
+		activationValuesRepository: activationValuesRepository,
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.clientRepository = app.GetModule(interfaces.ModuleRepository, names.ClientRepository).(ClientRepository.Interface)
+	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
+
+	c, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wg.Add(1)
+	go t.run(c)
+
+	return nil
+}
+
+func (t *Service) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+
+	// Deliver what was collected since the previous tick
+	c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	t.flush(c)
+
+	return nil
+}
+
+func (t *Service) Seen(_ context.Context, client *db.Client) {
+	if !normalize(client) {
+		return
+	}
+
+	now := time.Now()
+	cl := *client
+	cl.Streaming = false
+	cl.ConnectedAt = nil
+	cl.VersionAt = now
+	cl.SeenAt = now
+
+	t.mu.Lock()
+	t.queue(cl)
+	t.mu.Unlock()
+}
+
+func (t *Service) Connect(_ context.Context, client *db.Client) {
+	if !normalize(client) {
+		return
+	}
+
+	now := time.Now()
+	client.Streaming = true
+	client.ConnectedAt = &now
+	client.VersionAt = now
+	client.SeenAt = now
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.live == nil {
+		t.live = make(map[*db.Client]struct{})
+	}
+	t.live[client] = struct{}{}
+	t.queue(*client)
+}
+
+func (t *Service) Advance(client *db.Client, version int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.live[client]; !ok || version <= client.Version {
+		return
+	}
+
+	now := time.Now()
+	client.Version = version
+	client.VersionAt = now
+	client.SeenAt = now
+	t.queue(*client)
+}
+
+func (t *Service) Disconnect(_ context.Context, client *db.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.live[client]; !ok {
+		return
+	}
+	delete(t.live, client)
+
+	// The instance is still streaming when its reconnect opened before the old stream closed
+	for other := range t.live {
+		if other.ServiceName == client.ServiceName && other.InstanceId == client.InstanceId {
+			return
+		}
+	}
+
+	cl := *client
+	cl.Streaming = false
+	cl.SeenAt = time.Now()
+	t.queue(cl)
+}
+
+func (t *Service) List(c context.Context, serviceName string) ([]*dto.Client, error) {
+	serviceName = ProjectRepository.QualifiedName(ProjectRepository.SplitServiceName(serviceName))
+	now := time.Now()
+
+	clients, err := t.clientRepository.ListByService(c, serviceName, now.Add(-listFor))
+	if err != nil {
+		return nil, err
+	}
+
+	global, _, err := t.activationValuesRepository.GetGlobalVersion(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Instances mostly hold the same few versions, each is checked against the service once
+	behind := make(map[int64]bool)
+	res := make([]*dto.Client, 0, len(clients))
+	for _, cl := range clients {
+		if cl.Streaming && now.Sub(cl.SeenAt) > streamTimeout {
+			cl.Streaming = false
+		}
+
+		item := &dto.Client{Client: *cl}
+		if cl.Version < global {
+			missed, ok := behind[cl.Version]
+			if !ok {
+				_, features, err := t.activationValuesRepository.GetNewByServiceName(c, serviceName, cl.Version)
+				missed = err == nil && len(features) > 0
+				behind[cl.Version] = missed
+			}
+
+			if missed {
+				item.Lag = global - cl.Version
+				item.Stuck = now.Sub(cl.VersionAt) > stuckAfter
+			}
+		}
+
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+func (t *Service) Connected(c context.Context, serviceName string) (int, error) {
+	serviceName = ProjectRepository.QualifiedName(ProjectRepository.SplitServiceName(serviceName))
+	now := time.Now()
+
+	clients, err := t.clientRepository.ListByService(c, serviceName, now.Add(-streamTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	streaming := make(map[clientKey]struct{}, len(clients))
+	for _, cl := range clients {
+		if cl.Streaming {
+			streaming[clientKey{service: cl.ServiceName, instance: cl.InstanceId}] = struct{}{}
+		}
+	}
+
+	// Streams opened here since the last flush are not in the registry yet
+	t.mu.Lock()
+	for cl := range t.live {
+		if cl.ServiceName == serviceName {
+			streaming[clientKey{service: cl.ServiceName, instance: cl.InstanceId}] = struct{}{}
+		}
+	}
+	t.mu.Unlock()
+
+	return len(streaming), nil
+}
+
+// queue keeps the newest state per instance until the next flush, t.mu must be held
+func (t *Service) queue(cl db.Client) {
+	if t.pending == nil {
+		t.pending = make(map[clientKey]db.Client)
+	}
+	t.pending[clientKey{service: cl.ServiceName, instance: cl.InstanceId}] = cl
+}
+
+func (t *Service) run(c context.Context) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			t.flush(c)
+		}
+	}
+}
+
+// flush writes queued changes and refreshes every open stream, so other server instances see it alive
+func (t *Service) flush(c context.Context) {
+	now := time.Now()
+
+	t.mu.Lock()
+	// One row per instance: a single upsert cannot touch a row twice, and an instance may hold two streams while a
+	// reconnect overlaps the old one
+	rows := make(map[clientKey]*db.Client, len(t.pending)+len(t.live))
+	for key, cl := range t.pending {
+		rows[key] = &cl
+	}
+	for client := range t.live {
+		key := clientKey{service: client.ServiceName, instance: client.InstanceId}
+		if _, ok := t.pending[key]; ok {
+			continue
+		}
+		if other, ok := rows[key]; ok && other.Version >= client.Version {
+			continue
+		}
+		cl := *client
+		cl.SeenAt = now
+		rows[key] = &cl
+	}
+	t.pending = nil
+	prune := now.Sub(t.pruned) > pruneEvery
+	if prune {
+		t.pruned = now
+	}
+	t.mu.Unlock()
+
+	batch := make([]*db.Client, 0, len(rows))
+	for _, cl := range rows {
+		batch = append(batch, cl)
+	}
+
+	// A failed write is not retried, open streams are written again on the next tick and pollers come back
+	if len(batch) > 0 {
+		_ = t.clientRepository.Save(c, batch)
+	}
+
+	if prune {
+		_ = t.clientRepository.Prune(c, now.Add(-keepFor))
+	}
+}
+
+// normalize qualifies the service name and trims client supplied fields, false when the client cannot be told apart
+func normalize(client *db.Client) bool {
+	if client.InstanceId == "" {
+		client.InstanceId = client.Hostname
+	}
+	if client.InstanceId == "" || client.ServiceName == "" || len(client.ServiceName) > maxService {
+		return false
+	}
+
+	client.ServiceName = ProjectRepository.QualifiedName(ProjectRepository.SplitServiceName(client.ServiceName))
+	client.InstanceId = truncate(client.InstanceId, maxName)
+	client.Hostname = truncate(client.Hostname, maxName)
+	client.SdkName = truncate(client.SdkName, maxSdk)
+	client.SdkVersion = truncate(client.SdkVersion, maxSdk)
+
+	if len(client.Labels) > 0 {
+		labels := make(map[string]string, min(len(client.Labels), maxLabels))
+		for k, v := range client.Labels {
+			if len(labels) == maxLabels {
+				break
+			}
+			labels[truncate(k, maxName)] = truncate(v, maxName)
+		}
+		client.Labels = labels
+	}
+
+	return true
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	// Do not cut a multi-byte character in half
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
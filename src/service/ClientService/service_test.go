@@ -0,0 +1,184 @@
+package ClientService
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+type fakeClients struct {
+	saved  []*db.Client
+	listed []*db.Client
+}
+
+func (f *fakeClients) Save(_ context.Context, clients []*db.Client) error {
+	f.saved = append(f.saved, clients...)
+	return nil
+}
+
+func (f *fakeClients) ListByService(context.Context, string, time.Time) ([]*db.Client, error) {
+	return f.listed, nil
+}
+
+func (f *fakeClients) Prune(context.Context, time.Time) error { return nil }
+
+func (f *fakeClients) byInstance(id string) *db.Client {
+	for i := len(f.saved) - 1; i >= 0; i-- {
+		if f.saved[i].InstanceId == id {
+			return f.saved[i]
+		}
+	}
+	return nil
+}
+
+// fakeValues has changes of the service up to version changed, the global version is global
+type fakeValues struct {
+	ActivationValuesRepository.Interface
+	global  int64
+	changed int64
+}
+
+func (f *fakeValues) GetGlobalVersion(context.Context) (int64, time.Time, error) {
+	return f.global, time.Time{}, nil
+}
+
+func (f *fakeValues) GetNewByServiceName(_ context.Context, _ string, lastVersion int64) (int64, []*dto.Feature, error) {
+	if lastVersion >= f.changed {
+		return f.global, nil, nil
+	}
+	return f.global, []*dto.Feature{{Name: "checkout"}}, nil
+}
+
+func TestService_registersPollersAndStreams(t *testing.T) {
+	repo := &fakeClients{}
+	svc := NewForTest(repo, &fakeValues{}, mock_log.New(true))
+	c := context.Background()
+
+	svc.Seen(c, &db.Client{ServiceName: "default/billing", Hostname: "billing-7f9c", Transport: db.ClientTransportPoll, Version: 3})
+	svc.Seen(c, &db.Client{ServiceName: "billing", Transport: db.ClientTransportPoll})
+
+	stream := &db.Client{ServiceName: "shop/cart", InstanceId: "a1", SdkName: "go", Transport: db.ClientTransportGrpc, Version: 5}
+	svc.Connect(c, stream)
+	svc.Advance(stream, 7)
+	svc.Advance(stream, 6)
+	svc.flush(c)
+
+	if len(repo.saved) != 2 {
+		t.Fatalf("anonymous client must be ignored, saved %+v", repo.saved)
+	}
+	poller := repo.byInstance("billing-7f9c")
+	if poller == nil || poller.ServiceName != "billing" || poller.Version != 3 || poller.Streaming {
+		t.Errorf("poller must be keyed by hostname in the qualified service, got %+v", poller)
+	}
+	if s := repo.byInstance("a1"); s == nil || s.Version != 7 || !s.Streaming || s.ConnectedAt == nil {
+		t.Errorf("stream must hold the newest delivered version, got %+v", s)
+	}
+
+	// Open streams are refreshed on every flush, even without changes
+	repo.saved = nil
+	svc.flush(c)
+	if s := repo.byInstance("a1"); s == nil || !s.Streaming {
+		t.Fatalf("open stream must be refreshed, got %+v", repo.saved)
+	}
+
+	repo.saved = nil
+	svc.Disconnect(c, stream)
+	svc.flush(c)
+	if s := repo.byInstance("a1"); s == nil || s.Streaming {
+		t.Errorf("disconnect must be recorded, got %+v", s)
+	}
+
+	repo.saved = nil
+	svc.flush(c)
+	if len(repo.saved) != 0 {
+		t.Errorf("closed stream must not be refreshed, got %+v", repo.saved)
+	}
+}
+
+func TestService_flushOverlappingStreams(t *testing.T) {
+	repo := &fakeClients{}
+	svc := NewForTest(repo, &fakeValues{}, mock_log.New(true))
+	c := context.Background()
+
+	// The reconnect opens before the old stream of the instance is closed
+	old := &db.Client{ServiceName: "billing", InstanceId: "a1", Transport: db.ClientTransportGrpc, Version: 5}
+	svc.Connect(c, old)
+	reconnect := &db.Client{ServiceName: "default/billing", InstanceId: "a1", Transport: db.ClientTransportGrpc, Version: 5}
+	svc.Connect(c, reconnect)
+	svc.Advance(reconnect, 8)
+
+	for i := 0; i < 2; i++ {
+		repo.saved = nil
+		svc.flush(c)
+		if len(repo.saved) != 1 || repo.saved[0].Version != 8 {
+			t.Fatalf("flush %d must write the instance once at its newest version, got %+v", i, repo.saved)
+		}
+	}
+
+	repo.saved = nil
+	svc.Disconnect(c, old)
+	svc.flush(c)
+	if s := repo.byInstance("a1"); len(repo.saved) != 1 || !s.Streaming {
+		t.Errorf("closing the old stream must keep the instance streaming, got %+v", repo.saved)
+	}
+}
+
+func TestService_Connected(t *testing.T) {
+	now := time.Now()
+	repo := &fakeClients{listed: []*db.Client{
+		// Streams of other server instances and one that closed
+		{ServiceName: "billing", InstanceId: "b1", Streaming: true, SeenAt: now},
+		{ServiceName: "billing", InstanceId: "b2", SeenAt: now},
+		{ServiceName: "billing", InstanceId: "a1", Streaming: true, SeenAt: now},
+	}}
+	svc := NewForTest(repo, &fakeValues{}, mock_log.New(true))
+	c := context.Background()
+
+	// Opened here under the qualified name, a1 is already in the registry
+	svc.Connect(c, &db.Client{ServiceName: "default/billing", InstanceId: "a1", Transport: db.ClientTransportGrpc})
+	svc.Connect(c, &db.Client{ServiceName: "default/billing", InstanceId: "a2", Transport: db.ClientTransportGrpc})
+
+	if n, err := svc.Connected(c, "default/billing"); err != nil || n != 3 {
+		t.Errorf("expected 3 streaming instances, got %d %v", n, err)
+	}
+}
+
+func TestService_List(t *testing.T) {
+	now := time.Now()
+	repo := &fakeClients{listed: []*db.Client{
+		{InstanceId: "current", Version: 10, VersionAt: now, SeenAt: now},
+		{InstanceId: "unaffected", Version: 8, VersionAt: now.Add(-time.Hour), SeenAt: now},
+		{InstanceId: "catching-up", Version: 4, VersionAt: now.Add(-time.Second), SeenAt: now},
+		{InstanceId: "stuck", Version: 4, VersionAt: now.Add(-time.Hour), SeenAt: now, Streaming: true},
+		{InstanceId: "gone", Version: 10, VersionAt: now, SeenAt: now.Add(-time.Minute), Streaming: true},
+	}}
+	svc := NewForTest(repo, &fakeValues{global: 10, changed: 6}, mock_log.New(true))
+
+	clients, err := svc.List(context.Background(), "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		lag       int64
+		stuck     bool
+		streaming bool
+	}{
+		"current":     {},
+		"unaffected":  {},
+		"catching-up": {lag: 6},
+		"stuck":       {lag: 6, stuck: true, streaming: true},
+		"gone":        {},
+	}
+	for _, cl := range clients {
+		w := want[cl.InstanceId]
+		if cl.Lag != w.lag || cl.Stuck != w.stuck || cl.Streaming != w.streaming {
+			t.Errorf("%s: expected lag %d stuck %v streaming %v, got %d %v %v", cl.InstanceId, w.lag, w.stuck, w.streaming, cl.Lag, cl.Stuck, cl.Streaming)
+		}
+	}
+}
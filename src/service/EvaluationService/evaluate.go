@@ -0,0 +1,64 @@
+package EvaluationService
+
+import (
+	"hash/fnv"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+// Bucket maps (salt, seed) to 0..99 with FNV-1a, the same pair always lands in the same bucket
+func Bucket(salt string, seed string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(salt))
+	_, _ = h.Write([]byte{':'})
+	_, _ = h.Write([]byte(seed))
+	return int(h.Sum32() % 100)
+}
+
+// IsEnabled applies the documented decision order: layer range, exact key=value param, feature percent
+func IsEnabled(feature *dto.Feature, seed string, attributes map[string]string) bool {
+	if feature.BucketBy != "" {
+		if v, ok := attributes[feature.BucketBy]; ok {
+			seed = v
+		}
+	}
+
+	if feature.Layer != nil {
+		bucket := Bucket(feature.Layer.Salt, seed)
+		if bucket < feature.Layer.From || bucket >= feature.Layer.To {
+			return false
+		}
+	}
+
+	percent := percentFor(feature, attributes)
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+
+	return Bucket(feature.Salt, seed) < percent
+}
+
+func percentFor(feature *dto.Feature, attributes map[string]string) int {
+	for _, key := range feature.Keys {
+		if key.IsDeleted {
+			continue
+		}
+
+		value, ok := attributes[key.Key]
+		if !ok {
+			continue
+		}
+
+		for _, param := range key.Params {
+			if !param.IsDeleted && param.Name == value {
+				return param.Value
+			}
+		}
+	}
+
+	// -1 marks an unset value, i.e. off
+	return feature.Value
+}
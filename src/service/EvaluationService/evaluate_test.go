@@ -0,0 +1,195 @@
+package EvaluationService
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
+	"gitlab.com/devpro_studio/Paranoia/pkg/cache/redis"
+	"gitlab.com/devpro_studio/Paranoia/pkg/database/postgres"
+	"gitlab.com/devpro_studio/Paranoia/pkg/logger/mock_log"
+)
+
+func TestBucket(t *testing.T) {
+	if Bucket("salt", "user-1") != Bucket("salt", "user-1") {
+		t.Fatal("bucket must be deterministic")
+	}
+
+	// Roughly uniform over many seeds
+	counts := make([]int, 100)
+	for i := 0; i < 100000; i++ {
+		b := Bucket("salt", uuid.NewString())
+		if b < 0 || b >= 100 {
+			t.Fatalf("bucket %d out of range", b)
+		}
+		counts[b]++
+	}
+	for b, n := range counts {
+		if n < 800 || n > 1200 {
+			t.Errorf("bucket %d got %d of 100000", b, n)
+		}
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	country := []dto.FeatureKey{{Key: "country", Value: -1, Params: []dto.FeatureParam{{Name: "US", Value: 100}, {Name: "RU", Value: 0}, {Name: "DE", Value: 100, IsDeleted: true}}}}
+
+	tests := []struct {
+		name    string
+		feature dto.Feature
+		seed    string
+		attrs   map[string]string
+		want    bool
+	}{
+		{name: "off", feature: dto.Feature{Salt: "f", Value: 0}, seed: "u", want: false},
+		{name: "unset", feature: dto.Feature{Salt: "f", Value: -1}, seed: "u", want: false},
+		{name: "on", feature: dto.Feature{Salt: "f", Value: 100}, seed: "u", want: true},
+		{name: "param match wins", feature: dto.Feature{Salt: "f", Value: 0, Keys: country}, attrs: map[string]string{"country": "US"}, want: true},
+		{name: "param match off", feature: dto.Feature{Salt: "f", Value: 100, Keys: country}, attrs: map[string]string{"country": "RU"}, want: false},
+		{name: "deleted param ignored", feature: dto.Feature{Salt: "f", Value: 0, Keys: country}, attrs: map[string]string{"country": "DE"}, want: false},
+		{name: "no match falls back", feature: dto.Feature{Salt: "f", Value: 100, Keys: country}, attrs: map[string]string{"country": "FR"}, want: true},
+		{name: "outside layer range", feature: dto.Feature{Salt: "f", Value: 100, Layer: &dto.FeatureLayer{Salt: "l", From: 0, To: 0}}, seed: "u", want: false},
+		{name: "inside layer range", feature: dto.Feature{Salt: "f", Value: 100, Layer: &dto.FeatureLayer{Salt: "l", From: 0, To: 100}}, seed: "u", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsEnabled(&tt.feature, tt.seed, tt.attrs); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsEnabled_Percent(t *testing.T) {
+	f := &dto.Feature{Salt: "f", Value: 30}
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		if IsEnabled(f, uuid.NewString(), nil) {
+			on++
+		}
+	}
+	if on < 2700 || on > 3300 {
+		t.Errorf("expected about 30%% enabled, got %d of 10000", on)
+	}
+
+	// bucket_by takes the seed from the context attribute
+	f = &dto.Feature{Salt: "f", Value: 50, BucketBy: "company_id"}
+	for i := 0; i < 100; i++ {
+		attrs := map[string]string{"company_id": "acme"}
+		if IsEnabled(f, uuid.NewString(), attrs) != IsEnabled(f, "other", attrs) {
+			t.Fatal("users of one company must get the same result")
+		}
+	}
+}
+
+func TestService_Evaluate(t *testing.T) {
+	queries := 0
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			queries++
+			return &postgres.MockRows{
+				Values: [][]any{
+					{uuid.New().String(), "on_feature", nil, nil, nil, nil, 100, int64(1), nil, nil, nil, nil, nil, nil, nil},
+					{uuid.New().String(), "off_feature", nil, nil, nil, nil, 0, int64(1), nil, nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "3"}}
+
+	signer := SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	s := NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)), signer)
+
+	result, token, err := s.Evaluate(context.Background(), "web", "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != 3 || result.Subject != "user-1" || !result.Features["on_feature"] || result.Features["off_feature"] {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.ExpiresAt <= result.IssuedAt {
+		t.Errorf("result must expire after it is issued")
+	}
+
+	_, pub := signer.PublicKey()
+	var claims dto.Evaluation
+	if err := SignService.VerifyToken(pub, token, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || !claims.Features["on_feature"] {
+		t.Errorf("token does not carry the result: %+v", claims)
+	}
+
+	// Same version, the configuration comes from the snapshot
+	if _, _, err := s.Evaluate(context.Background(), "web", "user-2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if queries != 1 {
+		t.Errorf("expected one load per version, got %d", queries)
+	}
+
+	cache.Data["feature_version"] = "4"
+	if _, _, err := s.Evaluate(context.Background(), "web", "user-2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if queries != 2 {
+		t.Errorf("expected a reload after the version moved, got %d loads", queries)
+	}
+
+	// Same version but an expired snapshot, it may hold rows read before the version's own commit
+	snap := s.snapshots["web"]
+	snap.loadedAt = snap.loadedAt.Add(-snapshotTTL)
+	s.snapshots["web"] = snap
+	if _, _, err := s.Evaluate(context.Background(), "web", "user-2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if queries != 3 {
+		t.Errorf("expected a reload after the snapshot expired, got %d loads", queries)
+	}
+}
+
+func TestService_SnapshotsOnlyKnownServices(t *testing.T) {
+	pg := &postgres.Mock{
+		QueryFunc: func(c context.Context, query string, args ...any) (postgres.SQLRows, error) {
+			if args[1] != "web" {
+				return &postgres.MockRows{}, nil
+			}
+			return &postgres.MockRows{
+				Values: [][]any{
+					{uuid.New().String(), "on_feature", nil, nil, nil, nil, 100, int64(1), nil, nil, nil, nil, nil, nil, nil},
+				},
+			}, nil
+		},
+	}
+	cache := &redis.Mock{Data: map[string]string{"feature_version": "3"}}
+
+	signer := SignService.NewForTest(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	s := NewForTest(ActivationValuesRepository.NewForTest(pg, nil, nil, VersionRepository.NewForTest(pg, cache, mock_log.New(true)), mock_log.New(true)), signer)
+
+	for _, name := range []string{"web", "random-1", "random-2"} {
+		if _, _, err := s.Evaluate(context.Background(), name, "user-1", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := s.snapshots["web"]; !ok || len(s.snapshots) != 1 {
+		t.Fatalf("only services with client-side features must be kept, got %d snapshots", len(s.snapshots))
+	}
+
+	// An expired snapshot is dropped on the next load even if its service is not asked for again
+	snap := s.snapshots["web"]
+	snap.loadedAt = snap.loadedAt.Add(-snapshotTTL)
+	s.snapshots["web"] = snap
+	if _, _, err := s.Evaluate(context.Background(), "random-3", "user-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.snapshots) != 0 {
+		t.Errorf("expired snapshots must be evicted, got %d", len(s.snapshots))
+	}
+}
@@ -0,0 +1,12 @@
+package EvaluationService
+
+import (
+	"context"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+type Interface interface {
+	// Evaluate resolves the client-side features of the service for one user and returns the result with its signed token
+	Evaluate(c context.Context, serviceName string, seed string, attributes map[string]string) (*dto.Evaluation, string, error)
+}
@@ -0,0 +1,143 @@
+package EvaluationService
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/SignService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
+)
+
+type Config struct {
+	// TTL bounds how long clients may trust a cached result
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// snapshotTTL bounds how long a snapshot is served while the global version stands still, rows read while a newer
+// version was on its way are replaced within it
+const snapshotTTL = time.Minute
+
+// snapshot is the client-side configuration of one service at a global version
+type snapshot struct {
+	version  int64
+	features []*dto.Feature
+	loadedAt time.Time
+}
+
+type Service struct {
+	service.Mock
+	activationValuesRepository ActivationValuesRepository.Interface
+	signService                SignService.Interface
+
+	config Config
+
+	mu        sync.Mutex
+	snapshots map[string]snapshot
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewForTest(activationValuesRepository ActivationValuesRepository.Interface, signService SignService.Interface) *Service {
+	return &Service{
+		activationValuesRepository: activationValuesRepository,
+		signService:                signService,
+		config:                     Config{TTL: time.Hour},
+		snapshots:                  make(map[string]snapshot),
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.TTL == 0 {
+		t.config.TTL = time.Hour
+	}
+
+	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
+	t.signService = app.GetModule(interfaces.ModuleService, names.SignService).(SignService.Interface)
+	t.snapshots = make(map[string]snapshot)
+
+	return nil
+}
+
+func (t *Service) Evaluate(c context.Context, serviceName string, seed string, attributes map[string]string) (*dto.Evaluation, string, error) {
+	snap, err := t.snapshot(c, serviceName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	result := &dto.Evaluation{
+		ServiceName: serviceName,
+		Subject:     seed,
+		Version:     snap.version,
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(t.config.TTL).Unix(),
+		Features:    make(map[string]bool, len(snap.features)),
+	}
+
+	for _, feature := range snap.features {
+		result.Features[feature.Name] = IsEnabled(feature, seed, attributes)
+	}
+
+	token, err := t.signService.Sign(result)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, token, nil
+}
+
+// snapshot reloads the service configuration when the global version moved or the snapshot expired
+func (t *Service) snapshot(c context.Context, serviceName string) (snapshot, error) {
+	version, _, err := t.activationValuesRepository.GetGlobalVersion(c)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	t.mu.Lock()
+	snap, ok := t.snapshots[serviceName]
+	t.mu.Unlock()
+
+	if ok && snap.version == version && time.Since(snap.loadedAt) < snapshotTTL {
+		return snap, nil
+	}
+
+	version, features, err := t.activationValuesRepository.GetClientSideByServiceName(c, serviceName)
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	snap = snapshot{version: version, features: features, loadedAt: time.Now()}
+
+	// Any name may come from the public endpoint: only services with client-side features are kept and expired
+	// snapshots are dropped, so the cache stays bounded by the real services
+	t.mu.Lock()
+	for name, old := range t.snapshots {
+		if snap.loadedAt.Sub(old.loadedAt) >= snapshotTTL {
+			delete(t.snapshots, name)
+		}
+	}
+	if len(features) > 0 {
+		t.snapshots[serviceName] = snap
+	}
+	t.mu.Unlock()
+
+	return snap, nil
+}
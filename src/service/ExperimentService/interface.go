@@ -0,0 +1,17 @@
+package ExperimentService
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+type Interface interface {
+	TrackExposures(c context.Context, events []*db.ExperimentExposure) error
+	TrackMetrics(c context.Context, events []*db.ExperimentMetric) error
+
+	// Analyze reads the exposures of the feature, featureName only labels the result
+	Analyze(c context.Context, featureId uuid.UUID, featureName string, metric string, control string) (*dto.ExperimentResult, error)
+}
@@ -0,0 +1,72 @@
+package ExperimentService
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ExperimentRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+)
+
+// Variant treated as the baseline when the caller does not choose one
+const defaultControl = "control"
+
+type Service struct {
+	service.Mock
+	experimentRepository ExperimentRepository.Interface
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, _ map[string]interface{}) error {
+	t.experimentRepository = app.GetModule(interfaces.ModuleRepository, names.ExperimentRepository).(ExperimentRepository.Interface)
+	return nil
+}
+
+func (t *Service) TrackExposures(c context.Context, events []*db.ExperimentExposure) error {
+	return t.experimentRepository.InsertExposures(c, events)
+}
+
+func (t *Service) TrackMetrics(c context.Context, events []*db.ExperimentMetric) error {
+	return t.experimentRepository.InsertMetrics(c, events)
+}
+
+func (t *Service) Analyze(c context.Context, featureId uuid.UUID, featureName string, metric string, control string) (*dto.ExperimentResult, error) {
+	stats, err := t.experimentRepository.GetVariantStats(c, featureId, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	if control == "" {
+		control = defaultControl
+	}
+
+	// Fall back to the first variant (ordered by name) when there is no explicit control group
+	found := false
+	for _, s := range stats {
+		if s.Variant == control {
+			found = true
+			break
+		}
+	}
+	if !found && len(stats) > 0 {
+		control = stats[0].Variant
+	}
+
+	return &dto.ExperimentResult{
+		FeatureName: featureName,
+		Metric:      metric,
+		Control:     control,
+		Variants:    Summarize(control, stats),
+	}, nil
+}
@@ -0,0 +1,197 @@
+package ExperimentService
+
+import (
+	"math"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+// Significance level used to flag a difference against the control variant
+const alpha = 0.05
+
+// Summarize computes per-variant rates and moments and compares every variant with the control one
+func Summarize(control string, stats []*db.ExperimentVariantStats) []dto.ExperimentVariant {
+	out := make([]dto.ExperimentVariant, 0, len(stats))
+
+	var base *db.ExperimentVariantStats
+	for _, s := range stats {
+		if s.Variant == control {
+			base = s
+			break
+		}
+	}
+
+	for _, s := range stats {
+		v := dto.ExperimentVariant{
+			Variant:     s.Variant,
+			Units:       s.Units,
+			Conversions: s.Conversions,
+		}
+
+		if s.Units > 0 {
+			v.ConversionRate = float64(s.Conversions) / float64(s.Units)
+			v.Mean = s.Sum / float64(s.Units)
+			v.StdDev = math.Sqrt(sampleVariance(s))
+		}
+
+		if base != nil && s != base {
+			v.ConversionTest = TwoProportionZTest(base.Conversions, base.Units, s.Conversions, s.Units)
+			v.MeanTest = WelchTTest(base, s)
+		}
+
+		out = append(out, v)
+	}
+
+	return out
+}
+
+// TwoProportionZTest compares conversion rates with a pooled two-sided z-test
+func TwoProportionZTest(conversionsA int64, unitsA int64, conversionsB int64, unitsB int64) *dto.SignificanceTest {
+	if unitsA == 0 || unitsB == 0 {
+		return nil
+	}
+
+	pA := float64(conversionsA) / float64(unitsA)
+	pB := float64(conversionsB) / float64(unitsB)
+	pooled := float64(conversionsA+conversionsB) / float64(unitsA+unitsB)
+
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(unitsA) + 1/float64(unitsB)))
+	if se == 0 {
+		return &dto.SignificanceTest{PValue: 1}
+	}
+
+	z := (pB - pA) / se
+	p := math.Erfc(math.Abs(z) / math.Sqrt2)
+
+	return &dto.SignificanceTest{
+		Statistic:   z,
+		PValue:      p,
+		Significant: p < alpha,
+	}
+}
+
+// WelchTTest compares per-unit metric means without assuming equal variances
+func WelchTTest(a *db.ExperimentVariantStats, b *db.ExperimentVariantStats) *dto.SignificanceTest {
+	if a.Units < 2 || b.Units < 2 {
+		return nil
+	}
+
+	nA, nB := float64(a.Units), float64(b.Units)
+	meanA, meanB := a.Sum/nA, b.Sum/nB
+	seA, seB := sampleVariance(a)/nA, sampleVariance(b)/nB
+
+	se := math.Sqrt(seA + seB)
+	if se == 0 {
+		return &dto.SignificanceTest{PValue: 1}
+	}
+
+	t := (meanB - meanA) / se
+	df := (seA + seB) * (seA + seB) / (seA*seA/(nA-1) + seB*seB/(nB-1))
+	p := studentTwoSidedP(t, df)
+
+	return &dto.SignificanceTest{
+		Statistic:        t,
+		DegreesOfFreedom: df,
+		PValue:           p,
+		Significant:      p < alpha,
+	}
+}
+
+func sampleVariance(s *db.ExperimentVariantStats) float64 {
+	if s.Units < 2 {
+		return 0
+	}
+
+	n := float64(s.Units)
+	mean := s.Sum / n
+	v := (s.SumSquares - n*mean*mean) / (n - 1)
+
+	// Guard against tiny negative values from floating point cancellation
+	if v < 0 {
+		return 0
+	}
+
+	return v
+}
+
+// studentTwoSidedP returns P(|T| > |t|) for Student's t distribution with df degrees of freedom
+func studentTwoSidedP(t float64, df float64) float64 {
+	x := df / (df + t*t)
+	return regularizedIncompleteBeta(df/2, 0.5, x)
+}
+
+// regularizedIncompleteBeta evaluates I_x(a, b) with the Lentz continued fraction
+func regularizedIncompleteBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lbeta, _ := math.Lgamma(a + b)
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	front := math.Exp(lbeta - la - lb + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges fast only below the mean, use the symmetry otherwise
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+	}
+
+	return front * betaContinuedFraction(a, b, x) / a
+}
+
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		// Even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// Odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+
+	return h
+}
@@ -0,0 +1,143 @@
+package ExperimentService
+
+import (
+	"math"
+	"testing"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+func almostEqual(a float64, b float64, eps float64) bool {
+	return math.Abs(a-b) <= eps
+}
+
+func TestStudentTwoSidedP(t *testing.T) {
+	tests := []struct {
+		name string
+		t    float64
+		df   float64
+		want float64
+	}{
+		{name: "zero statistic", t: 0, df: 5, want: 1},
+		{name: "cauchy", t: 1, df: 1, want: 0.5},
+		{name: "critical value df=10", t: 2.228, df: 10, want: 0.05},
+		{name: "critical value df=30", t: 2.042, df: 30, want: 0.05},
+		{name: "negative statistic", t: -2.228, df: 10, want: 0.05},
+		{name: "large df approaches normal", t: 1.96, df: 1e6, want: 0.05},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := studentTwoSidedP(tt.t, tt.df)
+			if !almostEqual(got, tt.want, 5e-4) {
+				t.Errorf("studentTwoSidedP(%v, %v) = %v, want %v", tt.t, tt.df, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoProportionZTest(t *testing.T) {
+	res := TwoProportionZTest(100, 1000, 120, 1000)
+	if !almostEqual(res.Statistic, 1.4286, 1e-3) {
+		t.Errorf("z = %v, want 1.4286", res.Statistic)
+	}
+	if !almostEqual(res.PValue, 0.1531, 1e-3) {
+		t.Errorf("p = %v, want 0.1531", res.PValue)
+	}
+	if res.Significant {
+		t.Errorf("expected not significant")
+	}
+
+	res = TwoProportionZTest(1000, 10000, 1200, 10000)
+	if !res.Significant || res.PValue > 1e-4 {
+		t.Errorf("expected significant difference, got p = %v", res.PValue)
+	}
+
+	if TwoProportionZTest(0, 0, 1, 10) != nil {
+		t.Errorf("expected nil for empty variant")
+	}
+
+	res = TwoProportionZTest(0, 10, 0, 10)
+	if res == nil || res.PValue != 1 || res.Significant {
+		t.Errorf("expected p = 1 without conversions, got %+v", res)
+	}
+}
+
+func statsOf(variant string, values ...float64) *db.ExperimentVariantStats {
+	s := &db.ExperimentVariantStats{Variant: variant, Units: int64(len(values))}
+	for _, v := range values {
+		s.Sum += v
+		s.SumSquares += v * v
+		if v != 0 {
+			s.Conversions++
+		}
+	}
+	return s
+}
+
+func TestWelchTTest(t *testing.T) {
+	// Reference values cross-checked by numerically integrating the Student t density
+	a := statsOf("control", 19.8, 20.4, 19.6, 17.8, 18.5, 18.9, 18.3, 18.9, 19.5, 22.0)
+	b := statsOf("treatment", 28.2, 26.6, 20.1, 23.3, 25.2, 22.1, 17.7, 27.6, 20.6, 13.7, 23.2, 17.5, 20.6, 18.0, 23.9, 21.6, 24.3, 20.4, 24.0, 13.2)
+
+	res := WelchTTest(a, b)
+	if !almostEqual(res.Statistic, 2.2192, 1e-3) {
+		t.Errorf("t = %v, want 2.2192", res.Statistic)
+	}
+	if !almostEqual(res.DegreesOfFreedom, 24.496, 1e-2) {
+		t.Errorf("df = %v, want 24.496", res.DegreesOfFreedom)
+	}
+	if !almostEqual(res.PValue, 0.0360, 1e-3) {
+		t.Errorf("p = %v, want 0.0360", res.PValue)
+	}
+	if !res.Significant {
+		t.Errorf("expected significant")
+	}
+
+	if WelchTTest(statsOf("a", 1), b) != nil {
+		t.Errorf("expected nil for a single unit")
+	}
+
+	res = WelchTTest(statsOf("a", 1, 1, 1), statsOf("b", 1, 1))
+	if res == nil || res.PValue != 1 {
+		t.Errorf("expected p = 1 for identical constant samples, got %+v", res)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	stats := []*db.ExperimentVariantStats{
+		statsOf("control", 0, 0, 1, 3),
+		statsOf("treatment", 2, 0, 4, 6),
+	}
+
+	out := Summarize("control", stats)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(out))
+	}
+
+	control := out[0]
+	if control.ConversionTest != nil || control.MeanTest != nil {
+		t.Errorf("control must not be compared with itself")
+	}
+	if control.ConversionRate != 0.5 || control.Mean != 1 {
+		t.Errorf("unexpected control summary %+v", control)
+	}
+	if !almostEqual(control.StdDev, math.Sqrt(2), 1e-9) {
+		t.Errorf("std dev = %v, want %v", control.StdDev, math.Sqrt(2))
+	}
+
+	treatment := out[1]
+	if treatment.ConversionRate != 0.75 || treatment.Mean != 3 {
+		t.Errorf("unexpected treatment summary %+v", treatment)
+	}
+	if treatment.ConversionTest == nil || treatment.MeanTest == nil {
+		t.Errorf("treatment must be compared with control")
+	}
+
+	out = Summarize("missing", stats)
+	for _, v := range out {
+		if v.ConversionTest != nil || v.MeanTest != nil {
+			t.Errorf("no comparison expected without control, got %+v", v)
+		}
+	}
+}
@@ -2,10 +2,35 @@ package FeatureService
 
 import (
 	"context"
+	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
 )
 
 type Interface interface {
 	GetNewFeature(c context.Context, serviceName string, lastVersion int64) (int64, []*dto.Feature)
+
+	// Snapshot returns the live configuration of the service without deletion markers, an unreadable configuration
+	// is an error rather than an empty one
+	Snapshot(c context.Context, serviceName string) (int64, []*dto.Feature, error)
+
+	// GetVersion returns the global version and its publish time, the time is zero if unknown
+	GetVersion(c context.Context) (int64, time.Time)
+
+	// Watch delivers every change newer than lastVersion to send until c is done or send fails. With heartbeat > 0
+	// send is also called without features once the stream stayed quiet that long, version is then the one
+	// the stream is up to date with. When the server starts draining reconnect is called once with a jittered
+	// delay and the stream goes on until the drain deadline, a nil reconnect ends it with ErrDraining right away.
+	// While draining new watches fail with ErrDraining
+	Watch(c context.Context, serviceName string, lastVersion int64, heartbeat time.Duration, reconnect func(version int64, after time.Duration) error, send func(version int64, features []*dto.Feature) error) error
+
+	// Ready is false once the server started draining
+	Ready() bool
+
+	// Drain asks open streams to reconnect elsewhere and waits until they are gone, streams still open
+	// after the drain timeout or once c is done are closed
+	Drain(c context.Context)
+
+	// Connected returns how many update streams of the service are open on this instance
+	Connected(serviceName string) int
 }
@@ -2,17 +2,46 @@ package FeatureService
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
 	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
 )
 
+const (
+	// How often subscribers check the global version for changes
+	watchInterval = time.Second
+	// How often a drain checks whether the streams are gone
+	drainPoll = 100 * time.Millisecond
+)
+
+var ErrDraining = errors.New("server is shutting down, reconnect to another instance")
+
 type Service struct {
 	service.Mock
 	activationValuesRepository ActivationValuesRepository.Interface
+	config                     Config
+
+	mu       sync.Mutex
+	watching map[string]int
+	open     int
+
+	// draining is closed when the drain starts, closed once its deadline passes and the remaining streams must end
+	drainOnce sync.Once
+	draining  chan struct{}
+	closed    chan struct{}
+}
+
+type Config struct {
+	// DrainTimeout is how long a shutdown waits for clients to leave, reconnect hints are spread over its first half
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
 }
 
 func New(name string) *Service {
@@ -20,16 +49,30 @@ func New(name string) *Service {
 		Mock: service.Mock{
 			NamePkg: name,
 		},
+		draining: make(chan struct{}),
+		closed:   make(chan struct{}),
 	}
 }
 
 func NewForTest(activationValuesRepository ActivationValuesRepository.Interface) *Service {
 	return &Service{
 		activationValuesRepository: activationValuesRepository,
+		draining:                   make(chan struct{}),
+		closed:                     make(chan struct{}),
 	}
 }
 
 func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.DrainTimeout == 0 {
+		t.config.DrainTimeout = 30 * time.Second
+	}
+
 	t.activationValuesRepository = app.GetModule(interfaces.ModuleRepository, names.ActivationValuesRepository).(ActivationValuesRepository.Interface)
 
 	return nil
@@ -43,3 +86,181 @@ func (t *Service) GetNewFeature(c context.Context, serviceName string, lastVersi
 
 	return version, features
 }
+
+func (t *Service) Snapshot(c context.Context, serviceName string) (int64, []*dto.Feature, error) {
+	// Polling treats an unknown version as nothing new, a snapshot must not
+	if _, _, err := t.activationValuesRepository.GetGlobalVersion(c); err != nil {
+		return 0, nil, err
+	}
+	version, features, err := t.activationValuesRepository.GetNewByServiceName(c, serviceName, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	live := make([]*dto.Feature, 0, len(features))
+	for _, feature := range features {
+		if feature.IsDeleted {
+			continue
+		}
+
+		keys := make([]dto.FeatureKey, 0, len(feature.Keys))
+		for _, key := range feature.Keys {
+			if key.IsDeleted {
+				continue
+			}
+
+			params := make([]dto.FeatureParam, 0, len(key.Params))
+			for _, param := range key.Params {
+				if !param.IsDeleted {
+					params = append(params, param)
+				}
+			}
+			key.Params = params
+			keys = append(keys, key)
+		}
+
+		f := *feature
+		f.Keys = keys
+		live = append(live, &f)
+	}
+
+	return version, live, nil
+}
+
+func (t *Service) GetVersion(c context.Context) (int64, time.Time) {
+	version, at, err := t.activationValuesRepository.GetGlobalVersion(c)
+	if err != nil {
+		return -1, time.Time{}
+	}
+
+	return version, at
+}
+
+func (t *Service) Watch(c context.Context, serviceName string, lastVersion int64, heartbeat time.Duration, reconnect func(version int64, after time.Duration) error, send func(version int64, features []*dto.Feature) error) error {
+	if !t.track(serviceName, 1) {
+		return ErrDraining
+	}
+	defer t.track(serviceName, -1)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	draining := t.draining
+	sentAt := time.Now()
+	for {
+		version, features := t.GetNewFeature(c, serviceName, lastVersion)
+
+		if len(features) > 0 {
+			if err := send(version, features); err != nil {
+				return err
+			}
+			sentAt = time.Now()
+		}
+
+		// Never move backwards, e.g. when the cached global version is missing
+		if version > lastVersion {
+			lastVersion = version
+		}
+
+		if heartbeat > 0 && time.Since(sentAt) >= heartbeat {
+			if err := send(lastVersion, nil); err != nil {
+				return err
+			}
+			sentAt = time.Now()
+		}
+
+		select {
+		case <-c.Done():
+			return nil
+		case <-t.closed:
+			return ErrDraining
+		case <-draining:
+			if reconnect == nil {
+				return ErrDraining
+			}
+			// Keep delivering until the client leaves, spread so the remaining instances are not hit at once
+			if err := reconnect(lastVersion, rand.N(t.config.DrainTimeout/2+1)); err != nil {
+				return err
+			}
+			draining = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Service) Ready() bool {
+	select {
+	case <-t.draining:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *Service) Drain(c context.Context) {
+	t.mu.Lock()
+	t.drainOnce.Do(func() { close(t.draining) })
+	t.mu.Unlock()
+
+	deadline := time.NewTimer(t.config.DrainTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+
+	// Once the streams are closed only the ticker is left to wait on, a fired channel would spin the loop
+	expired, done := deadline.C, c.Done()
+	for t.opened() > 0 {
+		select {
+		case <-expired:
+			t.close()
+			expired, done = nil, nil
+		case <-done:
+			t.close()
+			expired, done = nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Service) close() {
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+}
+
+func (t *Service) opened() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.open
+}
+
+func (t *Service) Connected(serviceName string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.watching[serviceName]
+}
+
+// track counts a stream in or out, new streams are refused once draining started
+func (t *Service) track(serviceName string, delta int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if delta > 0 && !t.Ready() {
+		return false
+	}
+	t.open += delta
+
+	if t.watching == nil {
+		t.watching = make(map[string]int)
+	}
+
+	t.watching[serviceName] += delta
+	if t.watching[serviceName] <= 0 {
+		delete(t.watching, serviceName)
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package FeatureService
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/ActivationValuesRepository"
+)
+
+type unchangedValues struct {
+	ActivationValuesRepository.Interface
+}
+
+func (t *unchangedValues) GetNewByServiceName(_ context.Context, _ string, lastVersion int64) (int64, []*dto.Feature, error) {
+	return lastVersion, nil, nil
+}
+
+func TestService_Drain(t *testing.T) {
+	s := NewForTest(&unchangedValues{})
+	s.config.DrainTimeout = 200 * time.Millisecond
+
+	hints := make(chan time.Duration, 1)
+	result := make(chan error, 1)
+	go func() {
+		result <- s.Watch(context.Background(), "billing", 5, 0, func(version int64, after time.Duration) error {
+			if version != 5 {
+				t.Errorf("reconnect must carry the stream version, got %d", version)
+			}
+			hints <- after
+			return nil
+		}, func(int64, []*dto.Feature) error { return nil })
+	}()
+	for s.Connected("billing") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	started := time.Now()
+	s.Drain(context.Background())
+
+	if s.Ready() {
+		t.Errorf("draining server must not be ready")
+	}
+	if after := <-hints; after < 0 || after > s.config.DrainTimeout/2 {
+		t.Errorf("reconnect hint must fall into the first half of the drain, got %v", after)
+	}
+	if err := <-result; !errors.Is(err, ErrDraining) {
+		t.Errorf("stream open past the deadline must end with ErrDraining, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < s.config.DrainTimeout {
+		t.Errorf("drain must wait for the client up to the deadline, returned after %v", elapsed)
+	}
+
+	err := s.Watch(context.Background(), "billing", 5, 0, nil, func(int64, []*dto.Feature) error { return nil })
+	if !errors.Is(err, ErrDraining) {
+		t.Errorf("new streams must be refused while draining, got %v", err)
+	}
+}
+
+func TestService_DrainEndsWhenClientsLeave(t *testing.T) {
+	s := NewForTest(&unchangedValues{})
+	s.config.DrainTimeout = time.Minute
+
+	c, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		// The client leaves as soon as it is asked to
+		result <- s.Watch(c, "billing", 5, 0, func(int64, time.Duration) error {
+			cancel()
+			return nil
+		}, func(int64, []*dto.Feature) error { return nil })
+	}()
+	for s.Connected("billing") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.Drain(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain must return once the streams are gone")
+	}
+	if err := <-result; err != nil {
+		t.Errorf("client that left must end cleanly, got %v", err)
+	}
+}
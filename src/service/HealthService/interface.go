@@ -0,0 +1,18 @@
+package HealthService
+
+import (
+	"context"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+)
+
+type Interface interface {
+	// Live reports whether the process should be kept running, dependency outages do not fail it:
+	// restarting the server would not bring Postgres or Redis back
+	Live(c context.Context) dto.HealthReport
+	// Ready reports whether the server should receive traffic: dependencies answer, the schema is migrated,
+	// the published version is not behind Postgres and the server is not draining
+	Ready(c context.Context) dto.HealthReport
+	// Details reports whether reports may be shown with their checks, bare statuses otherwise
+	Details() bool
+}
@@ -0,0 +1,152 @@
+package HealthService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/migrations"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/HealthRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
+)
+
+var errDraining = errors.New("server is draining")
+
+type Service struct {
+	service.Mock
+	config             Config
+	featureService     FeatureService.Interface
+	healthRepository   HealthRepository.Interface
+	versionRepository  VersionRepository.Interface
+	expectedMigrations int64
+}
+
+type Config struct {
+	// Details shows every check in the HTTP answers, they name the dependencies and their errors
+	Details bool `yaml:"details"`
+	// Timeout bounds each check, a hanging dependency fails it instead of the probe
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewForTest(featureService FeatureService.Interface, healthRepository HealthRepository.Interface, versionRepository VersionRepository.Interface, expectedMigrations int64) *Service {
+	return &Service{
+		config:             Config{Timeout: time.Second},
+		featureService:     featureService,
+		healthRepository:   healthRepository,
+		versionRepository:  versionRepository,
+		expectedMigrations: expectedMigrations,
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.Timeout == 0 {
+		t.config.Timeout = 2 * time.Second
+	}
+
+	t.featureService = app.GetModule(interfaces.ModuleService, names.FeatureService).(FeatureService.Interface)
+	// Relays have no database, only draining decides their readiness
+	t.healthRepository, _ = app.GetModule(interfaces.ModuleRepository, names.HealthRepository).(HealthRepository.Interface)
+	t.versionRepository, _ = app.GetModule(interfaces.ModuleRepository, names.VersionRepository).(VersionRepository.Interface)
+	t.expectedMigrations = migrations.Latest()
+
+	return nil
+}
+
+func (t *Service) Live(context.Context) dto.HealthReport {
+	return dto.HealthReport{Ok: true, Checks: make([]dto.HealthCheck, 0)}
+}
+
+func (t *Service) Ready(c context.Context) dto.HealthReport {
+	report := dto.HealthReport{Ok: true, Checks: make([]dto.HealthCheck, 0, 5)}
+
+	t.check(c, &report, "draining", func(context.Context) error {
+		if !t.featureService.Ready() {
+			return errDraining
+		}
+		return nil
+	})
+
+	if t.healthRepository != nil {
+		t.check(c, &report, "postgres", t.healthRepository.Ping)
+		if t.healthRepository.HasCache() {
+			t.check(c, &report, "redis", t.healthRepository.PingCache)
+		}
+		t.check(c, &report, "migrations", t.checkMigrations)
+	}
+
+	// The cached version is shared by the whole cluster and trails every commit until its publish: failing
+	// readiness on it would pull all replicas at once, it is only reported
+	if t.versionRepository != nil {
+		t.advise(c, &report, "version", t.versionRepository.Verify)
+	}
+
+	return report
+}
+
+func (t *Service) Details() bool {
+	return t.config.Details
+}
+
+func (t *Service) check(c context.Context, report *dto.HealthReport, name string, fn func(context.Context) error) {
+	result := t.run(c, name, fn)
+	if result.Error != "" {
+		report.Ok = false
+	}
+	report.Checks = append(report.Checks, result)
+}
+
+// advise reports a check without letting it fail the report
+func (t *Service) advise(c context.Context, report *dto.HealthReport, name string, fn func(context.Context) error) {
+	result := t.run(c, name, fn)
+	result.Advisory = true
+	report.Checks = append(report.Checks, result)
+}
+
+func (t *Service) run(c context.Context, name string, fn func(context.Context) error) dto.HealthCheck {
+	c, cancel := context.WithTimeout(c, t.config.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := fn(c)
+
+	result := dto.HealthCheck{Name: name, Duration: time.Since(started)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkMigrations fails while migrations shipped with the binary are not applied, a newer schema left by
+// the next release during a rollout is fine
+func (t *Service) checkMigrations(c context.Context) error {
+	applied, err := t.healthRepository.MigrationVersion(c)
+	if err != nil {
+		return err
+	}
+	if applied < t.expectedMigrations {
+		return fmt.Errorf("schema is at migration %d, the binary expects %d", applied, t.expectedMigrations)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package HealthService
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/dto"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/HealthRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/VersionRepository"
+	"gitlab.com/devpro_studio/FeatureChaos/src/service/FeatureService"
+)
+
+type fakeFeatures struct {
+	FeatureService.Interface
+	draining bool
+}
+
+func (t *fakeFeatures) Ready() bool { return !t.draining }
+
+type fakeStorage struct {
+	HealthRepository.Interface
+	pingErr   error
+	cache     bool
+	cacheErr  error
+	migration int64
+}
+
+func (t *fakeStorage) Ping(context.Context) error      { return t.pingErr }
+func (t *fakeStorage) PingCache(context.Context) error { return t.cacheErr }
+func (t *fakeStorage) HasCache() bool                  { return t.cache }
+func (t *fakeStorage) MigrationVersion(context.Context) (int64, error) {
+	return t.migration, t.pingErr
+}
+
+type fakeVersions struct {
+	VersionRepository.Interface
+	err error
+}
+
+func (t *fakeVersions) Verify(context.Context) error { return t.err }
+
+// failed lists the checks of the report that failed, advisory ones or the others
+func failed(report dto.HealthReport, advisory bool) []string {
+	names := make([]string, 0)
+	for _, check := range report.Checks {
+		if check.Error != "" && check.Advisory == advisory {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+func TestService_Ready(t *testing.T) {
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		features *fakeFeatures
+		storage  *fakeStorage
+		versions *fakeVersions
+		checks   int
+		failed   []string
+		// advised failed without taking the server out of rotation
+		advised []string
+	}{
+		{name: "healthy", features: &fakeFeatures{}, storage: &fakeStorage{cache: true, migration: 20}, versions: &fakeVersions{}, checks: 5},
+		{name: "without redis", features: &fakeFeatures{}, storage: &fakeStorage{migration: 20}, versions: &fakeVersions{}, checks: 4},
+		{name: "newer schema", features: &fakeFeatures{}, storage: &fakeStorage{migration: 30}, versions: &fakeVersions{}, checks: 4},
+		{name: "draining", features: &fakeFeatures{draining: true}, storage: &fakeStorage{migration: 20}, versions: &fakeVersions{}, checks: 4, failed: []string{"draining"}},
+		{name: "postgres down", features: &fakeFeatures{}, storage: &fakeStorage{pingErr: down}, versions: &fakeVersions{err: down}, checks: 4, failed: []string{"postgres", "migrations"}, advised: []string{"version"}},
+		{name: "redis down", features: &fakeFeatures{}, storage: &fakeStorage{cache: true, cacheErr: down, migration: 20}, versions: &fakeVersions{}, checks: 5, failed: []string{"redis"}},
+		{name: "pending migrations", features: &fakeFeatures{}, storage: &fakeStorage{migration: 10}, versions: &fakeVersions{}, checks: 4, failed: []string{"migrations"}},
+		{name: "version behind", features: &fakeFeatures{}, storage: &fakeStorage{migration: 20}, versions: &fakeVersions{err: errors.New("behind")}, checks: 4, advised: []string{"version"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewForTest(tt.features, tt.storage, tt.versions, 20).Ready(context.Background())
+
+			if len(report.Checks) != tt.checks {
+				t.Errorf("expected %d checks, got %+v", tt.checks, report.Checks)
+			}
+			got := failed(report, false)
+			if report.Ok != (len(tt.failed) == 0) || !slices.Equal(got, tt.failed) {
+				t.Fatalf("expected failed %v, got ok %v %v", tt.failed, report.Ok, got)
+			}
+			if advised := failed(report, true); !slices.Equal(advised, tt.advised) {
+				t.Errorf("expected advisory failures %v, got %v", tt.advised, advised)
+			}
+		})
+	}
+}
+
+func TestService_ReadyRelay(t *testing.T) {
+	// Relays have neither a database nor a version store
+	s := NewForTest(&fakeFeatures{}, nil, nil, 20)
+	if report := s.Ready(context.Background()); !report.Ok || len(report.Checks) != 1 {
+		t.Errorf("relay readiness is only about draining, got %+v", report)
+	}
+
+	if report := NewForTest(&fakeFeatures{draining: true}, nil, nil, 20).Live(context.Background()); !report.Ok {
+		t.Errorf("draining must not fail liveness")
+	}
+}
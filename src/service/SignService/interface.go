@@ -0,0 +1,10 @@
+package SignService
+
+import "crypto/ed25519"
+
+type Interface interface {
+	// Sign returns a compact JWS (EdDSA) carrying the JSON encoding of claims
+	Sign(claims any) (string, error)
+	// PublicKey returns the key id and the key that verifies Sign output
+	PublicKey() (string, ed25519.PublicKey)
+}
@@ -0,0 +1,78 @@
+package SignService
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// SignToken builds header.payload.signature, verifiable by any JWS library supporting EdDSA
+func SignToken(key ed25519.PrivateKey, keyId string, claims any) (string, error) {
+	h, err := json.Marshal(header{Alg: "EdDSA", Typ: "JWT", Kid: keyId})
+	if err != nil {
+		return "", err
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := b64.EncodeToString(h) + "." + b64.EncodeToString(p)
+	return signingInput + "." + b64.EncodeToString(ed25519.Sign(key, []byte(signingInput))), nil
+}
+
+// VerifyToken checks the signature and decodes the payload into claims
+func VerifyToken(key ed25519.PublicKey, token string, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrMalformedToken
+	}
+
+	rawHeader, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return ErrMalformedToken
+	}
+	var h header
+	if err := json.Unmarshal(rawHeader, &h); err != nil || h.Alg != "EdDSA" {
+		return ErrMalformedToken
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return ErrInvalidSignature
+	}
+
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return ErrMalformedToken
+	}
+
+	return json.Unmarshal(payload, claims)
+}
+
+// KeyId derives a stable identifier from the public key so rotated keys can be told apart
+func KeyId(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,90 @@
+package SignService
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testClaims struct {
+	Service string          `json:"service"`
+	Flags   map[string]bool `json:"flags"`
+}
+
+func testKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	return ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+}
+
+func TestSignVerify(t *testing.T) {
+	s := NewForTest(testKey(t))
+
+	token, err := s.Sign(testClaims{Service: "web", Flags: map[string]bool{"a": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(token, ".") != 2 {
+		t.Fatalf("expected compact JWS, got %q", token)
+	}
+
+	keyId, pub := s.PublicKey()
+	if keyId != KeyId(pub) {
+		t.Errorf("unexpected key id %q", keyId)
+	}
+
+	var out testClaims
+	if err := VerifyToken(pub, token, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Service != "web" || !out.Flags["a"] {
+		t.Errorf("unexpected claims %+v", out)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	s := NewForTest(testKey(t))
+	_, pub := s.PublicKey()
+
+	token, _ := s.Sign(testClaims{Service: "web"})
+	parts := strings.Split(token, ".")
+
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"service":"admin"}`)) + "." + parts[2]
+	if err := VerifyToken(pub, forged, &testClaims{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+
+	if err := VerifyToken(pub, "not-a-token", &testClaims{}); !errors.Is(err, ErrMalformedToken) {
+		t.Errorf("expected malformed token, got %v", err)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if err := VerifyToken(otherPub, token, &testClaims{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature for another key, got %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+
+	fromSeed, err := parseKey(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFull, err := parseKey(base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromSeed.Equal(fromFull) {
+		t.Errorf("seed and full key must give the same key")
+	}
+
+	if key, err := parseKey(""); key != nil || err != nil {
+		t.Errorf("empty config must fall back to a generated key")
+	}
+	if _, err := parseKey(base64.StdEncoding.EncodeToString([]byte("short"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected invalid key, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package SignService
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
+)
+
+var ErrInvalidKey = errors.New("private_key must be a base64 ed25519 seed (32 bytes) or private key (64 bytes)")
+
+type Config struct {
+	// PrivateKey is base64, without it a key is generated on start and signatures do not survive restarts
+	PrivateKey string `yaml:"private_key"`
+	KeyId      string `yaml:"key_id"`
+}
+
+type Service struct {
+	service.Mock
+	config Config
+
+	key   ed25519.PrivateKey
+	keyId string
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func NewForTest(key ed25519.PrivateKey) *Service {
+	return &Service{
+		key:   key,
+		keyId: KeyId(key.Public().(ed25519.PublicKey)),
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	key, err := parseKey(t.config.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	if key == nil {
+		app.GetLogger().Warn(context.Background(), "sign: private_key is not configured, using an ephemeral key")
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.key = key
+	t.keyId = t.config.KeyId
+	if t.keyId == "" {
+		t.keyId = KeyId(key.Public().(ed25519.PublicKey))
+	}
+
+	return nil
+}
+
+func parseKey(v string) (ed25519.PrivateKey, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+func (t *Service) Sign(claims any) (string, error) {
+	return SignToken(t.key, t.keyId, claims)
+}
+
+func (t *Service) PublicKey() (string, ed25519.PublicKey) {
+	return t.keyId, t.key.Public().(ed25519.PublicKey)
+}
@@ -1,9 +1,16 @@
 package StatsService
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Interface interface {
 	SetStat(c context.Context, serviceName string, featureName string)
 	IsUsed(c context.Context, featureName string) bool
 	IsServiceUsed(c context.Context, serviceName string) bool
+
+	// FeatureSeenAt and ServiceSeenAt return the last report while it still counts as use, false afterwards
+	FeatureSeenAt(c context.Context, featureName string) (time.Time, bool)
+	ServiceSeenAt(c context.Context, serviceName string) (time.Time, bool)
 }
@@ -2,6 +2,7 @@ package StatsService
 
 import (
 	"context"
+	"time"
 
 	"gitlab.com/devpro_studio/FeatureChaos/names"
 	"gitlab.com/devpro_studio/FeatureChaos/src/repository/StatsRepository"
@@ -38,3 +39,11 @@ func (t *Service) IsUsed(c context.Context, featureName string) bool {
 func (t *Service) IsServiceUsed(c context.Context, serviceName string) bool {
 	return t.statsRepository.IsServiceUsed(c, serviceName)
 }
+
+func (t *Service) FeatureSeenAt(c context.Context, featureName string) (time.Time, bool) {
+	return t.statsRepository.FeatureSeenAt(c, featureName)
+}
+
+func (t *Service) ServiceSeenAt(c context.Context, serviceName string) (time.Time, bool) {
+	return t.statsRepository.ServiceSeenAt(c, serviceName)
+}
@@ -3,6 +3,7 @@ package StatsService
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 type fakeStatsRepo struct {
@@ -18,6 +19,12 @@ func (f *fakeStatsRepo) SetStat(_ context.Context, serviceName string, featureNa
 
 func (f *fakeStatsRepo) IsUsed(_ context.Context, _ string) bool        { return false }
 func (f *fakeStatsRepo) IsServiceUsed(_ context.Context, _ string) bool { return false }
+func (f *fakeStatsRepo) FeatureSeenAt(_ context.Context, _ string) (time.Time, bool) {
+	return time.Time{}, false
+}
+func (f *fakeStatsRepo) ServiceSeenAt(_ context.Context, _ string) (time.Time, bool) {
+	return time.Time{}, false
+}
 
 func TestSetStatDelegation(t *testing.T) {
 	repo := &fakeStatsRepo{}
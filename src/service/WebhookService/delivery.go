@@ -0,0 +1,116 @@
+package WebhookService
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+const (
+	HeaderSignature = "X-FeatureChaos-Signature"
+	HeaderEvent     = "X-FeatureChaos-Event"
+	HeaderDelivery  = "X-FeatureChaos-Delivery"
+)
+
+const (
+	backoffBase = time.Second
+	backoffMax  = time.Hour
+)
+
+// Payload is the JSON body sent to subscribers
+type Payload struct {
+	EventId int64   `json:"event_id"`
+	Event   string  `json:"event"`
+	Feature string  `json:"feature"`
+	Key     *string `json:"key,omitempty"`
+	Param   *string `json:"param,omitempty"`
+	Before  *int    `json:"before"`
+	After   *int    `json:"after"`
+	// Service is set for bound and unbound events, PreviousName for renamed ones
+	Service      *string   `json:"service,omitempty"`
+	PreviousName *string   `json:"previous_name,omitempty"`
+	Version      int64     `json:"version"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewPayload(e *db.WebhookEvent) Payload {
+	return Payload{
+		EventId:      e.Id,
+		Event:        e.EventType,
+		Feature:      e.FeatureName,
+		Key:          e.KeyName,
+		Param:        e.ParamName,
+		Before:       e.Before,
+		After:        e.After,
+		Service:      e.ServiceName,
+		PreviousName: e.PreviousName,
+		Version:      e.Version,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+// Sign returns the signature header value: hex HMAC-SHA256 of the raw body keyed with the subscription secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is the delay before the next attempt: 1s, 2s, 4s... capped at one hour
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := backoffBase
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+
+	return d
+}
+
+// Send posts the signed event, any non-2xx answer counts as a failed attempt
+func Send(c context.Context, client *http.Client, d *db.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(NewPayload(&d.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, d.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.Id, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,155 @@
+package WebhookService
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/WebhookRepository"
+)
+
+func testDelivery(url string) *db.WebhookDelivery {
+	before, after := 10, 50
+	key := "region"
+	return &db.WebhookDelivery{
+		Id:      7,
+		Attempt: 1,
+		Url:     url,
+		Secret:  "s3cr3t",
+		Event: db.WebhookEvent{
+			Id:          42,
+			EventType:   db.WebhookEventUpdated,
+			FeatureName: "checkout",
+			KeyName:     &key,
+			Before:      &before,
+			After:       &after,
+			Version:     100,
+		},
+	}
+}
+
+func TestSendSignedPayload(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	status, err := Send(context.Background(), srv.Client(), testDelivery(srv.URL))
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v", status, err)
+	}
+
+	if got, want := gotHeader.Get(HeaderSignature), Sign("s3cr3t", gotBody); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if gotHeader.Get(HeaderEvent) != db.WebhookEventUpdated || gotHeader.Get(HeaderDelivery) != "7" {
+		t.Errorf("unexpected headers %v", gotHeader)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(gotBody, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.EventId != 42 || p.Feature != "checkout" || *p.Key != "region" || *p.Before != 10 || *p.After != 50 || p.Version != 100 {
+		t.Errorf("unexpected payload %+v", p)
+	}
+}
+
+func TestSendRejectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	status, err := Send(context.Background(), srv.Client(), testDelivery(srv.URL))
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Fatalf("expected failed attempt with 503, got %d, %v", status, err)
+	}
+}
+
+func TestSignKnownValue(t *testing.T) {
+	// echo -n 'hello' | openssl dgst -sha256 -hmac 'key'
+	want := "sha256=9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b"
+	if got := Sign("key", []byte("hello")); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 5, want: 16 * time.Second},
+		{attempt: 13, want: time.Hour},
+		{attempt: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+type fakeWebhookRepo struct {
+	WebhookRepository.Interface
+	status int
+	err    error
+	next   *time.Time
+}
+
+func (f *fakeWebhookRepo) RecordAttempt(_ context.Context, _ *db.WebhookDelivery, statusCode int, deliveryErr error, _ time.Duration, nextAttemptAt *time.Time) error {
+	f.status, f.err, f.next = statusCode, deliveryErr, nextAttemptAt
+	return nil
+}
+
+func TestDeliverSchedulesRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	repo := &fakeWebhookRepo{}
+	svc := &Service{webhookRepository: repo, client: srv.Client(), config: Config{MaxAttempts: 3}}
+
+	d := testDelivery(srv.URL)
+	svc.deliver(context.Background(), d)
+	if repo.err == nil || repo.status != http.StatusInternalServerError || repo.next == nil {
+		t.Fatalf("expected a retry to be scheduled, got %+v", repo)
+	}
+
+	d.Attempt = 3
+	svc.deliver(context.Background(), d)
+	if repo.err == nil || repo.next != nil {
+		t.Fatalf("expected delivery to give up after max attempts, got %+v", repo)
+	}
+}
+
+func TestDeliverSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	repo := &fakeWebhookRepo{}
+	svc := &Service{webhookRepository: repo, client: srv.Client(), config: Config{MaxAttempts: 3}}
+
+	svc.deliver(context.Background(), testDelivery(srv.URL))
+	if repo.err != nil || repo.status != http.StatusOK || repo.next != nil {
+		t.Fatalf("expected delivered attempt, got %+v", repo)
+	}
+}
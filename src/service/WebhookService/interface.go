@@ -0,0 +1,17 @@
+package WebhookService
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+)
+
+type Interface interface {
+	ListWebhooks(c context.Context, projectId uuid.UUID) ([]*db.Webhook, error)
+	CreateWebhook(c context.Context, hook *db.Webhook) (uuid.UUID, error)
+	UpdateWebhook(c context.Context, hook *db.Webhook) error
+	DeleteWebhook(c context.Context, id uuid.UUID) error
+
+	ListDeliveryLog(c context.Context, webhookId uuid.UUID, limit int) ([]*db.WebhookDeliveryLog, error)
+}
@@ -0,0 +1,156 @@
+package WebhookService
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/devpro_studio/FeatureChaos/names"
+	"gitlab.com/devpro_studio/FeatureChaos/src/model/db"
+	"gitlab.com/devpro_studio/FeatureChaos/src/repository/WebhookRepository"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/interfaces"
+	"gitlab.com/devpro_studio/Paranoia/paranoia/service"
+	"gitlab.com/devpro_studio/go_utils/decode"
+)
+
+const batchSize = 100
+
+type Config struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+}
+
+type Service struct {
+	service.Mock
+	logger            interfaces.ILogger
+	webhookRepository WebhookRepository.Interface
+	client            *http.Client
+
+	config Config
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(name string) *Service {
+	return &Service{
+		Mock: service.Mock{
+			NamePkg: name,
+		},
+	}
+}
+
+func (t *Service) Init(app interfaces.IEngine, cfg map[string]interface{}) error {
+	t.logger = app.GetLogger()
+	t.webhookRepository = app.GetModule(interfaces.ModuleRepository, names.WebhookRepository).(WebhookRepository.Interface)
+
+	if len(cfg) > 0 {
+		if err := decode.Decode(cfg, &t.config, "yaml", decode.DecoderStrongFoundDst); err != nil {
+			return err
+		}
+	}
+
+	if t.config.PollInterval == 0 {
+		t.config.PollInterval = time.Second
+	}
+	if t.config.Timeout == 0 {
+		t.config.Timeout = 5 * time.Second
+	}
+	if t.config.MaxAttempts == 0 {
+		t.config.MaxAttempts = 10
+	}
+
+	t.client = &http.Client{Timeout: t.config.Timeout}
+
+	c, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wg.Add(1)
+	go t.run(c)
+
+	return nil
+}
+
+func (t *Service) Stop() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+	return nil
+}
+
+func (t *Service) ListWebhooks(c context.Context, projectId uuid.UUID) ([]*db.Webhook, error) {
+	return t.webhookRepository.ListWebhooks(c, projectId)
+}
+
+func (t *Service) CreateWebhook(c context.Context, hook *db.Webhook) (uuid.UUID, error) {
+	return t.webhookRepository.CreateWebhook(c, hook)
+}
+
+func (t *Service) UpdateWebhook(c context.Context, hook *db.Webhook) error {
+	return t.webhookRepository.UpdateWebhook(c, hook)
+}
+
+func (t *Service) DeleteWebhook(c context.Context, id uuid.UUID) error {
+	return t.webhookRepository.DeleteWebhook(c, id)
+}
+
+func (t *Service) ListDeliveryLog(c context.Context, webhookId uuid.UUID, limit int) ([]*db.WebhookDeliveryLog, error) {
+	return t.webhookRepository.ListDeliveryLog(c, webhookId, limit)
+}
+
+// run polls the outbox; several instances may run it, rows are claimed with SKIP LOCKED
+func (t *Service) run(c context.Context) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			t.tick(c)
+		}
+	}
+}
+
+func (t *Service) tick(c context.Context) {
+	for {
+		n, err := t.webhookRepository.Dispatch(c, batchSize)
+		if err != nil || n < batchSize {
+			break
+		}
+	}
+
+	// The lease outlives one request so a crashed worker only delays the delivery
+	deliveries, err := t.webhookRepository.ClaimDue(c, batchSize, time.Now().Add(2*t.config.Timeout))
+	if err != nil {
+		return
+	}
+
+	for _, d := range deliveries {
+		if c.Err() != nil {
+			return
+		}
+		t.deliver(c, d)
+	}
+}
+
+func (t *Service) deliver(c context.Context, d *db.WebhookDelivery) {
+	started := time.Now()
+	status, err := Send(c, t.client, d)
+	duration := time.Since(started)
+
+	var next *time.Time
+	if err != nil && d.Attempt < t.config.MaxAttempts {
+		at := time.Now().Add(Backoff(d.Attempt))
+		next = &at
+	}
+
+	if recErr := t.webhookRepository.RecordAttempt(c, d, status, err, duration, next); recErr != nil {
+		t.logger.Error(c, recErr)
+	}
+}